@@ -0,0 +1,46 @@
+// Package main
+// Wrote by yijian on 2026/10/18
+// mooonerrcode 输出 mooonerror 中登记的所有错误码的目录，供 API 文档和网关配置使用
+// 用法：
+//
+//	mooonerrcode -format=markdown -o ERRCODES.md
+//	mooonerrcode -format=json
+//
+// 在 mooonerror 目录下执行 go generate 即可重新生成 mooonerror/ERRCODES.md
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/eyjian/gomooon/mooonerror"
+)
+
+var (
+	format = flag.String("format", "markdown", "Format of the catalog: markdown or json")
+	output = flag.String("o", "", "Output file, default to stdout")
+)
+
+func main() {
+	flag.Parse()
+
+	var buf bytes.Buffer
+	if *format == "markdown" {
+		buf.WriteString("# 错误码\n\n本文件由 cmd/mooonerrcode 生成，请勿手工修改。\n\n")
+	}
+	if err := mooonerror.WriteCatalog(&buf, *format); err != nil {
+		fmt.Fprintf(os.Stderr, "write catalog error: %s\n", err.Error())
+		os.Exit(1)
+	}
+
+	if *output == "" {
+		os.Stdout.Write(buf.Bytes())
+		return
+	}
+	if err := os.WriteFile(*output, buf.Bytes(), 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "write %s error: %s\n", *output, err.Error())
+		os.Exit(1)
+	}
+}
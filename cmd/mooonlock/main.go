@@ -0,0 +1,152 @@
+// Package main
+// Wrote by yijian on 2026/10/18
+// mooonlock 查看和强制释放 mooonredis 的分布式锁
+// 用法：
+//
+//	mooonlock -addr=127.0.0.1:6379 list job:            列出前缀为 job: 的锁
+//	mooonlock -addr=127.0.0.1:6379 show job:report      查看锁的持有者、剩余有效时间和获取时间
+//	mooonlock -addr=127.0.0.1:6379 -operator=yijian -reason="pod crashed" release job:report
+//	mooonlock -addr=127.0.0.1:6379 audit                列出最近的强制释放记录
+//
+// 集群模式：-addr 以逗号分隔多个节点地址
+// release 只释放一个 key：多 key 的 SimDistLock 需逐个释放，Redlock 需对每个实例（-addr）分别释放
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/eyjian/gomooon/mooonredis"
+	"github.com/go-redis/redis/v8"
+)
+
+var (
+	addr     = flag.String("addr", "127.0.0.1:6379", "Redis address, separated by comma in cluster mode")
+	password = flag.String("password", "", "Redis password")
+	db       = flag.Int("db", 0, "Redis database")
+	operator = flag.String("operator", os.Getenv("USER"), "Operator recorded in the audit log of release")
+	reason   = flag.String("reason", "", "Reason recorded in the audit log of release")
+	n        = flag.Int64("n", 20, "Number of audit records to list")
+	timeout  = flag.Duration("timeout", 10*time.Second, "Timeout of the whole command")
+)
+
+func main() {
+	flag.Usage = usage
+	flag.Parse()
+	args := flag.Args()
+	if len(args) == 0 {
+		usage()
+		os.Exit(2)
+	}
+
+	client := redis.NewUniversalClient(&redis.UniversalOptions{
+		Addrs:    strings.Split(*addr, ","),
+		Password: *password,
+		DB:       *db,
+	})
+	defer client.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	var err error
+	switch {
+	case args[0] == "list" && len(args) == 2:
+		err = listLocks(ctx, client, args[1])
+	case args[0] == "show" && len(args) == 2:
+		err = showLock(ctx, client, args[1])
+	case args[0] == "release" && len(args) == 2:
+		err = releaseLock(ctx, client, args[1])
+	case args[0] == "audit" && len(args) == 1:
+		err = listAudits(ctx, client)
+	default:
+		usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s error: %s\n", args[0], err.Error())
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: %s [flags] list <prefix> | show <key> | release <key> | audit\n", os.Args[0])
+	flag.PrintDefaults()
+}
+
+func listLocks(ctx context.Context, client redis.UniversalClient, prefix string) error {
+	locks, err := mooonredis.ListLocks(ctx, client, prefix)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "KEY\tTYPE\tHOLDERS\tTTL\tACQUIRED_AT")
+	for _, lock := range locks {
+		printLock(w, lock)
+	}
+	return w.Flush()
+}
+
+func showLock(ctx context.Context, client redis.UniversalClient, key string) error {
+	lock, err := mooonredis.GetLockInfo(ctx, client, key)
+	if err != nil {
+		return err
+	}
+	if lock == nil {
+		return fmt.Errorf("lock %s not found", key)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "KEY\tTYPE\tHOLDERS\tTTL\tACQUIRED_AT")
+	printLock(w, lock)
+	return w.Flush()
+}
+
+func releaseLock(ctx context.Context, client redis.UniversalClient, key string) error {
+	if *operator == "" || *reason == "" {
+		return fmt.Errorf("-operator and -reason are required for the audit log")
+	}
+
+	lock, err := mooonredis.ForceRelease(ctx, client, key, *operator, *reason)
+	if err != nil {
+		return err
+	}
+	if lock == nil {
+		return fmt.Errorf("lock %s not found", key)
+	}
+	fmt.Printf("released %s held by %s\n", key, strings.Join(lock.Holders, ","))
+	return nil
+}
+
+func listAudits(ctx context.Context, client redis.UniversalClient) error {
+	audits, err := mooonredis.ListLockAudits(ctx, client, *n)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "RELEASED_AT\tOPERATOR\tKEY\tHOLDERS\tREASON")
+	for _, audit := range audits {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+			audit.ReleasedAt.Format(time.DateTime), audit.Operator,
+			audit.Lock.Key, strings.Join(audit.Lock.Holders, ","), audit.Reason)
+	}
+	return w.Flush()
+}
+
+func printLock(w *tabwriter.Writer, lock *mooonredis.LockInfo) {
+	ttl := "never"
+	if lock.TTL >= 0 {
+		ttl = lock.TTL.Round(time.Millisecond).String()
+	}
+	acquiredAt := "-"
+	if !lock.AcquiredAt.IsZero() {
+		acquiredAt = lock.AcquiredAt.Format(time.DateTime)
+	}
+	fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", lock.Key, lock.Type, strings.Join(lock.Holders, ","), ttl, acquiredAt)
+}
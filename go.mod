@@ -12,6 +12,8 @@ require (
 	github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/faceid v1.0.1041
 	github.com/tjfoc/gmsm v1.4.1
 	github.com/wechatpay-apiv3/wechatpay-go v0.2.20
+	go.opentelemetry.io/otel v1.37.0
+	go.opentelemetry.io/otel/metric v1.37.0
 	go.opentelemetry.io/otel/trace v1.37.0
 	golang.org/x/crypto v0.38.0
 	golang.org/x/text v0.25.0
@@ -38,7 +40,6 @@ require (
 	github.com/rogpeppe/go-internal v1.13.1 // indirect
 	github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/ocr v1.3.98 // indirect
 	github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/ses v1.3.106 // indirect
-	go.opentelemetry.io/otel v1.37.0 // indirect
 	golang.org/x/image v0.27.0 // indirect
 	golang.org/x/net v0.40.0 // indirect
 	golang.org/x/sys v0.33.0 // indirect
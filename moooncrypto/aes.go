@@ -4,7 +4,7 @@ package moooncrypto
 
 import (
     "bytes"
-    "fmt"
+    "github.com/eyjian/gomooon/mooonerror"
     "strings"
 )
 
@@ -37,7 +37,7 @@ func pkcs7UnPadding(data []byte) ([]byte, error) {
     length := len(data)
     unpadding := int(data[length-1])
     if unpadding > length {
-        return nil, fmt.Errorf("invalid pkcs7 padding")
+        return nil, mooonerror.Errorf(mooonerror.ErrCodeCryptoInvalidData, "invalid pkcs7 padding")
     }
     return data[:(length - unpadding)], nil
 }
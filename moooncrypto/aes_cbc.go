@@ -6,8 +6,7 @@ import (
     "crypto/aes"
     "crypto/cipher"
     "encoding/base64"
-    "errors"
-    "fmt"
+    "github.com/eyjian/gomooon/mooonerror"
 )
 
 // 在 CBC 模式下，每一个明文分组先与前一个密文分组进行异或操作，再进行加密。
@@ -19,14 +18,14 @@ import (
 func AesCBCEncryptText(key, data string) (string, error) {
     keyLen := len(key)
     if keyLen > 256 {
-        return "", fmt.Errorf("length of CBC encrypt key exceeds 256")
+        return "", mooonerror.Errorf(mooonerror.ErrCodeCryptoInvalidKey, "length of CBC encrypt key exceeds 256")
     }
 
     // 创建 AES 分组密码的实例
     keyBytes := []byte(padToLength(key))
     block, err := aes.NewCipher(keyBytes)
     if err != nil {
-        return "", fmt.Errorf("new CBC encrypt cipher error: %s", err.Error())
+        return "", mooonerror.Errorf(mooonerror.ErrCodeCryptoInvalidKey, "new CBC encrypt cipher error: %w", err)
     }
 
     // 创建分组模式（这里使用 CBC 模式）
@@ -39,7 +38,7 @@ func AesCBCEncryptText(key, data string) (string, error) {
     // 加密
     paddedPlaintextLen := len(paddedPlaintext)
     if paddedPlaintextLen%aes.BlockSize != 0 {
-        return "", errors.New("plaintext format data")
+        return "", mooonerror.NewError(mooonerror.ErrCodeCryptoInvalidData, "plaintext format data")
     }
     ciphertext := make([]byte, paddedPlaintextLen)
     mode.CryptBlocks(ciphertext, paddedPlaintext)
@@ -54,14 +53,14 @@ func AesCBCEncryptText(key, data string) (string, error) {
 func AesCBCDecryptText(key, data string) (string, error) {
     keyLen := len(key)
     if keyLen > 256 {
-        return "", fmt.Errorf("length of CBC decrypt key exceeds 256")
+        return "", mooonerror.Errorf(mooonerror.ErrCodeCryptoInvalidKey, "length of CBC decrypt key exceeds 256")
     }
 
     // 创建 AES 分组密码的实例
     keyBytes := []byte(padToLength(key))
     block, err := aes.NewCipher(keyBytes)
     if err != nil {
-        return "", fmt.Errorf("new CBC decrypt cipher error: %s", err.Error())
+        return "", mooonerror.Errorf(mooonerror.ErrCodeCryptoInvalidKey, "new CBC decrypt cipher error: %w", err)
     }
 
     // 创建分组模式（这里使用 CBC 模式）
@@ -71,13 +70,13 @@ func AesCBCDecryptText(key, data string) (string, error) {
     // 将加密后的密文转换为字节数组
     ciphertext, err := base64.StdEncoding.DecodeString(data)
     if err != nil {
-        return "", fmt.Errorf("CBC decrypt base64 decode error: %s", err.Error())
+        return "", mooonerror.Errorf(mooonerror.ErrCodeCryptoInvalidData, "CBC decrypt base64 decode error: %w", err)
     }
 
     // 解密
     ciphertextLen := len(ciphertext)
     if ciphertextLen%aes.BlockSize != 0 {
-        return "", errors.New("ciphertext format data")
+        return "", mooonerror.NewError(mooonerror.ErrCodeCryptoInvalidData, "ciphertext format data")
     }
     paddedPlaintext := make([]byte, ciphertextLen)
     mode.CryptBlocks(paddedPlaintext, ciphertext)
@@ -85,7 +84,7 @@ func AesCBCDecryptText(key, data string) (string, error) {
     // 去除填充
     plaintext, err := pkcs7UnPadding(paddedPlaintext)
     if err != nil {
-        return "", fmt.Errorf("CBC decrypt %s", err.Error())
+        return "", mooonerror.Errorf(mooonerror.ErrCodeCryptoInvalidData, "CBC decrypt %w", err)
     }
 
     return string(plaintext), nil
@@ -6,7 +6,7 @@ import (
     "crypto/aes"
     "crypto/cipher"
     "encoding/hex"
-    "fmt"
+    "github.com/eyjian/gomooon/mooonerror"
 )
 
 // 与 ECB 和 CBC 模式只能够加密块数据不同，CFB 能够将块密文（Block Cipher）转换为流密文（Stream Cipher）。
@@ -17,7 +17,7 @@ import (
 func AesCFBEncryptText(key, data string) (string, error) {
     keyLen := len(key)
     if keyLen > 256 {
-        return "", fmt.Errorf("length of CFB encrypt key exceeds 256")
+        return "", mooonerror.Errorf(mooonerror.ErrCodeCryptoInvalidKey, "length of CFB encrypt key exceeds 256")
     }
 
     // 创建 AES 分组密码的实例
@@ -26,7 +26,7 @@ func AesCFBEncryptText(key, data string) (string, error) {
     // 创建 AES 密钥
     block, err := aes.NewCipher(keyBytes)
     if err != nil {
-        return "", fmt.Errorf("new CFB encrypt cipher error: %s", err.Error())
+        return "", mooonerror.Errorf(mooonerror.ErrCodeCryptoInvalidKey, "new CFB encrypt cipher error: %w", err)
     }
 
     // 初始化向量
@@ -52,7 +52,7 @@ func AesCFBEncryptText(key, data string) (string, error) {
 func AesCFBDecryptText(key, data string) (string, error) {
     keyLen := len(key)
     if keyLen > 256 {
-        return "", fmt.Errorf("length of CFB decrypt key exceeds 256")
+        return "", mooonerror.Errorf(mooonerror.ErrCodeCryptoInvalidKey, "length of CFB decrypt key exceeds 256")
     }
 
     // 创建 AES 分组密码的实例
@@ -61,7 +61,7 @@ func AesCFBDecryptText(key, data string) (string, error) {
     // 创建 AES 密钥
     block, err := aes.NewCipher(keyBytes)
     if err != nil {
-        return "", fmt.Errorf("new CFB decrypt cipher error: %s", err.Error())
+        return "", mooonerror.Errorf(mooonerror.ErrCodeCryptoInvalidKey, "new CFB decrypt cipher error: %w", err)
     }
 
     // 初始化向量
@@ -0,0 +1,60 @@
+// Package moooncrypto
+// Wrote by yijian on 2026/10/18
+package moooncrypto
+
+import (
+    "crypto/aes"
+    "crypto/cipher"
+    "encoding/base64"
+    "github.com/eyjian/gomooon/mooonerror"
+)
+
+// GCM 模式是带认证的加密（AEAD），在 CTR 模式加密的同时计算认证标签，解密时校验密文和附加数据（associated data）未被篡改。
+// 微信支付 APIv3 的平台证书和回调报文即使用 AEAD_AES_256_GCM 加密，密钥为 32 字节的 APIv3 密钥。
+
+// AesGCMEncryptText 加密文本，返回 Base64 编码的密文（含 16 字节的认证标签）
+// key 长度只能为 16、24 或 32 字节，nonce 长度为 12 字节，associatedData 可为空
+func AesGCMEncryptText(key, nonce, associatedData, data string) (string, error) {
+    aead, err := newAesGCM(key, nonce)
+    if err != nil {
+        return "", err
+    }
+    ciphertext := aead.Seal(nil, []byte(nonce), []byte(data), []byte(associatedData))
+    return base64.StdEncoding.EncodeToString(ciphertext), nil
+}
+
+// AesGCMDecryptText 解密 Base64 编码的密文，参数同 AesGCMEncryptText
+func AesGCMDecryptText(key, nonce, associatedData, data string) (string, error) {
+    aead, err := newAesGCM(key, nonce)
+    if err != nil {
+        return "", err
+    }
+
+    // 将加密后的密文转换为字节数组
+    ciphertext, err := base64.StdEncoding.DecodeString(data)
+    if err != nil {
+        return "", mooonerror.Errorf(mooonerror.ErrCodeCryptoInvalidData, "GCM decrypt base64 decode error: %w", err)
+    }
+
+    // 解密并校验认证标签
+    plaintext, err := aead.Open(nil, []byte(nonce), ciphertext, []byte(associatedData))
+    if err != nil {
+        return "", mooonerror.Errorf(mooonerror.ErrCodeCryptoInvalidData, "GCM decrypt error: %w", err)
+    }
+    return string(plaintext), nil
+}
+
+func newAesGCM(key, nonce string) (cipher.AEAD, error) {
+    block, err := aes.NewCipher([]byte(key))
+    if err != nil {
+        return nil, mooonerror.Errorf(mooonerror.ErrCodeCryptoInvalidKey, "new GCM cipher error: %w", err)
+    }
+    aead, err := cipher.NewGCM(block)
+    if err != nil {
+        return nil, mooonerror.Errorf(mooonerror.ErrCodeCryptoInvalidKey, "new GCM error: %w", err)
+    }
+    if len(nonce) != aead.NonceSize() {
+        return nil, mooonerror.Errorf(mooonerror.ErrCodeCryptoInvalidData, "length of GCM nonce must be %d", aead.NonceSize())
+    }
+    return aead, nil
+}
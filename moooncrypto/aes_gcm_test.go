@@ -0,0 +1,34 @@
+// Package moooncrypto
+// Wrote by yijian on 2026/10/18
+package moooncrypto
+
+import "testing"
+
+// go test -v -run="TestAesGCMText"
+func TestAesGCMText(t *testing.T) {
+    key := "0123456789abcdef0123456789abcdef" // 同微信支付的 APIv3 密钥，32 字节
+    nonce := "0123456789ab"
+    associatedData := "certificate"
+    data := "gomooon"
+
+    ciphertext, err := AesGCMEncryptText(key, nonce, associatedData, data)
+    if err != nil {
+        t.Fatalf("encrypt error: %s\n", err.Error())
+    }
+    plaintext, err := AesGCMDecryptText(key, nonce, associatedData, ciphertext)
+    if err != nil {
+        t.Fatalf("decrypt error: %s\n", err.Error())
+    }
+    if plaintext != data {
+        t.Errorf("decrypt mismatch: %s\n", plaintext)
+    }
+
+    // 附加数据不一致时认证失败
+    if _, err = AesGCMDecryptText(key, nonce, "other", ciphertext); err == nil {
+        t.Error("decrypt with wrong associated data should fail")
+    }
+    // nonce 长度不正确
+    if _, err = AesGCMEncryptText(key, "short", associatedData, data); err == nil {
+        t.Error("encrypt with short nonce should fail")
+    }
+}
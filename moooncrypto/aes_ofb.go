@@ -6,7 +6,7 @@ import (
     "crypto/aes"
     "crypto/cipher"
     "encoding/hex"
-    "fmt"
+    "github.com/eyjian/gomooon/mooonerror"
 )
 
 // 在 OFB 模式下，先用块加密器生成密钥流（keystream），然后再将密钥流与明文流异或得到密文流，
@@ -17,7 +17,7 @@ import (
 func AesOFBEncryptText(key, data string) (string, error) {
     keyLen := len(key)
     if keyLen > 256 {
-        return "", fmt.Errorf("length of OFB encrypt key exceeds 256")
+        return "", mooonerror.Errorf(mooonerror.ErrCodeCryptoInvalidKey, "length of OFB encrypt key exceeds 256")
     }
 
     // 创建 AES 分组密码的实例
@@ -26,7 +26,7 @@ func AesOFBEncryptText(key, data string) (string, error) {
     // 创建 AES 密钥
     block, err := aes.NewCipher(keyBytes)
     if err != nil {
-        return "", fmt.Errorf("new OFB encrypt cipher error: %s", err.Error())
+        return "", mooonerror.Errorf(mooonerror.ErrCodeCryptoInvalidKey, "new OFB encrypt cipher error: %w", err)
     }
 
     // 初始化向量
@@ -52,7 +52,7 @@ func AesOFBEncryptText(key, data string) (string, error) {
 func AesOFBDecryptText(key, data string) (string, error) {
     keyLen := len(key)
     if keyLen > 256 {
-        return "", fmt.Errorf("length of OFB decrypt key exceeds 256")
+        return "", mooonerror.Errorf(mooonerror.ErrCodeCryptoInvalidKey, "length of OFB decrypt key exceeds 256")
     }
 
     // 创建 AES 分组密码的实例
@@ -61,7 +61,7 @@ func AesOFBDecryptText(key, data string) (string, error) {
     // 创建 AES 密钥
     block, err := aes.NewCipher(keyBytes)
     if err != nil {
-        return "", fmt.Errorf("new OFB decrypt cipher error: %s", err.Error())
+        return "", mooonerror.Errorf(mooonerror.ErrCodeCryptoInvalidKey, "new OFB decrypt cipher error: %w", err)
     }
 
     // 初始化向量
@@ -77,7 +77,7 @@ func AesOFBDecryptText(key, data string) (string, error) {
     // 解密密文
     ciphertext, err := hex.DecodeString(data)
     if err != nil {
-        return "", fmt.Errorf("OFB decrypt decode error: %s", err.Error())
+        return "", mooonerror.Errorf(mooonerror.ErrCodeCryptoInvalidData, "OFB decrypt decode error: %w", err)
     }
 
     plaintext := make([]byte, len(ciphertext))
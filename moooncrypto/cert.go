@@ -6,8 +6,7 @@ import (
     "crypto/rsa"
     "crypto/x509"
     "encoding/pem"
-    "errors"
-    "fmt"
+    "github.com/eyjian/gomooon/mooonerror"
     "golang.org/x/crypto/pkcs12"
     "golang.org/x/crypto/ssh"
     "io"
@@ -36,13 +35,13 @@ func GetCertInfo(certPEM string) (*CertInfo, error) {
     // 解码 PEM 格式的证书
     block, _ := pem.Decode([]byte(certPEM))
     if block == nil {
-        return nil, fmt.Errorf("failed to decode PEM block")
+        return nil, mooonerror.Errorf(mooonerror.ErrCodeCryptoCert, "failed to decode PEM block")
     }
 
     // 解析 X.509 证书
     cert, err := x509.ParseCertificate(block.Bytes)
     if err != nil {
-        return nil, fmt.Errorf("failed to parse X.509 certificate: %s", err.Error())
+        return nil, mooonerror.Errorf(mooonerror.ErrCodeCryptoCert, "failed to parse X.509 certificate: %w", err)
     }
 
     return &CertInfo{
@@ -84,13 +83,13 @@ func ExtractCertAndKeyFromP12(p12Data []byte, password string) (string, string,
     // 解码 p12 文件
     privateKeyInf, cert, err := pkcs12.Decode(p12Data, password)
     if err != nil {
-        return "", "", fmt.Errorf("failed to decode p12: %s", err.Error())
+        return "", "", mooonerror.Errorf(mooonerror.ErrCodeCryptoCert, "failed to decode p12: %w", err)
     }
 
     // 将私钥从 interface{} 类型转换为 *rsa.PrivateKey 类型
     privateKey, ok := privateKeyInf.(*rsa.PrivateKey)
     if !ok {
-        return "", "", fmt.Errorf("private key type assertion failed")
+        return "", "", mooonerror.Errorf(mooonerror.ErrCodeCryptoCert, "private key type assertion failed")
     }
 
     // 将证书转换为 PEM 格式（含公钥、证书序列号等）
@@ -159,7 +158,7 @@ func IsOpenSslPemPrivateKey(s string) bool {
 func Filepath2PrivateKey(filepath string) (*rsa.PrivateKey, error) {
     file, err := os.Open(filepath)
     if err != nil {
-        return nil, fmt.Errorf("open %s error: %s", filepath, err.Error())
+        return nil, mooonerror.Errorf(mooonerror.ErrCodeFileOperate, "open %s error: %w", filepath, err)
     }
     defer file.Close()
     return File2PrivateKey(file)
@@ -168,7 +167,7 @@ func Filepath2PrivateKey(filepath string) (*rsa.PrivateKey, error) {
 func File2PrivateKey(file *os.File) (*rsa.PrivateKey, error) {
     bytes, err := io.ReadAll(file)
     if err != nil {
-        return nil, fmt.Errorf("read %s error: %s", file.Name(), err.Error())
+        return nil, mooonerror.Errorf(mooonerror.ErrCodeFileOperate, "read %s error: %w", file.Name(), err)
     }
     return String2PrivateKey(string(bytes))
 }
@@ -180,7 +179,7 @@ func String2PrivateKey(str string) (*rsa.PrivateKey, error) {
     // 解析 PEM 编码数据
     block, _ := pem.Decode([]byte(str))
     if block == nil {
-        return nil, errors.New("failed to decode PEM block containing private key")
+        return nil, mooonerror.NewError(mooonerror.ErrCodeCryptoInvalidKey, "failed to decode PEM block containing private key")
     }
 
     // 解析私钥
@@ -194,15 +193,57 @@ func String2PrivateKey(str string) (*rsa.PrivateKey, error) {
     case "OPENSSH PRIVATE KEY":
         privateKey, err = ssh.ParseRawPrivateKey(block.Bytes)
     default:
-        return nil, errors.New("unsupported key type")
+        return nil, mooonerror.NewError(mooonerror.ErrCodeCryptoInvalidKey, "unsupported key type")
     }
     if err != nil {
-        return nil, fmt.Errorf("failed to parse private key: %s", err.Error())
+        return nil, mooonerror.Errorf(mooonerror.ErrCodeCryptoInvalidKey, "failed to parse private key: %w", err)
     }
 
     pk, ok := privateKey.(*rsa.PrivateKey)
     if !ok {
-        return nil, errors.New("not an RSA private key")
+        return nil, mooonerror.NewError(mooonerror.ErrCodeCryptoInvalidKey, "not an RSA private key")
     }
     return pk, err
-}
\ No newline at end of file
+}
+
+// String2Certificate 解析 PEM 格式的 X.509 证书
+func String2Certificate(str string) (*x509.Certificate, error) {
+    block, _ := pem.Decode([]byte(str))
+    if block == nil || block.Type != "CERTIFICATE" {
+        return nil, mooonerror.NewError(mooonerror.ErrCodeCryptoCert, "failed to decode PEM block containing certificate")
+    }
+    cert, err := x509.ParseCertificate(block.Bytes)
+    if err != nil {
+        return nil, mooonerror.Errorf(mooonerror.ErrCodeCryptoCert, "failed to parse X.509 certificate: %w", err)
+    }
+    return cert, nil
+}
+
+// String2PublicKey 解析 PEM 格式的 RSA 公钥，支持 PKIX（PUBLIC KEY）和 PKCS#1（RSA PUBLIC KEY）两种格式
+func String2PublicKey(str string) (*rsa.PublicKey, error) {
+    block, _ := pem.Decode([]byte(str))
+    if block == nil {
+        return nil, mooonerror.NewError(mooonerror.ErrCodeCryptoInvalidKey, "failed to decode PEM block containing public key")
+    }
+
+    switch block.Type {
+    case "PUBLIC KEY":
+        publicKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+        if err != nil {
+            return nil, mooonerror.Errorf(mooonerror.ErrCodeCryptoInvalidKey, "failed to parse public key: %w", err)
+        }
+        rsaPublicKey, ok := publicKey.(*rsa.PublicKey)
+        if !ok {
+            return nil, mooonerror.NewError(mooonerror.ErrCodeCryptoInvalidKey, "not a RSA public key")
+        }
+        return rsaPublicKey, nil
+    case "RSA PUBLIC KEY":
+        publicKey, err := x509.ParsePKCS1PublicKey(block.Bytes)
+        if err != nil {
+            return nil, mooonerror.Errorf(mooonerror.ErrCodeCryptoInvalidKey, "failed to parse public key: %w", err)
+        }
+        return publicKey, nil
+    default:
+        return nil, mooonerror.NewError(mooonerror.ErrCodeCryptoInvalidKey, "unsupported public key type")
+    }
+}
@@ -8,6 +8,7 @@ import (
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"encoding/pem"
+	"github.com/eyjian/gomooon/mooonerror"
 	"math/big"
 	"os"
 	"time"
@@ -94,7 +95,7 @@ func GenerateCertPemStringFromPrivateKey(privateKey *rsa.PrivateKey, ct *CertTem
 	// 生成证书
 	certBytes, err := x509.CreateCertificate(rand.Reader, certTemplate, certTemplate, &privateKey.PublicKey, privateKey)
 	if err != nil {
-		return "", err
+		return "", mooonerror.Wrap(mooonerror.ErrCodeCryptoCert, err)
 	}
 
 	// 将证书编码为 PEM 格式
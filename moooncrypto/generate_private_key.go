@@ -9,8 +9,7 @@ import (
 	"crypto/rsa"
 	"crypto/x509"
 	"encoding/pem"
-	"errors"
-	"fmt"
+	"github.com/eyjian/gomooon/mooonerror"
 	"github.com/eyjian/gomooon/mooonstr"
 	"golang.org/x/crypto/ssh"
 )
@@ -42,7 +41,7 @@ func GeneratePrivateKeyFile(keyType KeyType, keySize keySize, filepath string) e
 	if err != nil {
 		return err
 	}
-	return mooonstr.WriteString2File(filepath, privateKeyString)
+	return mooonerror.Wrap(mooonerror.ErrCodeFileOperate, mooonstr.WriteString2File(filepath, privateKeyString))
 }
 
 // GeneratePrivateKeyString 生成私钥字符串
@@ -62,10 +61,10 @@ func GeneratePrivateKeyString(keyType KeyType, keySize keySize) (string, error)
 		// 生成 ECDSA 私钥
 		privateKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
 	default:
-		return "", errors.New("unsupported key type")
+		return "", mooonerror.NewError(mooonerror.ErrCodeCryptoInvalidKey, "unsupported key type")
 	}
 	if err != nil {
-		return "", fmt.Errorf("generate key error: %s", err.Error())
+		return "", mooonerror.Errorf(mooonerror.ErrCodeCryptoCert, "generate key error: %w", err)
 	}
 
 	// 将私钥编码为相应的格式
@@ -86,12 +85,12 @@ func GeneratePrivateKeyString(keyType KeyType, keySize keySize) (string, error)
 		pemType = "OPENSSH PRIVATE KEY"
 		signer, err := ssh.NewSignerFromKey(privateKey)
 		if err != nil {
-			return "", err
+			return "", mooonerror.Wrap(mooonerror.ErrCodeCryptoCert, err)
 		}
 		privateKeyBytes = ssh.MarshalAuthorizedKey(signer.PublicKey())
 	}
 	if err != nil {
-		return "", fmt.Errorf("marshal key error: %s", err.Error())
+		return "", mooonerror.Errorf(mooonerror.ErrCodeCryptoCert, "marshal key error: %w", err)
 	}
 
 	// 创建PEM数据结构
@@ -6,6 +6,7 @@ import (
     "crypto/hmac"
     "crypto/sha256"
     "encoding/hex"
+    "github.com/eyjian/gomooon/mooonerror"
     "strings"
 )
 
@@ -20,7 +21,7 @@ func HmacSha256Sign(data, key string, toUpper bool) (string, error) {
     hash := hmac.New(sha256.New, []byte(key))
     _, err := hash.Write([]byte(data))
     if err != nil {
-        return "", err
+        return "", mooonerror.Wrap(mooonerror.ErrCodeCryptoSign, err)
     }
 
     // hex.EncodeToString 返回的是小写的十六进制字符串
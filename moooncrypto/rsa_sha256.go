@@ -9,8 +9,7 @@ import (
     "crypto/x509"
     "encoding/base64"
     "encoding/pem"
-    "errors"
-    "fmt"
+    "github.com/eyjian/gomooon/mooonerror"
 )
 
 // RSA（Rivest-Shamir-Adleman）是一种非对称加密算法，由 Ron Rivest、Adi Shamir 和 Leonard Adleman 于 1977 年提出
@@ -20,7 +19,7 @@ func RsaSha256SignWithPrivateKey(privateKey *rsa.PrivateKey, data []byte) (strin
     hash := sha256.Sum256(data)
     signature, err := rsa.SignPKCS1v15(nil, privateKey, crypto.SHA256, hash[:])
     if err != nil {
-        return "", fmt.Errorf("RSA-SHA256 sign error: %v", err)
+        return "", mooonerror.Errorf(mooonerror.ErrCodeCryptoSign, "RSA-SHA256 sign error: %w", err)
     }
 
     // 将签名结果转换为 Base64 编码
@@ -34,13 +33,29 @@ func RsaSha256SignWithPrivateKeyStr(privateKeyStr []byte, data []byte) (string,
     // 解析私钥
     block, _ := pem.Decode(privateKeyStr)
     if block == nil {
-        return "", errors.New("decode private key error")
+        return "", mooonerror.NewError(mooonerror.ErrCodeCryptoInvalidKey, "decode private key error")
     }
 
     privateKey, err := x509.ParsePKCS1PrivateKey(block.Bytes)
     if err != nil {
-        return "", fmt.Errorf("parse private key error: %s", err.Error())
+        return "", mooonerror.Errorf(mooonerror.ErrCodeCryptoInvalidKey, "parse private key error: %w", err)
     }
 
     return RsaSha256SignWithPrivateKey(privateKey, data)
 }
+
+// RsaSha256VerifyWithPublicKey 验证 RsaSha256SignWithPrivateKey 生成的签名，signature 为 Base64 编码
+// 签名匹配时返回 nil
+func RsaSha256VerifyWithPublicKey(publicKey *rsa.PublicKey, data []byte, signature string) error {
+    signatureBytes, err := base64.StdEncoding.DecodeString(signature)
+    if err != nil {
+        return mooonerror.Errorf(mooonerror.ErrCodeCryptoVerify, "RSA-SHA256 signature base64 decode error: %w", err)
+    }
+
+    hash := sha256.Sum256(data)
+    err = rsa.VerifyPKCS1v15(publicKey, crypto.SHA256, hash[:], signatureBytes)
+    if err != nil {
+        return mooonerror.Errorf(mooonerror.ErrCodeCryptoVerify, "RSA-SHA256 verify error: %w", err)
+    }
+    return nil
+}
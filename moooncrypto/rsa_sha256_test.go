@@ -3,6 +3,8 @@
 package moooncrypto
 
 import (
+    "crypto/rand"
+    "crypto/rsa"
     "crypto/x509"
     "encoding/pem"
     "os"
@@ -73,3 +75,23 @@ func TestRsaSha256SignWithPrivateKeyStr(t *testing.T) {
 
     t.Logf("data: %s\nsignature: %s\n", string(data), signature)
 }
+
+// go test -v -run="TestRsaSha256VerifyWithPublicKey"
+func TestRsaSha256VerifyWithPublicKey(t *testing.T) {
+    privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+    if err != nil {
+        t.Fatalf("Error generating private key: %s\n", err.Error())
+    }
+
+    data := []byte("data to be signed")
+    signature, err := RsaSha256SignWithPrivateKey(privateKey, data)
+    if err != nil {
+        t.Fatalf("Error signing data: %s\n", err.Error())
+    }
+    if err = RsaSha256VerifyWithPublicKey(&privateKey.PublicKey, data, signature); err != nil {
+        t.Errorf("Error verifying signature: %s\n", err.Error())
+    }
+    if err = RsaSha256VerifyWithPublicKey(&privateKey.PublicKey, []byte("tampered data"), signature); err == nil {
+        t.Error("verify tampered data should fail")
+    }
+}
@@ -2,6 +2,10 @@
 // Wrote by yijian on 2026/05/29
 package mooonerror
 
+import "net/http"
+
+//go:generate go run ../cmd/mooonerrcode -format=markdown -o ERRCODES.md
+
 // 通用错误码（1-99）
 const (
 	ErrCodeSuccess      = 0 // 成功
@@ -19,3 +23,125 @@ const (
 	ErrCodePdfPageRange = 101 // 页码范围无效
 	ErrCodePdfConvert   = 102 // PDF 转换失败
 )
+
+// 微信支付相关错误码（200-299）
+const (
+	ErrCodeWepayRequest      = 200 // 构造或发送请求失败
+	ErrCodeWepayResponse     = 201 // 微信支付返回错误（HTTP 状态码非 200）
+	ErrCodeWepayDecode       = 202 // 解析响应失败
+	ErrCodeWepaySign         = 203 // 请求签名失败
+	ErrCodeWepayDownload     = 204 // 下载账单或回单失败
+	ErrCodeWepayHashMismatch = 205 // 下载文件的摘要不匹配
+	ErrCodeWepayVerify       = 206 // 应答签名验证失败或平台证书不可用
+)
+
+// 腾讯云相关错误码（300-399）
+const (
+	ErrCodeTxcloudRequest  = 300 // 构造或发送请求失败
+	ErrCodeTxcloudAPI      = 301 // 腾讯云接口返回错误
+	ErrCodeTxcloudResponse = 302 // 响应不符合预期
+	ErrCodeTxcloudLimit    = 303 // 等待限流器放行失败
+
+	// 以下由 txcloud.TranslateError 根据腾讯云的错误码转换而来
+	ErrCodeTxcloudRateLimited         = 304 // 超过腾讯云的频率限制（可重试）
+	ErrCodeTxcloudAuth                = 305 // 鉴权失败，如密钥错误或无权限
+	ErrCodeTxcloudInsufficientBalance = 306 // 账户余额不足或欠费
+	ErrCodeTxcloudInvalidParam        = 307 // 参数错误
+	ErrCodeTxcloudInternal            = 308 // 腾讯云内部错误（可重试）
+)
+
+// Redis 相关错误码（400-499）
+const (
+	ErrCodeRedis                 = 400 // Redis 命令执行失败
+	ErrCodeRedisLockNotHeld      = 401 // 未持有锁
+	ErrCodeRedisLockLost         = 402 // 锁已丢失
+	ErrCodeRedisLockChanged      = 403 // 强制释放前锁已变化
+	ErrCodeRedisCacheNotFound    = 404 // 缓存的数据不存在
+	ErrCodeRedisIdempotency      = 405 // 幂等记录已被其他请求接管
+	ErrCodeRedisJobNotRunning    = 406 // 延迟任务已不在处理中
+	ErrCodeRedisIdempotencyCodec = 407 // 幂等结果编解码失败
+	ErrCodeRedisCacheCodec       = 408 // 缓存值编解码失败
+)
+
+// 加解密相关错误码（500-599）
+const (
+	ErrCodeCryptoInvalidKey  = 500 // 无效的密钥
+	ErrCodeCryptoInvalidData = 501 // 无效的明文或密文（如长度、填充不正确）
+	ErrCodeCryptoCert        = 502 // 证书或私钥的生成、解析失败
+	ErrCodeCryptoSign        = 503 // 签名失败
+	ErrCodeCryptoVerify      = 504 // 验签失败
+)
+
+// HTTP 相关错误码（600-699）
+const (
+	ErrCodeHttpRequest = 600 // 构造或发送请求失败
+	ErrCodeHttpStatus  = 601 // HTTP 状态码非 200
+	ErrCodeHttpFile    = 602 // 写本地文件失败
+)
+
+// ZIP 相关错误码（700-799）
+const (
+	ErrCodeZipOpen    = 700 // 打开 ZIP 文件失败
+	ErrCodeZipExtract = 701 // 解压失败
+	ErrCodeZipCreate  = 702 // 压缩失败
+)
+
+// 登记各错误码的默认消息、HTTP 状态码和 gRPC 状态码
+// 新增错误码时须同时在此登记（TestAllCodesRegistered 会检查）
+func init() {
+	Register(
+		CodeInfo{ErrCodeSuccess, "ErrCodeSuccess", "成功", "success", http.StatusOK, GRPCOK},
+		CodeInfo{ErrCodeUnknown, "ErrCodeUnknown", "未知错误", "unknown error", http.StatusInternalServerError, GRPCUnknown},
+		CodeInfo{ErrCodeInvalidParam, "ErrCodeInvalidParam", "参数错误", "invalid parameter", http.StatusBadRequest, GRPCInvalidArgument},
+		CodeInfo{ErrCodeFileNotFound, "ErrCodeFileNotFound", "文件不存在", "file not found", http.StatusNotFound, GRPCNotFound},
+		CodeInfo{ErrCodeFileOperate, "ErrCodeFileOperate", "文件操作失败", "file operation failed", http.StatusInternalServerError, GRPCInternal},
+		CodeInfo{ErrCodeToolNotFound, "ErrCodeToolNotFound", "依赖工具不可用", "required tool not available", http.StatusInternalServerError, GRPCFailedPrecondition},
+		CodeInfo{ErrCodeToolExecute, "ErrCodeToolExecute", "工具执行失败", "tool execution failed", http.StatusInternalServerError, GRPCInternal},
+
+		CodeInfo{ErrCodePdfInvalid, "ErrCodePdfInvalid", "无效的 PDF 文件", "invalid PDF file", http.StatusBadRequest, GRPCInvalidArgument},
+		CodeInfo{ErrCodePdfPageRange, "ErrCodePdfPageRange", "页码范围无效", "invalid page range", http.StatusBadRequest, GRPCOutOfRange},
+		CodeInfo{ErrCodePdfConvert, "ErrCodePdfConvert", "PDF 转换失败", "PDF conversion failed", http.StatusInternalServerError, GRPCInternal},
+
+		CodeInfo{ErrCodeWepayRequest, "ErrCodeWepayRequest", "请求微信支付失败", "failed to request WeChat Pay", http.StatusBadGateway, GRPCUnavailable},
+		CodeInfo{ErrCodeWepayResponse, "ErrCodeWepayResponse", "微信支付返回错误", "WeChat Pay returned an error", http.StatusBadGateway, GRPCInternal},
+		CodeInfo{ErrCodeWepayDecode, "ErrCodeWepayDecode", "解析微信支付响应失败", "failed to decode WeChat Pay response", http.StatusBadGateway, GRPCInternal},
+		CodeInfo{ErrCodeWepaySign, "ErrCodeWepaySign", "微信支付请求签名失败", "failed to sign WeChat Pay request", http.StatusInternalServerError, GRPCInternal},
+		CodeInfo{ErrCodeWepayDownload, "ErrCodeWepayDownload", "下载账单或回单失败", "failed to download bill or receipt", http.StatusBadGateway, GRPCUnavailable},
+		CodeInfo{ErrCodeWepayHashMismatch, "ErrCodeWepayHashMismatch", "下载文件的摘要不匹配", "hash of downloaded file mismatched", http.StatusBadGateway, GRPCDataLoss},
+		CodeInfo{ErrCodeWepayVerify, "ErrCodeWepayVerify", "微信支付应答签名验证失败", "failed to verify WeChat Pay response signature", http.StatusBadGateway, GRPCUnauthenticated},
+
+		CodeInfo{ErrCodeTxcloudRequest, "ErrCodeTxcloudRequest", "请求腾讯云失败", "failed to request Tencent Cloud", http.StatusBadGateway, GRPCUnavailable},
+		CodeInfo{ErrCodeTxcloudAPI, "ErrCodeTxcloudAPI", "腾讯云接口返回错误", "Tencent Cloud API returned an error", http.StatusBadGateway, GRPCInternal},
+		CodeInfo{ErrCodeTxcloudResponse, "ErrCodeTxcloudResponse", "腾讯云响应不符合预期", "unexpected Tencent Cloud response", http.StatusBadGateway, GRPCInternal},
+		CodeInfo{ErrCodeTxcloudLimit, "ErrCodeTxcloudLimit", "请求过于频繁", "too many requests", http.StatusTooManyRequests, GRPCResourceExhausted},
+		CodeInfo{ErrCodeTxcloudRateLimited, "ErrCodeTxcloudRateLimited", "超过腾讯云的频率限制", "Tencent Cloud rate limit exceeded", http.StatusTooManyRequests, GRPCResourceExhausted},
+		CodeInfo{ErrCodeTxcloudAuth, "ErrCodeTxcloudAuth", "腾讯云鉴权失败", "Tencent Cloud authentication failed", http.StatusBadGateway, GRPCPermissionDenied},
+		CodeInfo{ErrCodeTxcloudInsufficientBalance, "ErrCodeTxcloudInsufficientBalance", "腾讯云账户余额不足", "insufficient Tencent Cloud balance", http.StatusBadGateway, GRPCFailedPrecondition},
+		CodeInfo{ErrCodeTxcloudInvalidParam, "ErrCodeTxcloudInvalidParam", "腾讯云接口参数错误", "invalid Tencent Cloud parameter", http.StatusBadRequest, GRPCInvalidArgument},
+		CodeInfo{ErrCodeTxcloudInternal, "ErrCodeTxcloudInternal", "腾讯云内部错误", "Tencent Cloud internal error", http.StatusBadGateway, GRPCUnavailable},
+
+		CodeInfo{ErrCodeRedis, "ErrCodeRedis", "Redis 服务不可用", "Redis unavailable", http.StatusServiceUnavailable, GRPCUnavailable},
+		CodeInfo{ErrCodeRedisLockNotHeld, "ErrCodeRedisLockNotHeld", "未持有锁", "lock not held", http.StatusConflict, GRPCFailedPrecondition},
+		CodeInfo{ErrCodeRedisLockLost, "ErrCodeRedisLockLost", "锁已丢失", "lock lost", http.StatusConflict, GRPCAborted},
+		CodeInfo{ErrCodeRedisLockChanged, "ErrCodeRedisLockChanged", "锁已变化", "lock changed", http.StatusConflict, GRPCAborted},
+		CodeInfo{ErrCodeRedisCacheNotFound, "ErrCodeRedisCacheNotFound", "数据不存在", "not found", http.StatusNotFound, GRPCNotFound},
+		CodeInfo{ErrCodeRedisIdempotency, "ErrCodeRedisIdempotency", "请求已被其他请求接管", "request taken over by another request", http.StatusConflict, GRPCAborted},
+		CodeInfo{ErrCodeRedisJobNotRunning, "ErrCodeRedisJobNotRunning", "任务已不在处理中", "job is not running", http.StatusConflict, GRPCFailedPrecondition},
+		CodeInfo{ErrCodeRedisIdempotencyCodec, "ErrCodeRedisIdempotencyCodec", "幂等结果编解码失败", "failed to encode or decode idempotent result", http.StatusInternalServerError, GRPCInternal},
+		CodeInfo{ErrCodeRedisCacheCodec, "ErrCodeRedisCacheCodec", "缓存值编解码失败", "failed to encode or decode cached value", http.StatusInternalServerError, GRPCInternal},
+
+		CodeInfo{ErrCodeCryptoInvalidKey, "ErrCodeCryptoInvalidKey", "无效的密钥", "invalid key", http.StatusInternalServerError, GRPCFailedPrecondition},
+		CodeInfo{ErrCodeCryptoInvalidData, "ErrCodeCryptoInvalidData", "无效的数据", "invalid data", http.StatusBadRequest, GRPCInvalidArgument},
+		CodeInfo{ErrCodeCryptoCert, "ErrCodeCryptoCert", "证书或私钥处理失败", "failed to process certificate or private key", http.StatusInternalServerError, GRPCInternal},
+		CodeInfo{ErrCodeCryptoSign, "ErrCodeCryptoSign", "签名失败", "failed to sign", http.StatusInternalServerError, GRPCInternal},
+		CodeInfo{ErrCodeCryptoVerify, "ErrCodeCryptoVerify", "验签失败", "signature verification failed", http.StatusUnauthorized, GRPCUnauthenticated},
+
+		CodeInfo{ErrCodeHttpRequest, "ErrCodeHttpRequest", "HTTP 请求失败", "HTTP request failed", http.StatusBadGateway, GRPCUnavailable},
+		CodeInfo{ErrCodeHttpStatus, "ErrCodeHttpStatus", "HTTP 状态码错误（{status_code}）", "unexpected HTTP status ({status_code})", http.StatusBadGateway, GRPCInternal},
+		CodeInfo{ErrCodeHttpFile, "ErrCodeHttpFile", "写本地文件失败", "failed to write local file", http.StatusInternalServerError, GRPCInternal},
+
+		CodeInfo{ErrCodeZipOpen, "ErrCodeZipOpen", "打开 ZIP 文件失败", "failed to open ZIP file", http.StatusInternalServerError, GRPCInternal},
+		CodeInfo{ErrCodeZipExtract, "ErrCodeZipExtract", "解压失败", "failed to extract ZIP file", http.StatusInternalServerError, GRPCInternal},
+		CodeInfo{ErrCodeZipCreate, "ErrCodeZipCreate", "压缩失败", "failed to create ZIP file", http.StatusInternalServerError, GRPCInternal},
+	)
+}
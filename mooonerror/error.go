@@ -2,19 +2,208 @@
 // Wrote by yijian on 2026/05/29
 package mooonerror
 
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"runtime"
+	"strconv"
+)
+
 // CError gomooon 通用错误类型，包含错误码和错误消息
 // 错误码方便调用者程序化处理，错误消息方便人阅读
+// gomooon 各包返回的 error 均可通过 errors.As 转换为 *CError，或通过 Code 取得错误码：
+//
+//	if mooonerror.Code(err) == mooonerror.ErrCodeWepayResponse {
+//		...
+//	}
+//	if errors.Is(err, mooonerror.NewError(mooonerror.ErrCodeHttpStatus, "")) {
+//		...
+//	}
+//
+// 底层错误（如 pdftoppm 的执行错误、腾讯云 SDK 的错误）保存在 Cause 中，可通过 errors.Is/As 取得：
+//
+//	var sdkErr *errors.TencentCloudSDKError
+//	if errors.As(err, &sdkErr) {
+//		...
+//	}
 type CError struct {
-ErrCode int    // 错误码，方便调用者程序化处理
-ErrMsg  string // 错误消息，方便人阅读
+	ErrCode   int                    // 错误码，方便调用者程序化处理
+	ErrMsg    string                 // 错误消息，方便人阅读
+	Cause     error                  // 底层错误，可为 nil
+	Details   map[string]interface{} // 附加信息，如请求 ID、文件路径等，可为 nil
+	Stack     []string               // 创建时的调用栈，只有调用 WithStack 时才记录
+	Retryable bool                   // 是否可重试，如网络错误、限流
 }
 
 // Error 实现 error 接口
 func (e *CError) Error() string {
-return e.ErrMsg
+	return e.ErrMsg
+}
+
+// Unwrap 支持 errors.Is/As 取得底层错误
+func (e *CError) Unwrap() error {
+	return e.Cause
+}
+
+// Is 支持 errors.Is，错误码相同即视为同一错误
+func (e *CError) Is(target error) bool {
+	var t *CError
+	if !errors.As(target, &t) {
+		return false
+	}
+	return e.ErrCode == t.ErrCode
 }
 
 // NewError 创建一个 CError
 func NewError(errCode int, errMsg string) *CError {
-return &CError{ErrCode: errCode, ErrMsg: errMsg}
+	return &CError{ErrCode: errCode, ErrMsg: errMsg}
+}
+
+// Errorf 以格式化的错误消息创建一个 CError，格式同 fmt.Errorf
+// 格式中含有 %w 时，对应的错误作为 Cause
+func Errorf(errCode int, format string, args ...interface{}) *CError {
+	err := fmt.Errorf(format, args...)
+	return &CError{ErrCode: errCode, ErrMsg: err.Error(), Cause: errors.Unwrap(err)}
+}
+
+// Wrap 将 err 转换为错误码为 errCode 的 CError，err 作为 Cause
+// err 为 nil 时返回 nil；err 已经是 CError 时原样返回，保留更具体的错误码
+func Wrap(errCode int, err error) error {
+	if err == nil {
+		return nil
+	}
+	var cerr *CError
+	if errors.As(err, &cerr) {
+		return err
+	}
+	return &CError{ErrCode: errCode, ErrMsg: err.Error(), Cause: err}
+}
+
+// Code 取得 err 的错误码：err 为 nil 时返回 ErrCodeSuccess，不是 CError 时返回 ErrCodeUnknown
+func Code(err error) int {
+	if err == nil {
+		return ErrCodeSuccess
+	}
+	var cerr *CError
+	if errors.As(err, &cerr) {
+		return cerr.ErrCode
+	}
+	return ErrCodeUnknown
+}
+
+// IsRetryable err 是否可重试，err 链上任意一个 CError 标记为可重试即返回 true
+func IsRetryable(err error) bool {
+	for err != nil {
+		var cerr *CError
+		if !errors.As(err, &cerr) {
+			return false
+		}
+		if cerr.Retryable {
+			return true
+		}
+		err = cerr.Cause
+	}
+	return false
+}
+
+// WithDetail 返回添加了附加信息的副本，不修改 e（e 可能是包级变量）
+func (e *CError) WithDetail(key string, value interface{}) *CError {
+	c := e.clone()
+	details := make(map[string]interface{}, len(e.Details)+1)
+	for k, v := range e.Details {
+		details[k] = v
+	}
+	details[key] = value
+	c.Details = details
+	return c
+}
+
+// WithCause 返回以 cause 为底层错误的副本
+func (e *CError) WithCause(cause error) *CError {
+	c := e.clone()
+	c.Cause = cause
+	return c
+}
+
+// WithRetryable 返回标记为可重试的副本
+func (e *CError) WithRetryable() *CError {
+	c := e.clone()
+	c.Retryable = true
+	return c
+}
+
+// WithStack 返回记录了调用者调用栈的副本
+// 说明：取调用栈有一定开销，只应在错误较少的路径上使用
+func (e *CError) WithStack() *CError {
+	c := e.clone()
+	c.Stack = callers(3)
+	return c
+}
+
+func (e *CError) clone() *CError {
+	c := *e
+	return &c
+}
+
+// callers 取得调用栈，每帧格式为 "函数名 文件:行号"
+func callers(skip int) []string {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(skip, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	var stack []string
+	for {
+		frame, more := frames.Next()
+		stack = append(stack, frame.Function+" "+frame.File+":"+strconv.Itoa(frame.Line))
+		if !more {
+			break
+		}
+	}
+	return stack
+}
+
+// cerrorJSON CError 的 JSON 格式
+type cerrorJSON struct {
+	Code      int                    `json:"code"`
+	Message   string                 `json:"message"`
+	Cause     string                 `json:"cause,omitempty"`
+	Details   map[string]interface{} `json:"details,omitempty"`
+	Stack     []string               `json:"stack,omitempty"`
+	Retryable bool                   `json:"retryable,omitempty"`
+}
+
+// MarshalJSON 实现 json.Marshaler，便于 HTTP 接口返回和结构化日志
+// Cause 以字符串输出，反序列化后 Cause 为只含错误消息的 error
+func (e *CError) MarshalJSON() ([]byte, error) {
+	v := cerrorJSON{
+		Code:      e.ErrCode,
+		Message:   e.ErrMsg,
+		Details:   e.Details,
+		Stack:     e.Stack,
+		Retryable: e.Retryable,
+	}
+	if e.Cause != nil {
+		v.Cause = e.Cause.Error()
+	}
+	return json.Marshal(&v)
+}
+
+// UnmarshalJSON 实现 json.Unmarshaler
+func (e *CError) UnmarshalJSON(data []byte) error {
+	var v cerrorJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*e = CError{
+		ErrCode:   v.Code,
+		ErrMsg:    v.Message,
+		Details:   v.Details,
+		Stack:     v.Stack,
+		Retryable: v.Retryable,
+	}
+	if v.Cause != "" {
+		e.Cause = errors.New(v.Cause)
+	}
+	return nil
 }
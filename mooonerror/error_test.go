@@ -0,0 +1,93 @@
+// Package mooonerror
+// Wrote by yijian on 2026/10/18
+package mooonerror
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+// TestErrorIsAs 测试 errors.Is 和 errors.As
+// go test -v -run="TestErrorIsAs"
+func TestErrorIsAs(t *testing.T) {
+	err := fmt.Errorf("download bill: %w", Errorf(ErrCodeWepayResponse, "status %d", 500))
+
+	var cerr *CError
+	if !errors.As(err, &cerr) || cerr.ErrCode != ErrCodeWepayResponse {
+		t.Fatalf("errors.As failed: %v", err)
+	}
+	if !errors.Is(err, NewError(ErrCodeWepayResponse, "")) {
+		t.Fatalf("errors.Is should match the same code")
+	}
+	if errors.Is(err, NewError(ErrCodeWepayRequest, "")) {
+		t.Fatalf("errors.Is should not match a different code")
+	}
+	if Code(err) != ErrCodeWepayResponse || Code(nil) != ErrCodeSuccess || Code(io.EOF) != ErrCodeUnknown {
+		t.Fatalf("unexpected Code result")
+	}
+}
+
+// TestWrap 测试 Wrap
+// go test -v -run="TestWrap"
+func TestWrap(t *testing.T) {
+	if Wrap(ErrCodeRedis, nil) != nil {
+		t.Fatalf("Wrap(nil) should be nil")
+	}
+	if Code(Wrap(ErrCodeRedis, io.EOF)) != ErrCodeRedis {
+		t.Fatalf("Wrap should set the code")
+	}
+	// 已经是 CError 时保留更具体的错误码
+	if Code(Wrap(ErrCodeRedis, NewError(ErrCodeRedisLockLost, "lost"))) != ErrCodeRedisLockLost {
+		t.Fatalf("Wrap should keep the original code")
+	}
+}
+
+// TestCause 测试 Cause 和 Unwrap
+// go test -v -run="TestCause"
+func TestCause(t *testing.T) {
+	err := Errorf(ErrCodeToolExecute, "pdftoppm execution failed: %w", io.ErrUnexpectedEOF)
+	if !errors.Is(err, io.ErrUnexpectedEOF) || err.Error() != "pdftoppm execution failed: unexpected EOF" {
+		t.Fatalf("Errorf should keep the cause of %%w: %v", err)
+	}
+	if !errors.Is(Wrap(ErrCodeRedis, context.DeadlineExceeded), context.DeadlineExceeded) {
+		t.Fatalf("Wrap should keep the cause")
+	}
+	if Errorf(ErrCodeUnknown, "no cause").Cause != nil {
+		t.Fatalf("Errorf without %%w should have no cause")
+	}
+}
+
+// TestWithAndJSON 测试 WithXxx 和 JSON 序列化
+// go test -v -run="TestWithAndJSON"
+func TestWithAndJSON(t *testing.T) {
+	sentinel := NewError(ErrCodeRedisLockLost, "lock lost")
+	err := sentinel.WithDetail("key", "job:report").WithCause(io.EOF).WithRetryable().WithStack()
+	if sentinel.Details != nil || sentinel.Cause != nil || sentinel.Retryable || sentinel.Stack != nil {
+		t.Fatalf("WithXxx should not modify the original error")
+	}
+	if !IsRetryable(fmt.Errorf("renew: %w", err)) || IsRetryable(sentinel) || IsRetryable(io.EOF) {
+		t.Fatalf("unexpected IsRetryable result")
+	}
+	if len(err.Stack) == 0 || !strings.Contains(err.Stack[0], "TestWithAndJSON") {
+		t.Fatalf("unexpected stack: %v", err.Stack)
+	}
+
+	data, e := json.Marshal(err)
+	if e != nil {
+		t.Fatal(e)
+	}
+	var decoded CError
+	if e := json.Unmarshal(data, &decoded); e != nil {
+		t.Fatal(e)
+	}
+	if decoded.ErrCode != ErrCodeRedisLockLost || decoded.ErrMsg != "lock lost" || decoded.Cause.Error() != "EOF" ||
+		decoded.Details["key"] != "job:report" || !decoded.Retryable || len(decoded.Stack) != len(err.Stack) {
+		t.Fatalf("unexpected decoded error: %s", data)
+	}
+	t.Logf("%s", data)
+}
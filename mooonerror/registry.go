@@ -0,0 +1,172 @@
+// Package mooonerror
+// Wrote by yijian on 2026/10/18
+package mooonerror
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Lang 错误消息的语言
+type Lang string
+
+const (
+	LangZh Lang = "zh" // 中文
+	LangEn Lang = "en" // 英文
+)
+
+// GRPCCode gRPC 状态码，取值同 google.golang.org/grpc/codes，可直接转换：codes.Code(GRPCStatus(err))
+// 为避免本包依赖 gRPC，这里重新定义
+type GRPCCode uint32
+
+const (
+	GRPCOK                 GRPCCode = 0
+	GRPCCanceled           GRPCCode = 1
+	GRPCUnknown            GRPCCode = 2
+	GRPCInvalidArgument    GRPCCode = 3
+	GRPCDeadlineExceeded   GRPCCode = 4
+	GRPCNotFound           GRPCCode = 5
+	GRPCAlreadyExists      GRPCCode = 6
+	GRPCPermissionDenied   GRPCCode = 7
+	GRPCResourceExhausted  GRPCCode = 8
+	GRPCFailedPrecondition GRPCCode = 9
+	GRPCAborted            GRPCCode = 10
+	GRPCOutOfRange         GRPCCode = 11
+	GRPCUnimplemented      GRPCCode = 12
+	GRPCInternal           GRPCCode = 13
+	GRPCUnavailable        GRPCCode = 14
+	GRPCDataLoss           GRPCCode = 15
+	GRPCUnauthenticated    GRPCCode = 16
+)
+
+// CodeInfo 错误码的登记信息
+type CodeInfo struct {
+	Code       int      `json:"code"`
+	Name       string   `json:"name"`        // 常量名，如 ErrCodeWepayRequest
+	MessageZh  string   `json:"message_zh"`  // 中文消息模板，{key} 以 CError.Details 中的值替换
+	MessageEn  string   `json:"message_en"`  // 英文消息模板
+	HTTPStatus int      `json:"http_status"` // 对应的 HTTP 状态码
+	GRPCCode   GRPCCode `json:"grpc_code"`   // 对应的 gRPC 状态码
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[int]CodeInfo)
+)
+
+// Register 登记错误码，错误码重复时 panic
+// 使用方可以在自己的 init 中登记业务错误码，建议使用 10000 以上的值，避免与 gomooon 冲突
+func Register(infos ...CodeInfo) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	for _, info := range infos {
+		if old, ok := registry[info.Code]; ok {
+			panic(fmt.Sprintf("mooonerror: code %d registered twice: %s and %s", info.Code, old.Name, info.Name))
+		}
+		registry[info.Code] = info
+	}
+}
+
+// Lookup 查找错误码的登记信息
+func Lookup(code int) (CodeInfo, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	info, ok := registry[code]
+	return info, ok
+}
+
+// Codes 返回所有已登记的错误码，按错误码从小到大排序
+func Codes() []CodeInfo {
+	registryMu.RLock()
+	infos := make([]CodeInfo, 0, len(registry))
+	for _, info := range registry {
+		infos = append(infos, info)
+	}
+	registryMu.RUnlock()
+
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Code < infos[j].Code
+	})
+	return infos
+}
+
+// HTTPStatus 取得 err 对应的 HTTP 状态码
+// err 为 nil 时返回 200，错误码未登记时返回 500
+func HTTPStatus(err error) int {
+	if err == nil {
+		return http.StatusOK
+	}
+	if info, ok := Lookup(Code(err)); ok {
+		return info.HTTPStatus
+	}
+	return http.StatusInternalServerError
+}
+
+// GRPCStatus 取得 err 对应的 gRPC 状态码
+// err 为 nil 时返回 GRPCOK，错误码未登记时返回 GRPCUnknown
+func GRPCStatus(err error) GRPCCode {
+	if err == nil {
+		return GRPCOK
+	}
+	if info, ok := Lookup(Code(err)); ok {
+		return info.GRPCCode
+	}
+	return GRPCUnknown
+}
+
+// Message 取得 err 的本地化消息，供返回给最终用户
+// 模板中的 {key} 以 CError.Details 中的值替换，没有对应值的保持原样
+// err 不是 CError 或错误码未登记时返回 err.Error()，err 为 nil 时返回空字符串
+func Message(err error, lang Lang) string {
+	if err == nil {
+		return ""
+	}
+	var cerr *CError
+	if !errors.As(err, &cerr) {
+		return err.Error()
+	}
+	info, ok := Lookup(cerr.ErrCode)
+	if !ok {
+		return err.Error()
+	}
+
+	tmpl := info.MessageZh
+	if lang == LangEn {
+		tmpl = info.MessageEn
+	}
+	for key, value := range cerr.Details {
+		tmpl = strings.ReplaceAll(tmpl, "{"+key+"}", fmt.Sprint(value))
+	}
+	return tmpl
+}
+
+// WriteCatalog 以 markdown 或 json 格式输出所有已登记错误码的目录
+func WriteCatalog(w io.Writer, format string) error {
+	infos := Codes()
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(infos)
+	case "markdown":
+		var sb strings.Builder
+		sb.WriteString("| 错误码 | 名称 | 中文消息 | 英文消息 | HTTP 状态码 | gRPC 状态码 |\n")
+		sb.WriteString("| --- | --- | --- | --- | --- | --- |\n")
+		for _, info := range infos {
+			fmt.Fprintf(&sb, "| %d | %s | %s | %s | %d | %d |\n",
+				info.Code, info.Name, info.MessageZh, info.MessageEn, info.HTTPStatus, info.GRPCCode)
+		}
+		_, err := io.WriteString(w, sb.String())
+		return err
+	default:
+		return Errorf(ErrCodeInvalidParam, "unsupported catalog format: %s", format)
+	}
+}
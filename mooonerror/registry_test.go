@@ -0,0 +1,93 @@
+// Package mooonerror
+// Wrote by yijian on 2026/10/18
+package mooonerror
+
+import (
+	"bytes"
+	"encoding/json"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// TestAllCodesRegistered 检查 errcode.go 中定义的错误码均已登记，且登记的名称与常量名一致
+// go test -v -run="TestAllCodesRegistered"
+func TestAllCodesRegistered(t *testing.T) {
+	file, err := parser.ParseFile(token.NewFileSet(), "errcode.go", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n := 0
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.CONST {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			for _, name := range spec.(*ast.ValueSpec).Names {
+				n++
+				found := false
+				for _, info := range Codes() {
+					if info.Name == name.Name {
+						found = true
+						break
+					}
+				}
+				if !found {
+					t.Errorf("%s is not registered", name.Name)
+				}
+			}
+		}
+	}
+	if n != len(Codes()) {
+		t.Errorf("%d codes defined, but %d registered", n, len(Codes()))
+	}
+}
+
+// TestLookup 测试 HTTP 状态码、gRPC 状态码和本地化消息
+// go test -v -run="TestLookup"
+func TestLookup(t *testing.T) {
+	err := Errorf(ErrCodeHttpStatus, "HTTP request error: 503 Service Unavailable").WithDetail("status_code", 503)
+	if HTTPStatus(err) != http.StatusBadGateway || GRPCStatus(err) != GRPCInternal {
+		t.Fatalf("unexpected status")
+	}
+	if Message(err, LangZh) != "HTTP 状态码错误（503）" || Message(err, LangEn) != "unexpected HTTP status (503)" {
+		t.Fatalf("unexpected message: %s, %s", Message(err, LangZh), Message(err, LangEn))
+	}
+	if HTTPStatus(nil) != http.StatusOK || HTTPStatus(io.EOF) != http.StatusInternalServerError || GRPCStatus(io.EOF) != GRPCUnknown {
+		t.Fatalf("unexpected status of nil or non-CError")
+	}
+	if Message(io.EOF, LangZh) != "EOF" || Message(NewError(99999, "custom"), LangEn) != "custom" {
+		t.Fatalf("Message should fall back to err.Error()")
+	}
+}
+
+// TestWriteCatalog 测试输出错误码目录
+// go test -v -run="TestWriteCatalog"
+func TestWriteCatalog(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteCatalog(&buf, "markdown"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "| 400 | ErrCodeRedis | Redis 服务不可用 |") {
+		t.Fatalf("unexpected markdown:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	if err := WriteCatalog(&buf, "json"); err != nil {
+		t.Fatal(err)
+	}
+	var infos []CodeInfo
+	if err := json.Unmarshal(buf.Bytes(), &infos); err != nil || len(infos) != len(Codes()) {
+		t.Fatalf("unexpected json: %v", err)
+	}
+
+	if Code(WriteCatalog(&buf, "xml")) != ErrCodeInvalidParam {
+		t.Fatalf("unsupported format should return ErrCodeInvalidParam")
+	}
+}
@@ -0,0 +1,34 @@
+// Package mooongorm
+// Wrote by yijian on 2026/10/18
+package mooongorm
+
+import (
+    "errors"
+    "gorm.io/gorm"
+    "gorm.io/gorm/clause"
+)
+
+// ErrStaleFence fencing token 已过期，更新被拒绝
+var ErrStaleFence = errors.New("stale fencing token, update refused")
+
+// UpdateWithFence 基于 fencing token 的条件更新
+// 相当于：UPDATE ... SET values..., fenceColumn=fence WHERE <db 已有条件> AND fenceColumn <= fence
+// fence 取自 mooonredis.RentKeyWithFence 或 SimDistLock.TryLockWithFence 的返回值，
+// 锁的旧持有者暂停超过锁的过期时间后，新持有者已用更大的 token 写入过，此时旧持有者的更新会被拒绝
+// 使用注意：
+// 1、db 应已指定 Model 和条件，如：db.Model(&Order{}).Where("f_id = ?", id)，fenceColumn 为表中存放 token 的列，如：f_fence；
+// 2、影响行数为 0 时，返回的 db.Error 为 ErrStaleFence（条件不匹配任何记录时也是如此）；
+// 3、MySQL 默认返回的是值发生变化的行数，如果新值和旧值完全相同也会得到 ErrStaleFence，可在 DSN 中加上 clientFoundRows=true 避免。
+func UpdateWithFence(db *gorm.DB, fenceColumn string, fence int64, values map[string]interface{}) *gorm.DB {
+    updates := make(map[string]interface{}, len(values)+1)
+    for k, v := range values {
+        updates[k] = v
+    }
+    updates[fenceColumn] = fence
+
+    tx := db.Where(clause.Lte{Column: clause.Column{Name: fenceColumn}, Value: fence}).Updates(updates)
+    if tx.Error == nil && tx.RowsAffected == 0 {
+        tx.AddError(ErrStaleFence)
+    }
+    return tx
+}
@@ -0,0 +1,62 @@
+// Package mooongorm
+// Wrote by yijian on 2026/10/18
+package mooongorm
+
+import (
+    "errors"
+    "gorm.io/driver/sqlite"
+    "gorm.io/gorm"
+    "gorm.io/gorm/logger"
+    "testing"
+)
+
+type FenceModel struct {
+    Id    uint32 `gorm:"column:f_id;primaryKey;autoIncrement"`
+    Name  string `gorm:"column:f_name"`
+    Fence int64  `gorm:"column:f_fence"`
+}
+
+// go test -v -run="TestUpdateWithFence$"
+func TestUpdateWithFence(t *testing.T) {
+    db, err := gorm.Open(sqlite.Open("file:fence?mode=memory"), &gorm.Config{
+        Logger: logger.Default.LogMode(logger.Silent),
+    })
+    if err != nil {
+        t.Fatalf("failed to connect to the database: %v", err)
+    }
+    if err = db.AutoMigrate(&FenceModel{}); err != nil {
+        t.Fatalf("failed to migrate the schema: %v", err)
+    }
+
+    row := FenceModel{Name: "init"}
+    if err = db.Create(&row).Error; err != nil {
+        t.Fatalf("failed to create record: %v", err)
+    }
+
+    // 持有 token 5 的新持有者写入
+    err = UpdateWithFence(db.Model(&FenceModel{}).Where("f_id = ?", row.Id), "f_fence", 5, map[string]interface{}{"f_name": "holder-5"}).Error
+    if err != nil {
+        t.Fatalf("update with fence 5 error: %v", err)
+    }
+
+    // 同一持有者用相同 token 再次写入
+    err = UpdateWithFence(db.Model(&FenceModel{}).Where("f_id = ?", row.Id), "f_fence", 5, map[string]interface{}{"f_name": "holder-5-again"}).Error
+    if err != nil {
+        t.Fatalf("update again with fence 5 error: %v", err)
+    }
+
+    // 持有 token 3 的旧持有者写入应被拒绝
+    err = UpdateWithFence(db.Model(&FenceModel{}).Where("f_id = ?", row.Id), "f_fence", 3, map[string]interface{}{"f_name": "holder-3"}).Error
+    if !errors.Is(err, ErrStaleFence) {
+        t.Fatalf("expected ErrStaleFence, but got: %v", err)
+    }
+
+    var result FenceModel
+    if err = db.First(&result, row.Id).Error; err != nil {
+        t.Fatalf("failed to query record: %v", err)
+    }
+    if result.Name != "holder-5-again" || result.Fence != 5 {
+        t.Fatalf("unexpected record: %+v", result)
+    }
+    t.Logf("%+v\n", result)
+}
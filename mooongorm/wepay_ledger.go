@@ -0,0 +1,157 @@
+// Package mooongorm
+// Wrote by yijian on 2026/10/18
+package mooongorm
+
+import (
+    "context"
+    "database/sql"
+    "errors"
+    "strings"
+    "time"
+
+    "gorm.io/gorm"
+)
+import (
+    "github.com/eyjian/gomooon/mooonwepay"
+)
+
+// WepayLedgerColumns 订单表中与对账相关的列名，除 OutTradeNo、Amount 和 Time 外均可为空
+// 金额列的单位须为分
+type WepayLedgerColumns struct {
+    OutTradeNo    string // 商户订单号，如：f_out_trade_no
+    TransactionId string // 微信订单号，为空表示只按商户订单号匹配
+    Amount        string // 订单金额
+    RefundAmount  string // 已退款金额，为空表示没有退款
+    Fee           string // 手续费，为空表示不核对手续费
+    State         string // 订单状态，为空表示不核对状态，取值不是微信支付的交易状态时用 WithLedgerStateMapper 转换
+    Time          string // 支付时间，Range 按它筛选，如：f_pay_time
+}
+
+// WepayLedgerOption WepayLedger 的可选项
+type WepayLedgerOption func(*WepayLedger)
+
+// WithLedgerStateMapper 设置订单状态的转换函数，将表中的状态转为微信支付的交易状态，如 2 转为 SUCCESS
+func WithLedgerStateMapper(mapper func(state string) string) WepayLedgerOption {
+    return func(l *WepayLedger) {
+        l.stateMapper = mapper
+    }
+}
+
+// WepayLedger 基于 gorm 的对账账本，实现了 mooonwepay.LedgerSource
+// db 应已指定表和其它条件，如：db.Table("t_order").Where("f_pay_channel = ?", "wepay")
+type WepayLedger struct {
+    db          *gorm.DB
+    columns     WepayLedgerColumns
+    stateMapper func(state string) string
+}
+
+// NewWepayLedger 生成基于 gorm 的对账账本
+func NewWepayLedger(db *gorm.DB, columns WepayLedgerColumns, opts ...WepayLedgerOption) *WepayLedger {
+    l := &WepayLedger{
+        db:      db,
+        columns: columns,
+    }
+    for _, opt := range opts {
+        opt(l)
+    }
+    return l
+}
+
+// Range 依次回调支付时间在 [begin, end) 内的订单，以游标逐行读取，不一次性加载
+func (l *WepayLedger) Range(ctx context.Context, begin, end time.Time, fn func(order *mooonwepay.LedgerOrder) error) error {
+    if l.columns.Time == "" {
+        return errors.New("time column of wepay ledger is empty")
+    }
+    db := l.selectColumns(l.db.Session(&gorm.Session{}).WithContext(ctx)).
+        Where(l.columns.Time+" >= ? AND "+l.columns.Time+" < ?", begin, end)
+    rows, err := db.Rows()
+    if err != nil {
+        return err
+    }
+    defer rows.Close()
+
+    for rows.Next() {
+        order, err := l.scan(rows)
+        if err != nil {
+            return err
+        }
+        if err = fn(order); err != nil {
+            return err
+        }
+    }
+    return rows.Err()
+}
+
+// Lookup 按商户订单号查找订单
+func (l *WepayLedger) Lookup(ctx context.Context, outTradeNos []string) ([]*mooonwepay.LedgerOrder, error) {
+    if len(outTradeNos) == 0 {
+        return nil, nil
+    }
+    db := l.selectColumns(l.db.Session(&gorm.Session{}).WithContext(ctx)).
+        Where(l.columns.OutTradeNo+" IN ?", outTradeNos)
+    rows, err := db.Rows()
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    orders := make([]*mooonwepay.LedgerOrder, 0, len(outTradeNos))
+    for rows.Next() {
+        order, err := l.scan(rows)
+        if err != nil {
+            return nil, err
+        }
+        orders = append(orders, order)
+    }
+    return orders, rows.Err()
+}
+
+// selectColumns 选取非空的列，顺序与 scan 一致
+func (l *WepayLedger) selectColumns(db *gorm.DB) *gorm.DB {
+    columns := []string{l.columns.OutTradeNo, l.columns.Amount}
+    for _, column := range []string{l.columns.TransactionId, l.columns.RefundAmount, l.columns.Fee, l.columns.State} {
+        if column != "" {
+            columns = append(columns, column)
+        }
+    }
+    return db.Select(strings.Join(columns, ", "))
+}
+
+// scan 读取一行，列为 NULL 时取零值
+func (l *WepayLedger) scan(rows *sql.Rows) (*mooonwepay.LedgerOrder, error) {
+    var (
+        outTradeNo, transactionId, state sql.NullString
+        amount, refundAmount, fee        sql.NullInt64
+    )
+    dest := []interface{}{&outTradeNo, &amount}
+    if l.columns.TransactionId != "" {
+        dest = append(dest, &transactionId)
+    }
+    if l.columns.RefundAmount != "" {
+        dest = append(dest, &refundAmount)
+    }
+    if l.columns.Fee != "" {
+        dest = append(dest, &fee)
+    }
+    if l.columns.State != "" {
+        dest = append(dest, &state)
+    }
+    if err := rows.Scan(dest...); err != nil {
+        return nil, err
+    }
+
+    order := &mooonwepay.LedgerOrder{
+        OutTradeNo:    outTradeNo.String,
+        TransactionId: transactionId.String,
+        Amount:        amount.Int64,
+        RefundAmount:  refundAmount.Int64,
+        State:         state.String,
+    }
+    if l.columns.Fee != "" {
+        order.Fee = &fee.Int64
+    }
+    if l.columns.State != "" && l.stateMapper != nil {
+        order.State = l.stateMapper(order.State)
+    }
+    return order, nil
+}
@@ -0,0 +1,86 @@
+// Package mooongorm
+// Wrote by yijian on 2026/10/18
+package mooongorm
+
+import (
+    "context"
+    "gorm.io/driver/sqlite"
+    "gorm.io/gorm"
+    "gorm.io/gorm/logger"
+    "testing"
+    "time"
+)
+import (
+    "github.com/eyjian/gomooon/mooonwepay"
+)
+
+type LedgerOrderModel struct {
+    Id           uint32    `gorm:"column:f_id;primaryKey;autoIncrement"`
+    OutTradeNo   string    `gorm:"column:f_out_trade_no"`
+    Amount       int64     `gorm:"column:f_amount"`
+    RefundAmount int64     `gorm:"column:f_refund_amount"`
+    Fee          *int64    `gorm:"column:f_fee"`
+    State        int       `gorm:"column:f_state"`
+    PayTime      time.Time `gorm:"column:f_pay_time"`
+}
+
+// go test -v -run="TestWepayLedger$"
+func TestWepayLedger(t *testing.T) {
+    db, err := gorm.Open(sqlite.Open("file:ledger?mode=memory"), &gorm.Config{
+        Logger: logger.Default.LogMode(logger.Silent),
+    })
+    if err != nil {
+        t.Fatalf("failed to connect to the database: %v", err)
+    }
+    if err = db.AutoMigrate(&LedgerOrderModel{}); err != nil {
+        t.Fatalf("failed to migrate the schema: %v", err)
+    }
+
+    begin := time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)
+    fee := int64(6)
+    orders := []LedgerOrderModel{
+        {OutTradeNo: "T001", Amount: 1000, Fee: &fee, State: 2, PayTime: begin.Add(time.Hour)},
+        {OutTradeNo: "T002", Amount: 2000, RefundAmount: 500, State: 3, PayTime: begin.Add(2 * time.Hour)},
+        {OutTradeNo: "T003", Amount: 3000, State: 2, PayTime: begin.Add(-time.Hour)}, // 前一天
+    }
+    if err = db.Create(&orders).Error; err != nil {
+        t.Fatalf("failed to create records: %v", err)
+    }
+
+    ledger := NewWepayLedger(db.Model(&LedgerOrderModel{}), WepayLedgerColumns{
+        OutTradeNo:   "f_out_trade_no",
+        Amount:       "f_amount",
+        RefundAmount: "f_refund_amount",
+        Fee:          "f_fee",
+        State:        "f_state",
+        Time:         "f_pay_time",
+    }, WithLedgerStateMapper(func(state string) string {
+        return map[string]string{"1": "NOTPAY", "2": "SUCCESS", "3": "REFUND"}[state]
+    }))
+
+    var ranged []*mooonwepay.LedgerOrder
+    err = ledger.Range(context.Background(), begin, begin.AddDate(0, 0, 1), func(order *mooonwepay.LedgerOrder) error {
+        ranged = append(ranged, order)
+        return nil
+    })
+    if err != nil {
+        t.Fatalf("range error: %v", err)
+    }
+    if len(ranged) != 2 {
+        t.Fatalf("expected 2 orders, got %d", len(ranged))
+    }
+    if ranged[0].OutTradeNo != "T001" || ranged[0].Fee == nil || *ranged[0].Fee != 6 || ranged[0].State != "SUCCESS" {
+        t.Errorf("unexpected order: %+v", ranged[0])
+    }
+    if ranged[1].RefundAmount != 500 || ranged[1].Fee == nil || *ranged[1].Fee != 0 || ranged[1].State != "REFUND" {
+        t.Errorf("unexpected order: %+v", ranged[1])
+    }
+
+    looked, err := ledger.Lookup(context.Background(), []string{"T003", "T404"})
+    if err != nil {
+        t.Fatalf("lookup error: %v", err)
+    }
+    if len(looked) != 1 || looked[0].OutTradeNo != "T003" || looked[0].Amount != 3000 {
+        t.Fatalf("unexpected lookup result: %+v", looked)
+    }
+}
@@ -3,16 +3,19 @@
 package mooonhttp
 
 import (
-    "fmt"
     "io"
     "net/http"
     "os"
+
+    "github.com/eyjian/gomooon/mooonerror"
 )
 
 // DownloadFile 通过 http 下载文件，本实现基于 AI 生成
 // url 文件的链接
 // localFilepath 本地文件路径
 // 第一个返回值为 http 的响应代码，如果其值为 0 表示还没取得 http 的响应代码，是否出错应看第二个返回值是否为 nil
+// 第二个返回值可通过 errors.As 转换为 *mooonerror.CError，错误码为 ErrCodeHttpRequest、ErrCodeHttpStatus 或 ErrCodeHttpFile，
+// 网络错误、5xx 和 429 可通过 mooonerror.IsRetryable 判断是否重试
 func DownloadFile(url, localFilepath string) (int, error) {
     // 创建一个新的 HTTP 客户端
     client := &http.Client{}
@@ -20,31 +23,35 @@ func DownloadFile(url, localFilepath string) (int, error) {
     // 发送 GET 请求
     req, err := http.NewRequest("GET", url, nil)
     if err != nil {
-        return 0, fmt.Errorf("create %s error: %s", url, err.Error())
+        return 0, mooonerror.Errorf(mooonerror.ErrCodeHttpRequest, "create %s error: %w", url, err)
     }
 
     resp, err := client.Do(req)
     if err != nil {
-        return 0, fmt.Errorf("request %s error: %s", url, err.Error())
+        return 0, mooonerror.Errorf(mooonerror.ErrCodeHttpRequest, "request %s error: %w", url, err).WithRetryable()
     }
     defer resp.Body.Close()
 
     // 检查 HTTP 响应状态码
     if resp.StatusCode != http.StatusOK {
-        return resp.StatusCode, fmt.Errorf("HTTP request error: %s", resp.Status)
+        cerr := mooonerror.Errorf(mooonerror.ErrCodeHttpStatus, "HTTP request error: %s", resp.Status).WithDetail("status_code", resp.StatusCode)
+        if resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusTooManyRequests {
+            cerr = cerr.WithRetryable() // 服务端错误和限流可重试
+        }
+        return resp.StatusCode, cerr
     }
 
     // 创建一个新的文件
     file, err := os.Create(localFilepath)
     if err != nil {
-        return 0, fmt.Errorf("create file://%s error: %s", localFilepath, err.Error())
+        return 0, mooonerror.Errorf(mooonerror.ErrCodeHttpFile, "create file://%s error: %w", localFilepath, err)
     }
     defer file.Close()
 
     // 将响应体中的数据写入文件
     _, err = io.Copy(file, resp.Body) // 这里易遇到网络错误：unexpected EOF
     if err != nil {
-        return resp.StatusCode, fmt.Errorf("write file://%s error: %s", localFilepath, err.Error())
+        return resp.StatusCode, mooonerror.Errorf(mooonerror.ErrCodeHttpFile, "write file://%s error: %w", localFilepath, err).WithRetryable()
     }
 
     return resp.StatusCode, nil
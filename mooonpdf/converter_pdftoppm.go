@@ -41,8 +41,8 @@ func (c *PdftoppmConverter) Convert(
 			installHint = getLinuxInstallHint()
 			installHint = "\nInstall command: " + installHint
 		}
-		return nil, mooonerror.NewError(mooonerror.ErrCodeToolNotFound,
-			fmt.Sprintf("pdftoppm not available, please install poppler-utils: %s%s", err.Error(), installHint))
+		return nil, mooonerror.Errorf(mooonerror.ErrCodeToolNotFound,
+			"pdftoppm not available, please install poppler-utils: %w%s", err, installHint)
 	}
 	// 2. 检查 PDF 文件存在
 	if _, err := os.Stat(pdfPath); os.IsNotExist(err) {
@@ -116,16 +116,16 @@ func (c *PdftoppmConverter) Convert(
 	cmd := exec.Command(pdftoppmCmdName, args...)
 	output, err := cmd.CombinedOutput()
 	if err != nil {
-		return nil, mooonerror.NewError(mooonerror.ErrCodeToolExecute,
-			fmt.Sprintf("pdftoppm execution failed: %s, output: %s", err.Error(), string(output)))
+		return nil, mooonerror.Errorf(mooonerror.ErrCodeToolExecute,
+			"pdftoppm execution failed: %w, output: %s", err, string(output))
 	}
 
 	// 8. 收集生成的图片文件
 	ext := "." + string(options.Format)
 	resultFiles, err2 := mooonutils.GetFilesBySuffix(outDir, []string{ext})
 	if err2 != nil {
-		return nil, mooonerror.NewError(mooonerror.ErrCodeFileOperate,
-			fmt.Sprintf("failed to list output files: %s", err2.Error()))
+		return nil, mooonerror.Errorf(mooonerror.ErrCodeFileOperate,
+			"failed to list output files: %w", err2)
 	}
 
 	// 过滤：只返回本次生成的文件（以输出前缀开头）
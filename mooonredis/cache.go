@@ -0,0 +1,328 @@
+// Package mooonredis
+// Wrote by yijian on 2026/10/18
+package mooonredis
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/eyjian/gomooon/mooonerror"
+	"github.com/eyjian/gomooon/mooonstr"
+	"github.com/eyjian/gomooon/mooonutils"
+	"github.com/go-redis/redis/v8"
+)
+
+// ErrCacheNotFound 数据不存在
+// loader 返回该错误时，如果开启了负缓存（WithNegativeTTL），则缓存“不存在”，避免反复穿透到数据库
+var ErrCacheNotFound = mooonerror.NewError(mooonerror.ErrCodeRedisCacheNotFound, "cache: not found")
+
+// 缓存值的首字节：区分正常值和负缓存
+const (
+	cacheValueFlag    = 'v'
+	cacheNotFoundFlag = 'n'
+)
+
+// Codec 缓存值的编解码器
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// JSONCodec JSON 编解码器（默认）
+type JSONCodec struct{}
+
+func (JSONCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (JSONCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+// GobCodec gob 编解码器，只能用于 Go 程序之间共享的缓存
+type GobCodec struct{}
+
+func (GobCodec) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (GobCodec) Unmarshal(data []byte, v interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+// ChineseCodec 先 JSON 编码，再以 mooonstr.CompressChinese 压缩，适合中文较多的值（如发票、地址）
+// 说明：值中不能含有 GBK 无法表示的字符（如 emoji）
+type ChineseCodec struct{}
+
+func (ChineseCodec) Marshal(v interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	compressed, err := mooonstr.CompressChinese(string(data))
+	if err != nil {
+		return nil, err
+	}
+	return []byte(compressed), nil
+}
+
+func (ChineseCodec) Unmarshal(data []byte, v interface{}) error {
+	decompressed, err := mooonstr.DecompressChinese(string(data))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal([]byte(decompressed), v)
+}
+
+// cacheConfig Cache 的可选配置，与值的类型无关
+type cacheConfig struct {
+	codec       Codec
+	negativeTTL time.Duration // 负缓存的时长，为 0 表示不开启
+	jitter      float64       // 过期时间的随机抖动比例，避免大量 key 同时过期
+	leaseTTL    time.Duration // 加载租约的时长，应大于 loader 的最大耗时
+	leaseWait   time.Duration // 等待其他 POD 加载的最长时间，超过后自行加载
+}
+
+// CacheOption Cache 的可选项
+type CacheOption func(*cacheConfig)
+
+// WithCodec 设置编解码器，默认为 JSONCodec
+func WithCodec(codec Codec) CacheOption {
+	return func(c *cacheConfig) {
+		c.codec = codec
+	}
+}
+
+// WithNegativeTTL 开启负缓存：loader 返回 ErrCacheNotFound 时缓存“不存在” ttl 时长
+func WithNegativeTTL(ttl time.Duration) CacheOption {
+	return func(c *cacheConfig) {
+		c.negativeTTL = ttl
+	}
+}
+
+// WithTTLJitter 设置过期时间的随机抖动比例，如 0.1 表示在 [0.9*ttl, 1.1*ttl] 内随机，默认 0.1
+func WithTTLJitter(jitter float64) CacheOption {
+	return func(c *cacheConfig) {
+		c.jitter = jitter
+	}
+}
+
+// WithLoadLease 设置加载租约的时长和等待其他 POD 加载的最长时间，默认均为 5 秒
+func WithLoadLease(leaseTTL, leaseWait time.Duration) CacheOption {
+	return func(c *cacheConfig) {
+		c.leaseTTL = leaseTTL
+		c.leaseWait = leaseWait
+	}
+}
+
+// cacheCall 进程内正在进行的加载
+type cacheCall[T any] struct {
+	wg  sync.WaitGroup
+	val T
+	err error
+}
+
+// Cache 基于 Redis 的旁路缓存（cache-aside），防止缓存击穿
+// 1. 进程内：同一个 key 同时只有一个协程加载（single-flight），其它协程等待其结果
+// 2. 跨 POD：以类似 HoldKey 的短期租约保证同一个 key 同时只有一个 POD 加载，其它 POD 等待缓存写入
+// 使用示例：
+//
+//	cache := NewCache[*User](rdb, "user:", 10*time.Minute, WithNegativeTTL(time.Minute))
+//	user, err := cache.GetOrLoad(ctx, userId, func(ctx context.Context) (*User, error) {
+//		user, err := queryUser(ctx, userId)
+//		if errors.Is(err, gorm.ErrRecordNotFound) {
+//			return nil, ErrCacheNotFound
+//		}
+//		return user, err
+//	})
+type Cache[T any] struct {
+	client redis.UniversalClient
+	prefix string        // key 的前缀
+	ttl    time.Duration // 缓存时长
+	cacheConfig
+
+	mu    sync.Mutex
+	calls map[string]*cacheCall[T]
+}
+
+// NewCache 生成缓存实例
+func NewCache[T any](client redis.UniversalClient, prefix string, ttl time.Duration, opts ...CacheOption) *Cache[T] {
+	c := &Cache[T]{
+		client: client,
+		prefix: prefix,
+		ttl:    ttl,
+		cacheConfig: cacheConfig{
+			codec:     JSONCodec{},
+			jitter:    0.1,
+			leaseTTL:  5 * time.Second,
+			leaseWait: 5 * time.Second,
+		},
+		calls: make(map[string]*cacheCall[T]),
+	}
+	for _, opt := range opts {
+		opt(&c.cacheConfig)
+	}
+	return c
+}
+
+// Get 取缓存
+// 返回值：
+// 1. T: 缓存的值
+// 2. bool: 是否命中（包括命中负缓存）
+// 3. error: 命中负缓存时为 ErrCacheNotFound，出错时为 err
+func (c *Cache[T]) Get(ctx context.Context, key string) (T, bool, error) {
+	var zero T
+
+	data, err := c.client.Get(ctx, c.prefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return zero, false, nil
+	}
+	if err != nil {
+		return zero, false, mooonerror.Wrap(mooonerror.ErrCodeRedis, err)
+	}
+	if len(data) == 0 {
+		return zero, false, nil
+	}
+
+	switch data[0] {
+	case cacheNotFoundFlag:
+		return zero, true, ErrCacheNotFound
+	case cacheValueFlag:
+		var v T
+		if err := c.codec.Unmarshal(data[1:], &v); err != nil {
+			return zero, false, mooonerror.Wrap(mooonerror.ErrCodeRedisCacheCodec, err)
+		}
+		return v, true, nil
+	default:
+		return zero, false, nil // 不是本缓存写入的值，视为未命中
+	}
+}
+
+// Set 写缓存，过期时间带随机抖动
+func (c *Cache[T]) Set(ctx context.Context, key string, v T) error {
+	data, err := c.codec.Marshal(v)
+	if err != nil {
+		return mooonerror.Wrap(mooonerror.ErrCodeRedisCacheCodec, err)
+	}
+	return mooonerror.Wrap(mooonerror.ErrCodeRedis, c.client.Set(ctx, c.prefix+key, append([]byte{cacheValueFlag}, data...), c.jitterTTL(c.ttl)).Err())
+}
+
+// Delete 删除缓存，更新数据库后调用
+func (c *Cache[T]) Delete(ctx context.Context, key string) error {
+	return mooonerror.Wrap(mooonerror.ErrCodeRedis, c.client.Del(ctx, c.prefix+key).Err())
+}
+
+// GetOrLoad 取缓存，未命中时调用 loader 加载并写缓存
+// loader 返回 ErrCacheNotFound 表示数据不存在，开启负缓存时缓存“不存在”
+// Redis 不可用时直接调用 loader，不影响业务
+func (c *Cache[T]) GetOrLoad(ctx context.Context, key string, loader func(ctx context.Context) (T, error)) (T, error) {
+	c.mu.Lock()
+	if call, ok := c.calls[key]; ok {
+		c.mu.Unlock()
+		call.wg.Wait()
+		return call.val, call.err
+	}
+	call := &cacheCall[T]{}
+	call.wg.Add(1)
+	c.calls[key] = call
+	c.mu.Unlock()
+
+	defer func() {
+		// loader panic 时，等待的调用者得到错误而非零值，之后继续 panic
+		r := recover()
+		if r != nil {
+			call.err = mooonerror.Errorf(mooonerror.ErrCodeUnknown, "load cache %s panic: %v", key, r)
+		}
+		c.mu.Lock()
+		delete(c.calls, key)
+		c.mu.Unlock()
+		call.wg.Done()
+		if r != nil {
+			panic(r)
+		}
+	}()
+	call.val, call.err = c.load(ctx, key, loader)
+	return call.val, call.err
+}
+
+// load 在持有进程内加载权时执行：查缓存，未命中时抢租约加载，抢不到则等待其他 POD 写入缓存
+func (c *Cache[T]) load(ctx context.Context, key string, loader func(ctx context.Context) (T, error)) (T, error) {
+	var zero T
+	leaseKey := relatedKeyOf(c.prefix+key, "lease")
+	leaseValue := mooonutils.GetNonceStr(16)
+	deadline := time.Now().Add(c.leaseWait)
+
+	// 初始重试间隔
+	retryInterval := 20 * time.Millisecond
+	// 最大重试间隔
+	maxRetryInterval := 200 * time.Millisecond
+
+	for {
+		v, hit, err := c.Get(ctx, key)
+		if hit {
+			return v, err
+		}
+		if err != nil && mooonerror.Code(err) != mooonerror.ErrCodeRedisCacheCodec {
+			return loader(ctx) // Redis 不可用
+		}
+		// 解码失败（如值损坏或结构变更）视为未命中，抢租约加载后覆盖
+
+		leased, err := c.client.SetNX(ctx, leaseKey, leaseValue, c.leaseTTL).Result()
+		if err != nil {
+			return loader(ctx)
+		}
+		if leased {
+			defer unlockScript.Run(context.Background(), c.client, []string{leaseKey, acquiredKeyOf(leaseKey)}, leaseValue)
+			return c.loadAndSet(ctx, key, loader)
+		}
+
+		// 其他 POD 正在加载，等待超时后自行加载
+		if time.Now().After(deadline) {
+			return c.loadAndSet(ctx, key, loader)
+		}
+		timer := time.NewTimer(retryInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return zero, ctx.Err()
+		case <-timer.C:
+			retryInterval = time.Duration(math.Min(float64(retryInterval*2), float64(maxRetryInterval)))
+		}
+	}
+}
+
+// loadAndSet 调用 loader 加载并写缓存，写缓存失败不影响返回结果
+func (c *Cache[T]) loadAndSet(ctx context.Context, key string, loader func(ctx context.Context) (T, error)) (T, error) {
+	v, err := loader(ctx)
+	if errors.Is(err, ErrCacheNotFound) {
+		if c.negativeTTL > 0 {
+			c.client.Set(ctx, c.prefix+key, []byte{cacheNotFoundFlag}, c.jitterTTL(c.negativeTTL))
+		}
+		return v, err
+	}
+	if err != nil {
+		return v, err
+	}
+
+	_ = c.Set(ctx, key, v)
+	return v, nil
+}
+
+// jitterTTL 为 ttl 加上随机抖动
+func (c *Cache[T]) jitterTTL(ttl time.Duration) time.Duration {
+	if c.jitter <= 0 {
+		return ttl
+	}
+	return time.Duration(float64(ttl) * (1 + c.jitter*(rand.Float64()*2-1)))
+}
@@ -0,0 +1,125 @@
+// Package mooonredis
+// Wrote by yijian on 2026/10/18
+package mooonredis
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/eyjian/gomooon/mooonerror"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+)
+
+type cacheTestInvoice struct {
+	Seller string
+	Amount int64
+}
+
+// TestCodec 测试编解码器
+// go test -v -run="TestCodec"
+func TestCodec(t *testing.T) {
+	invoice := &cacheTestInvoice{Seller: "深圳市腾讯计算机系统有限公司", Amount: 100}
+	for _, codec := range []Codec{JSONCodec{}, GobCodec{}, ChineseCodec{}} {
+		data, err := codec.Marshal(invoice)
+		assert.NoError(t, err)
+
+		var decoded *cacheTestInvoice
+		assert.NoError(t, codec.Unmarshal(data, &decoded))
+		assert.Equal(t, invoice, decoded)
+	}
+}
+
+// TestCacheGetOrLoad 测试并发加载只穿透一次，以及负缓存
+// go test -v -run="TestCacheGetOrLoad"
+func TestCacheGetOrLoad(t *testing.T) {
+	ctx := context.Background()
+	client := getTestRedisClient(t)
+	defer client.Close()
+	client.Del(ctx, "test-cache:1", "test-cache:2")
+
+	// 两个实例模拟两个 POD
+	cache1 := NewCache[*cacheTestInvoice](client, "test-cache:", time.Minute, WithNegativeTTL(time.Minute))
+	cache2 := NewCache[*cacheTestInvoice](client, "test-cache:", time.Minute, WithNegativeTTL(time.Minute))
+
+	var loads int32
+	loader := func(ctx context.Context) (*cacheTestInvoice, error) {
+		atomic.AddInt32(&loads, 1)
+		time.Sleep(100 * time.Millisecond)
+		return &cacheTestInvoice{Seller: "腾讯", Amount: 1}, nil
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		cache := cache1
+		if i%2 == 1 {
+			cache = cache2
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			invoice, err := cache.GetOrLoad(ctx, "1", loader)
+			assert.NoError(t, err)
+			assert.Equal(t, "腾讯", invoice.Seller)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&loads))
+
+	// 负缓存
+	notFound := func(ctx context.Context) (*cacheTestInvoice, error) {
+		atomic.AddInt32(&loads, 1)
+		return nil, ErrCacheNotFound
+	}
+	_, err := cache1.GetOrLoad(ctx, "2", notFound)
+	assert.True(t, errors.Is(err, ErrCacheNotFound))
+	_, err = cache2.GetOrLoad(ctx, "2", notFound)
+	assert.True(t, errors.Is(err, ErrCacheNotFound))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&loads))
+
+	// 解码失败视为未命中，加载后覆盖损坏的值
+	client.Set(ctx, "test-cache:3", append([]byte{cacheValueFlag}, "{bad"...), time.Minute)
+	invoice, err := cache1.GetOrLoad(ctx, "3", loader)
+	assert.NoError(t, err)
+	assert.Equal(t, "腾讯", invoice.Seller)
+	_, hit, err := cache2.Get(ctx, "3")
+	assert.NoError(t, err)
+	assert.True(t, hit)
+	client.Del(ctx, "test-cache:3")
+}
+
+// TestCacheGetOrLoadPanic 测试 loader panic 时等待的调用者得到错误
+// go test -v -run="TestCacheGetOrLoadPanic"
+func TestCacheGetOrLoadPanic(t *testing.T) {
+	ctx := context.Background()
+	client := redis.NewClient(&redis.Options{Addr: "localhost:1"}) // Redis 不可用时直接调用 loader
+	defer client.Close()
+	cache := NewCache[*cacheTestInvoice](client, "test-cache:", time.Minute)
+
+	loading := make(chan struct{})
+	release := make(chan struct{})
+	go func() {
+		defer func() { assert.NotNil(t, recover()) }()
+		_, _ = cache.GetOrLoad(ctx, "1", func(ctx context.Context) (*cacheTestInvoice, error) {
+			close(loading)
+			<-release
+			panic("db crashed")
+		})
+	}()
+
+	<-loading
+	done := make(chan error)
+	go func() {
+		invoice, err := cache.GetOrLoad(ctx, "1", func(ctx context.Context) (*cacheTestInvoice, error) {
+			return &cacheTestInvoice{}, nil
+		})
+		assert.Nil(t, invoice)
+		done <- err
+	}()
+	time.Sleep(50 * time.Millisecond) // 等待第二个调用者进入等待
+	close(release)
+	assert.Equal(t, mooonerror.ErrCodeUnknown, mooonerror.Code(<-done))
+}
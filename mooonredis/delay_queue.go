@@ -0,0 +1,409 @@
+// Package mooonredis
+// Wrote by yijian on 2026/10/18
+package mooonredis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/eyjian/gomooon/mooonerror"
+	"github.com/eyjian/gomooon/mooonutils"
+	"github.com/go-redis/redis/v8"
+)
+
+// 延迟队列的 key（以队列名为 hash tag，集群模式下落在同一个 slot）：
+// delayed  sorted set，member 为任务 ID，score 为到期时间（毫秒）
+// running  sorted set，member 为任务 ID，score 为可见性超时时间（毫秒），超时未确认的任务将被重新投递
+// payloads hash，任务 ID 到任务内容
+// attempts hash，任务 ID 到已投递次数
+// errors   hash，任务 ID 到最近一次失败的原因
+// dead     list，超过最大投递次数的任务 ID（死信）
+// 脚本统一使用 Redis 服务端时间，避免多个 POD 之间的时钟偏差
+
+var delayEnqueueScript = redis.NewScript(`
+redis.replicate_commands()
+local t = redis.call("TIME")
+local now_ms = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+
+redis.call("HSET", KEYS[2], ARGV[1], ARGV[2])
+redis.call("ZADD", KEYS[1], now_ms + tonumber(ARGV[3]), ARGV[1])
+return 1
+`)
+
+var delayClaimScript = redis.NewScript(`
+redis.replicate_commands()
+local delayed_key = KEYS[1]
+local running_key = KEYS[2]
+local payloads_key = KEYS[3]
+local attempts_key = KEYS[4]
+local dead_key = KEYS[5]
+local visibility_ms = tonumber(ARGV[1])
+local max_attempts = tonumber(ARGV[2])
+
+local t = redis.call("TIME")
+local now_ms = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+
+-- 可见性超时（处理者崩溃或处理过慢）的任务重新投递
+local expired = redis.call("ZRANGEBYSCORE", running_key, "-inf", now_ms, "LIMIT", 0, 100)
+for _, id in ipairs(expired) do
+	redis.call("ZREM", running_key, id)
+	redis.call("ZADD", delayed_key, now_ms, id)
+end
+
+while true do
+	local id = redis.call("ZRANGEBYSCORE", delayed_key, "-inf", now_ms, "LIMIT", 0, 1)[1]
+	if not id then
+		return false
+	end
+
+	redis.call("ZREM", delayed_key, id)
+	local attempts = redis.call("HINCRBY", attempts_key, id, 1)
+	if max_attempts > 0 and attempts > max_attempts then
+		redis.call("LPUSH", dead_key, id) -- 超时重投的次数也计入，超过后转入死信
+	else
+		redis.call("ZADD", running_key, now_ms + visibility_ms, id)
+		return {id, redis.call("HGET", payloads_key, id) or "", attempts}
+	end
+end
+`)
+
+var delayAckScript = redis.NewScript(`
+if redis.call("ZREM", KEYS[1], ARGV[1]) == 0 then
+	return 0 -- 已超时被重新投递
+end
+redis.call("HDEL", KEYS[2], ARGV[1])
+redis.call("HDEL", KEYS[3], ARGV[1])
+redis.call("HDEL", KEYS[4], ARGV[1])
+return 1
+`)
+
+var delayNackScript = redis.NewScript(`
+redis.replicate_commands()
+local delayed_key = KEYS[1]
+local running_key = KEYS[2]
+local attempts_key = KEYS[3]
+local errors_key = KEYS[4]
+local dead_key = KEYS[5]
+local id = ARGV[1]
+local delay_ms = tonumber(ARGV[2])
+local max_attempts = tonumber(ARGV[3])
+
+if redis.call("ZREM", running_key, id) == 0 then
+	return 0 -- 已超时被重新投递
+end
+redis.call("HSET", errors_key, id, ARGV[4])
+if max_attempts > 0 and tonumber(redis.call("HGET", attempts_key, id) or "0") >= max_attempts then
+	redis.call("LPUSH", dead_key, id)
+	return 2 -- 转入死信
+end
+
+local t = redis.call("TIME")
+local now_ms = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+redis.call("ZADD", delayed_key, now_ms + delay_ms, id)
+return 1
+`)
+
+var delayRequeueDeadScript = redis.NewScript(`
+redis.replicate_commands()
+if redis.call("LREM", KEYS[3], 1, ARGV[1]) == 0 then
+	return 0
+end
+redis.call("HDEL", KEYS[2], ARGV[1])
+local t = redis.call("TIME")
+redis.call("ZADD", KEYS[1], tonumber(t[1]) * 1000, ARGV[1])
+return 1
+`)
+
+var delayDeleteDeadScript = redis.NewScript(`
+if redis.call("LREM", KEYS[1], 1, ARGV[1]) == 0 then
+	return 0
+end
+redis.call("HDEL", KEYS[2], ARGV[1])
+redis.call("HDEL", KEYS[3], ARGV[1])
+redis.call("HDEL", KEYS[4], ARGV[1])
+return 1
+`)
+
+// 最大重试间隔
+const maxDelayRetryBackoff = 10 * time.Minute
+
+// ErrDelayJobNotRunning 确认任务时任务已不在处理中，通常是因可见性超时已被重新投递
+var ErrDelayJobNotRunning = mooonerror.NewError(mooonerror.ErrCodeRedisJobNotRunning, "delay job is not running, it may have been redelivered")
+
+// DelayJob 延迟任务
+type DelayJob struct {
+	ID       string
+	Payload  []byte
+	Attempts int // 已投递次数（含本次），从 1 开始
+}
+
+// DeadLetter 死信：超过最大投递次数的任务
+type DeadLetter struct {
+	ID        string
+	Payload   []byte
+	Attempts  int
+	LastError string // 最近一次失败的原因，可见性超时时为空
+}
+
+// DelayHandler 任务处理函数，返回 nil 表示处理成功，否则按退避时间重试
+// 需在可见性超时时间内返回，否则任务会被重新投递给其它处理者
+type DelayHandler func(ctx context.Context, job *DelayJob) error
+
+// retryAfterError 指定重试时间的错误
+type retryAfterError struct {
+	delay time.Duration
+}
+
+func (e *retryAfterError) Error() string {
+	return fmt.Sprintf("retry after %s", e.delay)
+}
+
+// RetryAfter 处理函数返回该错误时，任务在 delay 之后重试，而不是按退避时间重试
+// 如微信支付回单仍在生成中（GENERATING）时：return mooonredis.RetryAfter(30 * time.Second)
+func RetryAfter(delay time.Duration) error {
+	return &retryAfterError{delay: delay}
+}
+
+// DelayQueue 基于 Redis sorted set 的延迟队列，任务在到期后被投递给处理者
+// 任务至少投递一次（at-least-once），处理函数应当幂等
+type DelayQueue struct {
+	client            redis.UniversalClient
+	name              string
+	visibilityTimeout time.Duration // 处理任务的最长时间，超过后任务被重新投递
+	maxAttempts       int           // 最大投递次数，超过后转入死信，为 0 表示不限
+	retryBackoff      time.Duration // 首次重试的间隔，之后每次翻倍，最大 10 分钟
+	pollInterval      time.Duration // 无到期任务时的轮询间隔
+}
+
+// DelayQueueOption DelayQueue 的可选项
+type DelayQueueOption func(*DelayQueue)
+
+// WithVisibilityTimeout 设置可见性超时时间，默认 1 分钟
+func WithVisibilityTimeout(timeout time.Duration) DelayQueueOption {
+	return func(q *DelayQueue) {
+		q.visibilityTimeout = timeout
+	}
+}
+
+// WithMaxAttempts 设置最大投递次数，默认 10 次，为 0 表示不限
+func WithMaxAttempts(maxAttempts int) DelayQueueOption {
+	return func(q *DelayQueue) {
+		q.maxAttempts = maxAttempts
+	}
+}
+
+// WithRetryBackoff 设置首次重试的间隔，默认 1 秒
+func WithRetryBackoff(backoff time.Duration) DelayQueueOption {
+	return func(q *DelayQueue) {
+		q.retryBackoff = backoff
+	}
+}
+
+// WithPollInterval 设置无到期任务时的轮询间隔，默认 1 秒
+func WithPollInterval(interval time.Duration) DelayQueueOption {
+	return func(q *DelayQueue) {
+		q.pollInterval = interval
+	}
+}
+
+// NewDelayQueue 生成延迟队列实例，多个 POD 使用相同的 name 即共享同一个队列
+func NewDelayQueue(client redis.UniversalClient, name string, opts ...DelayQueueOption) *DelayQueue {
+	q := &DelayQueue{
+		client:            client,
+		name:              name,
+		visibilityTimeout: time.Minute,
+		maxAttempts:       10,
+		retryBackoff:      time.Second,
+		pollInterval:      time.Second,
+	}
+	for _, opt := range opts {
+		opt(q)
+	}
+	return q
+}
+
+// Enqueue 添加任务，delay 之后到期，返回任务 ID
+func (q *DelayQueue) Enqueue(ctx context.Context, payload []byte, delay time.Duration) (string, error) {
+	id := mooonutils.GetNonceStr(32)
+	err := delayEnqueueScript.Run(ctx, q.client, []string{q.key("delayed"), q.key("payloads")},
+		id, payload, delay.Milliseconds()).Err()
+	if err != nil {
+		return "", mooonerror.Wrap(mooonerror.ErrCodeRedis, err)
+	}
+	return id, nil
+}
+
+// Claim 取一个到期的任务，没有到期的任务时返回 nil, nil
+// 取到的任务需在可见性超时时间内调用 Ack 或 Nack
+func (q *DelayQueue) Claim(ctx context.Context) (*DelayJob, error) {
+	res, err := delayClaimScript.Run(ctx, q.client,
+		[]string{q.key("delayed"), q.key("running"), q.key("payloads"), q.key("attempts"), q.key("dead")},
+		q.visibilityTimeout.Milliseconds(), q.maxAttempts).Slice()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, mooonerror.Wrap(mooonerror.ErrCodeRedis, err)
+	}
+
+	attempts, _ := res[2].(int64)
+	return &DelayJob{
+		ID:       res[0].(string),
+		Payload:  []byte(res[1].(string)),
+		Attempts: int(attempts),
+	}, nil
+}
+
+// Ack 确认任务处理成功，删除任务
+// 任务已因可见性超时被重新投递时返回 ErrDelayJobNotRunning
+func (q *DelayQueue) Ack(ctx context.Context, id string) error {
+	res, err := delayAckScript.Run(ctx, q.client,
+		[]string{q.key("running"), q.key("payloads"), q.key("attempts"), q.key("errors")}, id).Int()
+	if err != nil {
+		return mooonerror.Wrap(mooonerror.ErrCodeRedis, err)
+	}
+	if res != 1 {
+		return ErrDelayJobNotRunning
+	}
+	return nil
+}
+
+// Nack 任务处理失败，按退避时间（或 RetryAfter 指定的时间）重试，超过最大投递次数时转入死信
+// 任务已因可见性超时被重新投递时返回 ErrDelayJobNotRunning
+func (q *DelayQueue) Nack(ctx context.Context, job *DelayJob, cause error) error {
+	delay := q.retryBackoff
+	if job.Attempts > 1 {
+		delay = q.retryBackoff << (job.Attempts - 1)
+	}
+	if delay > maxDelayRetryBackoff || delay <= 0 {
+		delay = maxDelayRetryBackoff
+	}
+	var retryAfter *retryAfterError
+	if errors.As(cause, &retryAfter) {
+		delay = retryAfter.delay
+	}
+	errMsg := ""
+	if cause != nil {
+		errMsg = cause.Error()
+	}
+
+	res, err := delayNackScript.Run(ctx, q.client,
+		[]string{q.key("delayed"), q.key("running"), q.key("attempts"), q.key("errors"), q.key("dead")},
+		job.ID, delay.Milliseconds(), q.maxAttempts, errMsg).Int()
+	if err != nil {
+		return mooonerror.Wrap(mooonerror.ErrCodeRedis, err)
+	}
+	if res == 0 {
+		return ErrDelayJobNotRunning
+	}
+	return nil
+}
+
+// Run 启动 workers 个协程处理任务，阻塞直到 ctx 结束且所有处理中的任务返回
+// 处理函数 panic 时视为处理失败
+func (q *DelayQueue) Run(ctx context.Context, workers int, handler DelayHandler) {
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			q.work(ctx, handler)
+		}()
+	}
+	wg.Wait()
+}
+
+// work 单个处理协程
+func (q *DelayQueue) work(ctx context.Context, handler DelayHandler) {
+	for ctx.Err() == nil {
+		job, err := q.Claim(ctx)
+		if err != nil || job == nil {
+			timer := time.NewTimer(q.pollInterval)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+			continue
+		}
+
+		err = q.handle(ctx, handler, job)
+
+		// ctx 结束时也要确认，避免已处理的任务被重复投递
+		ackCtx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		if err == nil {
+			_ = q.Ack(ackCtx, job.ID)
+		} else {
+			_ = q.Nack(ackCtx, job, err)
+		}
+		cancel()
+	}
+}
+
+// handle 调用处理函数，将 panic 转为错误
+func (q *DelayQueue) handle(ctx context.Context, handler DelayHandler, job *DelayJob) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = mooonerror.Errorf(mooonerror.ErrCodeUnknown, "panic: %v", r)
+		}
+	}()
+	return handler(ctx, job)
+}
+
+// DeadLetters 列出最近的 n 个死信，最新的在最前
+func (q *DelayQueue) DeadLetters(ctx context.Context, n int64) ([]*DeadLetter, error) {
+	ids, err := q.client.LRange(ctx, q.key("dead"), 0, n-1).Result()
+	if err != nil || len(ids) == 0 {
+		return nil, mooonerror.Wrap(mooonerror.ErrCodeRedis, err)
+	}
+
+	pipe := q.client.Pipeline()
+	payloads := pipe.HMGet(ctx, q.key("payloads"), ids...)
+	attempts := pipe.HMGet(ctx, q.key("attempts"), ids...)
+	errs := pipe.HMGet(ctx, q.key("errors"), ids...)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, mooonerror.Wrap(mooonerror.ErrCodeRedis, err)
+	}
+
+	letters := make([]*DeadLetter, len(ids))
+	for i, id := range ids {
+		letter := &DeadLetter{ID: id}
+		if payload, ok := payloads.Val()[i].(string); ok {
+			letter.Payload = []byte(payload)
+		}
+		if attempt, ok := attempts.Val()[i].(string); ok {
+			letter.Attempts, _ = strconv.Atoi(attempt)
+		}
+		if lastError, ok := errs.Val()[i].(string); ok {
+			letter.LastError = lastError
+		}
+		letters[i] = letter
+	}
+	return letters, nil
+}
+
+// RequeueDeadLetter 将死信重新放回队列并立即到期，投递次数清零
+// 成功返回 true，死信不存在返回 false
+func (q *DelayQueue) RequeueDeadLetter(ctx context.Context, id string) (bool, error) {
+	res, err := delayRequeueDeadScript.Run(ctx, q.client,
+		[]string{q.key("delayed"), q.key("attempts"), q.key("dead")}, id).Int()
+	return res == 1, mooonerror.Wrap(mooonerror.ErrCodeRedis, err)
+}
+
+// DeleteDeadLetter 删除死信
+// 成功返回 true，死信不存在返回 false
+func (q *DelayQueue) DeleteDeadLetter(ctx context.Context, id string) (bool, error) {
+	res, err := delayDeleteDeadScript.Run(ctx, q.client,
+		[]string{q.key("dead"), q.key("payloads"), q.key("attempts"), q.key("errors")}, id).Int()
+	return res == 1, mooonerror.Wrap(mooonerror.ErrCodeRedis, err)
+}
+
+// key 取得队列的 key
+func (q *DelayQueue) key(suffix string) string {
+	return relatedKeyOf(q.name, suffix)
+}
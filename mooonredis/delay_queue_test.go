@@ -0,0 +1,102 @@
+// Package mooonredis
+// Wrote by yijian on 2026/10/18
+package mooonredis
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestDelayQueue 测试任务到期投递、重试和死信
+// go test -v -run="TestDelayQueue"
+func TestDelayQueue(t *testing.T) {
+	ctx := context.Background()
+	client := getTestRedisClient(t)
+	defer client.Close()
+	name := "test-delay-queue"
+	q := NewDelayQueue(client, name, WithMaxAttempts(2), WithRetryBackoff(10*time.Millisecond))
+	for _, suffix := range []string{"delayed", "running", "payloads", "attempts", "errors", "dead"} {
+		client.Del(ctx, q.key(suffix))
+	}
+
+	id, err := q.Enqueue(ctx, []byte("receipt-1"), 200*time.Millisecond)
+	assert.NoError(t, err)
+
+	// 未到期
+	job, err := q.Claim(ctx)
+	assert.NoError(t, err)
+	assert.Nil(t, job)
+
+	// 到期后投递，失败重试
+	time.Sleep(250 * time.Millisecond)
+	job, err = q.Claim(ctx)
+	assert.NoError(t, err)
+	if assert.NotNil(t, job) {
+		assert.Equal(t, id, job.ID)
+		assert.Equal(t, []byte("receipt-1"), job.Payload)
+		assert.Equal(t, 1, job.Attempts)
+		assert.NoError(t, q.Nack(ctx, job, errors.New("GENERATING")))
+	}
+
+	// 第二次失败后转入死信
+	time.Sleep(50 * time.Millisecond)
+	job, err = q.Claim(ctx)
+	assert.NoError(t, err)
+	if assert.NotNil(t, job) {
+		assert.Equal(t, 2, job.Attempts)
+		assert.NoError(t, q.Nack(ctx, job, errors.New("FAILED")))
+	}
+	letters, err := q.DeadLetters(ctx, 10)
+	assert.NoError(t, err)
+	if assert.Len(t, letters, 1) {
+		assert.Equal(t, id, letters[0].ID)
+		assert.Equal(t, "FAILED", letters[0].LastError)
+	}
+
+	// 重新放回队列后处理成功
+	ok, err := q.RequeueDeadLetter(ctx, id)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	job, err = q.Claim(ctx)
+	assert.NoError(t, err)
+	if assert.NotNil(t, job) {
+		assert.Equal(t, 1, job.Attempts)
+		assert.NoError(t, q.Ack(ctx, job.ID))
+		assert.ErrorIs(t, q.Ack(ctx, job.ID), ErrDelayJobNotRunning)
+	}
+}
+
+// TestDelayQueueRun 测试处理协程池
+// go test -v -run="TestDelayQueueRun"
+func TestDelayQueueRun(t *testing.T) {
+	ctx := context.Background()
+	client := getTestRedisClient(t)
+	defer client.Close()
+	q := NewDelayQueue(client, "test-delay-queue-run", WithPollInterval(20*time.Millisecond))
+	for _, suffix := range []string{"delayed", "running", "payloads", "attempts", "errors", "dead"} {
+		client.Del(ctx, q.key(suffix))
+	}
+
+	for i := 0; i < 10; i++ {
+		_, err := q.Enqueue(ctx, []byte("job"), 0)
+		assert.NoError(t, err)
+	}
+
+	var handled int32
+	runCtx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	q.Run(runCtx, 3, func(ctx context.Context, job *DelayJob) error {
+		// 第一次投递时要求稍后重试
+		if job.Attempts == 1 {
+			return RetryAfter(50 * time.Millisecond)
+		}
+		atomic.AddInt32(&handled, 1)
+		return nil
+	})
+	assert.Equal(t, int32(10), atomic.LoadInt32(&handled))
+}
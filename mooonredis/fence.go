@@ -0,0 +1,98 @@
+// Package mooonredis
+// Wrote by yijian on 2026/10/18
+package mooonredis
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/eyjian/gomooon/mooonerror"
+	"github.com/go-redis/redis/v8"
+)
+
+// fencing token（防护令牌）说明：
+// 锁的持有者可能因 GC 停顿、网络抖动等原因在锁过期后仍然认为自己持有锁，
+// 此时如果直接写存储，会覆盖新持有者的写入。
+// 每次成功获取锁时对计数器执行 INCR 得到一个单调递增的 token，
+// 写存储时带上 token，存储拒绝 token 小于已写入值的请求（参见 mooongorm.UpdateWithFence）。
+
+// fenceKeyOf 取得 key 对应的 fencing token 计数器的 key
+func fenceKeyOf(key string) string {
+	return relatedKeyOf(key, "fence")
+}
+
+// relatedKeyOf 取得 key 的关联 key（如 fencing token 计数器、等待队列等）
+// 关联 key 和锁 key 需在 Lua 脚本中同时操作，在集群模式下需要落在同一个 slot：
+// 1. 如果 key 已含有 hash tag（如 {job}:lock），则直接加后缀，hash tag 不变
+// 2. 否则将整个 key 作为 hash tag（如 job_lock 对应 {job_lock}:fence）
+// 3. key 不含 hash tag 却含有 }（如 a{}b，空的 {} 不是 hash tag）时无法作为 hash tag，
+// 改用与 key 同 slot 的数字作为 hash tag（如 a{}b 对应 {n}:a{}b:fence）
+func relatedKeyOf(key, suffix string) string {
+	if start := strings.Index(key, "{"); start >= 0 {
+		if end := strings.Index(key[start+1:], "}"); end > 0 {
+			return key + ":" + suffix
+		}
+	}
+	if !strings.Contains(key, "}") {
+		return "{" + key + "}:" + suffix
+	}
+	return "{" + slotTag(keyHashSlot(key)) + "}:" + key + ":" + suffix
+}
+
+// slotTags 缓存各 slot 对应的 hash tag
+var slotTags sync.Map
+
+// slotTag 取得落在 slot 上的最小非负整数的字符串形式
+func slotTag(slot uint16) string {
+	if tag, ok := slotTags.Load(slot); ok {
+		return tag.(string)
+	}
+	for i := 0; ; i++ {
+		tag := strconv.Itoa(i)
+		if crc16(tag)%clusterSlots == slot {
+			slotTags.Store(slot, tag)
+			return tag
+		}
+	}
+}
+
+// clusterSlots Redis 集群的 slot 数
+const clusterSlots = 16384
+
+// keyHashSlot 按 Redis 集群的规则计算 key 所在的 slot：有非空的 hash tag 时只对 hash tag 计算
+func keyHashSlot(key string) uint16 {
+	if start := strings.Index(key, "{"); start >= 0 {
+		if end := strings.Index(key[start+1:], "}"); end > 0 {
+			key = key[start+1 : start+1+end]
+		}
+	}
+	return crc16(key) % clusterSlots
+}
+
+// crc16 Redis 集群使用的 CRC16（XMODEM）
+func crc16(s string) uint16 {
+	var crc uint16
+	for i := 0; i < len(s); i++ {
+		crc ^= uint16(s[i]) << 8
+		for j := 0; j < 8; j++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+// GetFence 取得 key 当前的 fencing token，从未获取过锁时返回 0
+func GetFence(ctx context.Context, client redis.UniversalClient, key string) (int64, error) {
+	fence, err := client.Get(ctx, fenceKeyOf(key)).Int64()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	return fence, mooonerror.Wrap(mooonerror.ErrCodeRedis, err)
+}
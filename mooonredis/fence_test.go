@@ -0,0 +1,27 @@
+// Package mooonredis
+// Wrote by yijian on 2026/10/18
+package mooonredis
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestFenceKeyOf 计数器 key 需同锁 key 的 hash tag 一致
+// go test -v -run="TestFenceKeyOf"
+func TestFenceKeyOf(t *testing.T) {
+	assert.Equal(t, "{job_lock}:fence", fenceKeyOf("job_lock"))
+	assert.Equal(t, "{job}:lock:fence", fenceKeyOf("{job}:lock"))
+
+	// 计算 slot 的规则同 Redis 集群
+	assert.Equal(t, uint16(12182), keyHashSlot("foo"))
+	assert.Equal(t, keyHashSlot("bar"), keyHashSlot("{bar}:foo"))
+
+	// 空的 {} 不是 hash tag，关联 key 需同整个 key 落在同一个 slot
+	for _, key := range []string{"a{}b", "{}job", "job}", "a{}b{c}"} {
+		related := fenceKeyOf(key)
+		assert.Equal(t, keyHashSlot(key), keyHashSlot(related), related)
+		assert.True(t, isLockRelatedKey(related))
+	}
+}
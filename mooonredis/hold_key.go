@@ -6,13 +6,17 @@ import (
 	"context"
 	"time"
 
+	"github.com/eyjian/gomooon/mooonerror"
 	"github.com/go-redis/redis/v8"
 )
 
+// KEYS[2] 记录获取时间（毫秒），与 key 同时设置、续期和删除，供 GetLockInfo 查看
 var rentScript = redis.NewScript(`
 local key = KEYS[1]
+local acquired_key = KEYS[2]
 local new_val = ARGV[1]
 local ttl_ms = tonumber(ARGV[2])
+local now_ms = ARGV[3]
 
 local current_val = redis.call("GET", key)
 if current_val == new_val then
@@ -26,6 +30,7 @@ end
 
 local ok = redis.call("SET", key, new_val, "PX", ttl_ms, "NX")
 if ok then
+	redis.call("SET", acquired_key, now_ms, "PX", ttl_ms)
 	return 1 -- key不存在，设置成功
 end
 return -1 -- key已存在，且值不匹配
@@ -33,6 +38,7 @@ return -1 -- key已存在，且值不匹配
 
 var renewScript = redis.NewScript(`
 local key = KEYS[1]
+local acquired_key = KEYS[2]
 local target_val = ARGV[1]
 local ttl_ms = tonumber(ARGV[2])
 
@@ -46,11 +52,13 @@ if current_val ~= target_val then
 end
 
 redis.call("PEXPIRE", key, ttl_ms)
+redis.call("PEXPIRE", acquired_key, ttl_ms)
 return 1 -- key存在，续期成功
 `)
 
 var releaseScript = redis.NewScript(`
 local key = KEYS[1]
+local acquired_key = KEYS[2]
 local target_val = ARGV[1]
 
 local current_val = redis.call("GET", key)
@@ -62,15 +70,42 @@ if current_val ~= target_val then
 	return 0  -- 值不匹配，拒绝操作
 end
 
-redis.call("DEL", key)
+redis.call("DEL", key, acquired_key)
 return 1 -- key存在，释放成功
 `)
 
+var rentWithFenceScript = redis.NewScript(`
+local key = KEYS[1]
+local fence_key = KEYS[2]
+local acquired_key = KEYS[3]
+local new_val = ARGV[1]
+local ttl_ms = tonumber(ARGV[2])
+local now_ms = ARGV[3]
+
+local current_val = redis.call("GET", key)
+if current_val == new_val then
+	local remaining = redis.call("PTTL", key)
+	if remaining > 0 then
+		return {2, tonumber(redis.call("GET", fence_key) or "0")} -- key已存在，且未过期，返回当前 token
+	end
+elseif current_val then
+	return {0, 0} -- key已存在，但值不匹配
+end
+
+local ok = redis.call("SET", key, new_val, "PX", ttl_ms, "NX")
+if ok then
+	redis.call("SET", acquired_key, now_ms, "PX", ttl_ms)
+	return {1, redis.call("INCR", fence_key)} -- key不存在，设置成功，返回新 token
+end
+return {-1, 0} -- key已存在，且值不匹配
+`)
+
 type HoldKey struct {
 	RedisClient redis.UniversalClient
 	Key         string        // 定时器独一无二的 key
 	Value       string        // 建议使用 POD_IP
 	Expiration  time.Duration // 设置至少 1 分钟的值
+	Observer    Observer      // 观测钩子，为 nil 时使用全局默认的观测钩子（参见 SetObserver）
 }
 
 // RentKey 用于实现分布式锁
@@ -83,15 +118,19 @@ type HoldKey struct {
 // 3. 如果 key 存在，但 value 与当前值不同，则返回 false
 // 4. 如果设置 key 时发生错误，则返回 false, err
 func RentKey(ctx context.Context, hk *HoldKey) (bool, error) {
-	keys := []string{hk.Key}
+	keys := []string{hk.Key, acquiredKeyOf(hk.Key)}
 	args := []interface{}{
 		hk.Value,                     // value
 		hk.Expiration.Milliseconds(), // ttl
+		time.Now().UnixMilli(),       // 获取时间
 	}
 
+	startTime := time.Now()
 	res, err := rentScript.Run(ctx, hk.RedisClient, keys, args...).Int()
+	acquired := res == 1 || res == 2
+	hk.observe(ctx, LockOpAcquire, startTime, acquired, err == nil && !acquired, err)
 	if err != nil {
-		return false, err // 错误时返回 false, err
+		return false, mooonerror.Wrap(mooonerror.ErrCodeRedis, err) // 错误时返回 false, err
 	}
 
 	switch res {
@@ -108,6 +147,40 @@ func RentKey(ctx context.Context, hk *HoldKey) (bool, error) {
 	}
 }
 
+// RentKeyWithFence 同 RentKey，但成功时额外返回 fencing token
+// 返回值：
+// 1. bool: 是否成功
+// 2. int64: fencing token，每次新获取 key 时单调递增；同一持有者重复调用时返回当前值；失败时为 0
+// 3. error: 错误信息
+// 说明：
+// 写下游存储时带上 fencing token，存储拒绝比已写入值小的 token，
+// 以避免暂停超过 Expiration 的旧持有者覆盖新持有者的写入
+// 同一个 key 须始终使用 RentKeyWithFence：RentKey 获取 key 时不递增计数器，混用时 token 不能反映持有者的更替
+func RentKeyWithFence(ctx context.Context, hk *HoldKey) (bool, int64, error) {
+	keys := []string{hk.Key, fenceKeyOf(hk.Key), acquiredKeyOf(hk.Key)}
+	args := []interface{}{
+		hk.Value,                     // value
+		hk.Expiration.Milliseconds(), // ttl
+		time.Now().UnixMilli(),       // 获取时间
+	}
+
+	startTime := time.Now()
+	res, err := rentWithFenceScript.Run(ctx, hk.RedisClient, keys, args...).Int64Slice()
+	if err != nil {
+		hk.observe(ctx, LockOpAcquire, startTime, false, false, err)
+		return false, 0, mooonerror.Wrap(mooonerror.ErrCodeRedis, err)
+	}
+	acquired := res[0] == 1 || res[0] == 2
+	hk.observe(ctx, LockOpAcquire, startTime, acquired, !acquired, nil)
+
+	switch res[0] {
+	case 1, 2:
+		return true, res[1], nil
+	default:
+		return false, 0, nil
+	}
+}
+
 // RenewKey 用于续期，如果 key 和 value 还未过期，则续期
 // 返回值：
 // 1. bool: 续期是否成功
@@ -116,15 +189,17 @@ func RentKey(ctx context.Context, hk *HoldKey) (bool, error) {
 // 1. 续期成功返回 true, nil；续期失败返回 false, nil
 // 2. 如果续期时发生错误，则返回 false, err
 func RenewKey(ctx context.Context, hk *HoldKey) (bool, error) {
-	keys := []string{hk.Key}
+	keys := []string{hk.Key, acquiredKeyOf(hk.Key)}
 	args := []interface{}{
 		hk.Value,
 		hk.Expiration.Milliseconds(),
 	}
 
+	startTime := time.Now()
 	res, err := renewScript.Run(ctx, hk.RedisClient, keys, args...).Int()
+	hk.observe(ctx, LockOpRenew, startTime, res == 1, false, err)
 	if err != nil {
-		return false, err // 网络错误或脚本执行错误
+		return false, mooonerror.Wrap(mooonerror.ErrCodeRedis, err) // 网络错误或脚本执行错误
 	}
 
 	return res == 1, nil
@@ -138,13 +213,30 @@ func RenewKey(ctx context.Context, hk *HoldKey) (bool, error) {
 // 1. 仅当 key 存在且 value 匹配时才删除锁，避免误删其他客户端的锁
 // 2. key 不存在时视为已释放，返回 true
 func ReleaseKey(ctx context.Context, hk *HoldKey) (bool, error) {
-	keys := []string{hk.Key}
+	keys := []string{hk.Key, acquiredKeyOf(hk.Key)}
 	args := []interface{}{hk.Value}
 
+	startTime := time.Now()
 	res, err := releaseScript.Run(ctx, hk.RedisClient, keys, args...).Int()
+	hk.observe(ctx, LockOpRelease, startTime, res >= 1, false, err)
 	if err != nil {
-		return false, err // 网络或脚本错误
+		return false, mooonerror.Wrap(mooonerror.ErrCodeRedis, err) // 网络或脚本错误
 	}
 
 	return res >= 1, nil // res=1 或 res=0 但 key 不存在均视为成功
-}
\ No newline at end of file
+}
+
+// observe 将操作通知给观测钩子
+func (hk *HoldKey) observe(ctx context.Context, op string, startTime time.Time, success, contended bool, err error) {
+	observeLock(ctx, hk.Observer, &LockEvent{
+		Lock:      "HoldKey",
+		Op:        op,
+		Keys:      []string{hk.Key},
+		Holder:    hk.Value,
+		StartTime: startTime,
+		Success:   success,
+		Contended: contended,
+		Attempts:  1,
+		Err:       err,
+	})
+}
@@ -230,3 +230,50 @@ func TestErrorHandling(t *testing.T) {
 	assert.False(t, ok)
 	assert.Error(t, err)
 }
+
+// TestRentKeyWithFence fencing token 单调递增测试
+// go test -v -run="TestRentKeyWithFence"
+func TestRentKeyWithFence(t *testing.T) {
+	rdb := createRedisClient()
+	defer rdb.Close()
+
+	hk := &HoldKey{
+		RedisClient: rdb,
+		Key:         "test_fence_key",
+		Value:       "test_value",
+		Expiration:  time.Minute,
+	}
+	rdb.Del(ctx, hk.Key)
+
+	// 用例1：首次获取得到 token
+	ok, fence1, err := RentKeyWithFence(ctx, hk)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Greater(t, fence1, int64(0))
+
+	// 用例2：同一持有者重复获取，token 不变
+	ok, fence2, err := RentKeyWithFence(ctx, hk)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, fence1, fence2)
+
+	// 用例3：其他持有者获取失败
+	other := *hk
+	other.Value = "different_value"
+	ok, fence3, err := RentKeyWithFence(ctx, &other)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, int64(0), fence3)
+
+	// 用例4：释放后其他持有者获取，token 变大
+	ReleaseKey(ctx, hk)
+	ok, fence4, err := RentKeyWithFence(ctx, &other)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Greater(t, fence4, fence1)
+
+	current, err := GetFence(ctx, rdb, hk.Key)
+	assert.NoError(t, err)
+	assert.Equal(t, fence4, current)
+	ReleaseKey(ctx, &other)
+}
@@ -0,0 +1,218 @@
+// Package mooonredis
+// Wrote by yijian on 2026/10/18
+package mooonredis
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"time"
+
+	"github.com/eyjian/gomooon/mooonerror"
+	"github.com/eyjian/gomooon/mooonutils"
+	"github.com/go-redis/redis/v8"
+)
+
+// 幂等记录的状态
+const (
+	IdempotencyAcquired   = "acquired"    // 首次请求，调用者应执行请求并调用 Complete 或 Abort
+	IdempotencyInProgress = "in_progress" // 相同 key 的请求正在执行中
+	IdempotencyDone       = "done"        // 相同 key 的请求已执行完成，返回缓存的结果
+)
+
+// ErrIdempotencyTokenMismatch 执行超时（超过 inProgressTTL）后记录已被其他请求接管
+var ErrIdempotencyTokenMismatch = mooonerror.NewError(mooonerror.ErrCodeRedisIdempotency, "idempotency record has been taken over by another request")
+
+// 幂等记录以 hash 存储：state 为状态，token 为执行者标识，result 为执行结果
+var idempotencyBeginScript = redis.NewScript(`
+local key = KEYS[1]
+local token = ARGV[1]
+local ttl_ms = tonumber(ARGV[2])
+
+if redis.call("EXISTS", key) == 1 then
+	local data = redis.call("HMGET", key, "state", "result")
+	return {data[1] or "", data[2] or ""}
+end
+
+redis.call("HSET", key, "state", "in_progress", "token", token)
+redis.call("PEXPIRE", key, ttl_ms)
+return {"acquired", ""}
+`)
+
+var idempotencyCompleteScript = redis.NewScript(`
+local key = KEYS[1]
+local token = ARGV[1]
+local result = ARGV[2]
+local ttl_ms = tonumber(ARGV[3])
+
+if redis.call("HGET", key, "token") ~= token then
+	return 0
+end
+redis.call("HSET", key, "state", "done", "result", result)
+redis.call("HDEL", key, "token")
+redis.call("PEXPIRE", key, ttl_ms)
+return 1
+`)
+
+var idempotencyAbortScript = redis.NewScript(`
+if redis.call("HGET", KEYS[1], "token") ~= ARGV[1] then
+	return 0
+end
+redis.call("DEL", KEYS[1])
+return 1
+`)
+
+// IdempotencyStore 幂等记录存储
+// 典型应用场景：微信支付申请账单/回单、腾讯云身份核验等付费或有副作用的调用，超时重试时避免重复扣费
+// 使用示例：
+//
+//	store := NewIdempotencyStore(rdb, "idem:", time.Minute, 24*time.Hour)
+//	resp, err := IdempotentDo(ctx, store, "verify:"+idcard, func(ctx context.Context) (*VerifyResult, error) {
+//		return verify(ctx, idcard, name)
+//	})
+type IdempotencyStore struct {
+	client        redis.UniversalClient
+	prefix        string           // key 的前缀
+	inProgressTTL time.Duration    // 执行中记录的有效期，应大于请求的最大耗时，执行者崩溃后超过该时间其他请求可接管
+	resultTTL     time.Duration    // 执行结果的缓存时长
+	abortIf       func(error) bool // 判断 fn 返回的错误是否表示 fn 确定没有执行
+}
+
+// IdempotencyOption IdempotencyStore 的可选项
+type IdempotencyOption func(*IdempotencyStore)
+
+// WithAbortIf 设置 IdempotentDo 中 fn 出错时的判断函数，返回 true 表示 fn 确定没有执行（如参数校验失败），删除记录以便立即重试
+// 返回 false 的错误（如超时、网络错误）无法确定是否已执行，记录保持执行中，inProgressTTL 到期前相同 key 的请求不会再执行 fn
+// 默认只有 ErrCodeInvalidParam 错误返回 true
+func WithAbortIf(abortIf func(err error) bool) IdempotencyOption {
+	return func(s *IdempotencyStore) {
+		s.abortIf = abortIf
+	}
+}
+
+// NewIdempotencyStore 生成幂等记录存储
+func NewIdempotencyStore(client redis.UniversalClient, prefix string, inProgressTTL, resultTTL time.Duration, opts ...IdempotencyOption) *IdempotencyStore {
+	s := &IdempotencyStore{
+		client:        client,
+		prefix:        prefix,
+		inProgressTTL: inProgressTTL,
+		resultTTL:     resultTTL,
+		abortIf:       isNotExecutedError,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// isNotExecutedError 默认的 abortIf，参数错误通常在发出请求前就已返回
+func isNotExecutedError(err error) bool {
+	return mooonerror.Code(err) == mooonerror.ErrCodeInvalidParam
+}
+
+// Begin 开始一个请求
+// 返回值：
+// 1. string: 状态，取值为 IdempotencyAcquired、IdempotencyInProgress 或 IdempotencyDone
+// 2. []byte: 状态为 IdempotencyDone 时为缓存的结果
+// 3. string: 状态为 IdempotencyAcquired 时为执行者标识，调用 Complete 或 Abort 时传入
+// 4. error: 错误信息
+func (s *IdempotencyStore) Begin(ctx context.Context, key string) (string, []byte, string, error) {
+	token := mooonutils.GetNonceStr(32)
+	res, err := idempotencyBeginScript.Run(ctx, s.client, []string{s.prefix + key},
+		token, s.inProgressTTL.Milliseconds()).StringSlice()
+	if err != nil {
+		return "", nil, "", mooonerror.Wrap(mooonerror.ErrCodeRedis, err)
+	}
+
+	switch res[0] {
+	case IdempotencyAcquired:
+		return IdempotencyAcquired, nil, token, nil
+	case IdempotencyDone:
+		return IdempotencyDone, []byte(res[1]), "", nil
+	default:
+		return IdempotencyInProgress, nil, "", nil
+	}
+}
+
+// Complete 记录执行结果，之后相同 key 的请求直接返回该结果
+// 执行超过 inProgressTTL 被其他请求接管时返回 ErrIdempotencyTokenMismatch
+func (s *IdempotencyStore) Complete(ctx context.Context, key, token string, result []byte) error {
+	res, err := idempotencyCompleteScript.Run(ctx, s.client, []string{s.prefix + key},
+		token, result, s.resultTTL.Milliseconds()).Int()
+	if err != nil {
+		return mooonerror.Wrap(mooonerror.ErrCodeRedis, err)
+	}
+	if res != 1 {
+		return ErrIdempotencyTokenMismatch
+	}
+	return nil
+}
+
+// Abort 放弃执行（如请求失败），删除记录以便后续重试
+func (s *IdempotencyStore) Abort(ctx context.Context, key, token string) error {
+	res, err := idempotencyAbortScript.Run(ctx, s.client, []string{s.prefix + key}, token).Int()
+	if err != nil {
+		return mooonerror.Wrap(mooonerror.ErrCodeRedis, err)
+	}
+	if res != 1 {
+		return ErrIdempotencyTokenMismatch
+	}
+	return nil
+}
+
+// IdempotentDo 幂等地执行 fn
+// 1. 首次请求执行 fn，成功时以 JSON 格式缓存结果；失败且 abortIf（参见 WithAbortIf）确认 fn 没有执行时删除记录以便重试，
+// 否则记录保持执行中，直到 inProgressTTL 到期，避免超时等情况下重复执行付费或有副作用的调用
+// 2. 相同 key 的请求正在执行时，阻塞等待其完成，直到 ctx 结束
+// 3. 相同 key 的请求已完成时，直接返回缓存的结果，不再执行 fn
+func IdempotentDo[T any](ctx context.Context, s *IdempotencyStore, key string, fn func(ctx context.Context) (T, error)) (T, error) {
+	var zero T
+
+	// 初始重试间隔
+	retryInterval := 50 * time.Millisecond
+	// 最大重试间隔
+	maxRetryInterval := time.Second
+
+	for {
+		state, result, token, err := s.Begin(ctx, key)
+		if err != nil {
+			return zero, err
+		}
+
+		switch state {
+		case IdempotencyDone:
+			var v T
+			if err := json.Unmarshal(result, &v); err != nil {
+				return zero, mooonerror.Errorf(mooonerror.ErrCodeRedisIdempotencyCodec, "unmarshal idempotency result of %s error: %w", key, err)
+			}
+			return v, nil
+
+		case IdempotencyAcquired:
+			v, err := fn(ctx)
+			if err != nil {
+				if s.abortIf != nil && s.abortIf(err) {
+					_ = s.Abort(context.Background(), key, token)
+				}
+				return zero, err
+			}
+			data, err := json.Marshal(v)
+			if err != nil {
+				// fn 已执行，不删除记录
+				return zero, mooonerror.Errorf(mooonerror.ErrCodeRedisIdempotencyCodec, "marshal idempotency result of %s error: %w", key, err)
+			}
+			// fn 已执行成功，即使记录结果失败也返回结果
+			_ = s.Complete(context.Background(), key, token, data)
+			return v, nil
+		}
+
+		// 执行中，等待后再次检查
+		timer := time.NewTimer(retryInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return zero, ctx.Err()
+		case <-timer.C:
+			retryInterval = time.Duration(math.Min(float64(retryInterval*2), float64(maxRetryInterval)))
+		}
+	}
+}
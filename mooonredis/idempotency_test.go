@@ -0,0 +1,105 @@
+// Package mooonredis
+// Wrote by yijian on 2026/10/18
+package mooonredis
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/eyjian/gomooon/mooonerror"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestIdempotentDo 测试幂等执行
+// go test -v -run="TestIdempotentDo"
+func TestIdempotentDo(t *testing.T) {
+	ctx := context.Background()
+	client := getTestRedisClient(t)
+	defer client.Close()
+	key := "test-idempotent-do"
+	store := NewIdempotencyStore(client, "idem:", 10*time.Second, time.Minute)
+	client.Del(ctx, "idem:"+key)
+
+	type result struct {
+		BillNo string `json:"bill_no"`
+	}
+
+	// 并发的重复请求只执行一次，且都得到相同结果
+	var calls int32
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r, err := IdempotentDo(ctx, store, key, func(ctx context.Context) (*result, error) {
+				atomic.AddInt32(&calls, 1)
+				time.Sleep(200 * time.Millisecond)
+				return &result{BillNo: "bill-1"}, nil
+			})
+			assert.NoError(t, err)
+			assert.Equal(t, "bill-1", r.BillNo)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	// 确定没有执行的失败可立即重试
+	failedKey := key + "-failed"
+	client.Del(ctx, "idem:"+failedKey)
+	_, err := IdempotentDo(ctx, store, failedKey, func(ctx context.Context) (int, error) {
+		return 0, mooonerror.NewError(mooonerror.ErrCodeInvalidParam, "invalid idcard")
+	})
+	assert.Error(t, err)
+	n, err := IdempotentDo(ctx, store, failedKey, func(ctx context.Context) (int, error) {
+		return 2, nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	// 超时等无法确定是否已执行的失败，记录保持执行中，不再执行 fn
+	timeoutKey := key + "-timeout"
+	client.Del(ctx, "idem:"+timeoutKey)
+	_, err = IdempotentDo(ctx, store, timeoutKey, func(ctx context.Context) (int, error) {
+		return 0, errors.New("timeout")
+	})
+	assert.Error(t, err)
+	waitCtx, cancel := context.WithTimeout(ctx, 200*time.Millisecond)
+	defer cancel()
+	_, err = IdempotentDo(waitCtx, store, timeoutKey, func(ctx context.Context) (int, error) {
+		atomic.AddInt32(&calls, 1)
+		return 3, nil
+	})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+// TestIdempotencyStore 测试幂等记录的状态变化
+// go test -v -run="TestIdempotencyStore"
+func TestIdempotencyStore(t *testing.T) {
+	ctx := context.Background()
+	client := getTestRedisClient(t)
+	defer client.Close()
+	key := "test-idempotency-store"
+	store := NewIdempotencyStore(client, "idem:", 10*time.Second, time.Minute)
+	client.Del(ctx, "idem:"+key)
+
+	state, _, token, err := store.Begin(ctx, key)
+	assert.NoError(t, err)
+	assert.Equal(t, IdempotencyAcquired, state)
+
+	state, _, _, err = store.Begin(ctx, key)
+	assert.NoError(t, err)
+	assert.Equal(t, IdempotencyInProgress, state)
+
+	assert.ErrorIs(t, store.Complete(ctx, key, "other", []byte("x")), ErrIdempotencyTokenMismatch)
+	assert.NoError(t, store.Complete(ctx, key, token, []byte("ok")))
+
+	state, result, _, err := store.Begin(ctx, key)
+	assert.NoError(t, err)
+	assert.Equal(t, IdempotencyDone, state)
+	assert.Equal(t, []byte("ok"), result)
+}
@@ -0,0 +1,168 @@
+// Package mooonredis
+// Wrote by yijian on 2026/10/18
+package mooonredis
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/eyjian/gomooon/mooonerror"
+)
+
+// LeaderElector 基于 HoldKey 的自动续期选主
+// 典型应用场景：多个 POD 中只允许一个 POD 执行定时任务
+// 使用示例：
+//
+//	le := NewLeaderElector(&HoldKey{
+//		RedisClient: rdb,
+//		Key:         "my_cron_job",
+//		Value:       os.Getenv("POD_IP"),
+//		Expiration:  time.Minute,
+//	}, func(ctx context.Context) {
+//		// 成为 leader，ctx 在失去 leader 身份时立即被取消
+//	}, func() {
+//		// 失去 leader 身份
+//	})
+//	le.Run(ctx) // 阻塞直到 ctx 被取消，退出前会释放 key
+type LeaderElector struct {
+	hk            *HoldKey
+	onElected     func(ctx context.Context) // 成为 leader 时回调（在独立的协程中执行）
+	onDemoted     func()                    // 失去 leader 身份时回调
+	renewInterval time.Duration             // 续期间隔，默认为 Expiration/3
+
+	mu           sync.Mutex
+	isLeader     bool
+	leaderCtx    context.Context
+	leaderCancel context.CancelFunc
+	leaseExpire  time.Time // 最近一次续期成功后 key 的过期时间
+}
+
+// NewLeaderElector 生成选主实例
+// 参数 onElected 和 onDemoted 均可为 nil
+// hk.Value 应能唯一标识参选者（如 POD_IP），否则多个参选者会同时认为自己是 leader
+func NewLeaderElector(hk *HoldKey, onElected func(ctx context.Context), onDemoted func()) *LeaderElector {
+	return &LeaderElector{
+		hk:            hk,
+		onElected:     onElected,
+		onDemoted:     onDemoted,
+		renewInterval: hk.Expiration / 3,
+	}
+}
+
+// IsLeader 是否为 leader
+func (le *LeaderElector) IsLeader() bool {
+	le.mu.Lock()
+	defer le.mu.Unlock()
+	return le.isLeader
+}
+
+// LeaderContext 返回 leader 身份的 context，失去 leader 身份时立即被取消
+// 如果当前不是 leader，则返回 nil
+func (le *LeaderElector) LeaderContext() context.Context {
+	le.mu.Lock()
+	defer le.mu.Unlock()
+	return le.leaderCtx
+}
+
+// Run 运行选主循环（阻塞），直到 ctx 被取消
+// 每隔 Expiration/3 尝试抢占或续期 key，退出前如果为 leader 则释放 key
+// 返回值为 ctx.Err()，如果参数无效则返回相应错误
+func (le *LeaderElector) Run(ctx context.Context) error {
+	if le.hk == nil || le.hk.RedisClient == nil {
+		return mooonerror.NewError(mooonerror.ErrCodeInvalidParam, "leader elector: HoldKey or RedisClient is nil")
+	}
+	if le.renewInterval <= 0 {
+		return mooonerror.NewError(mooonerror.ErrCodeInvalidParam, "leader elector: Expiration must be positive")
+	}
+
+	ticker := time.NewTicker(le.renewInterval)
+	defer ticker.Stop()
+
+	for {
+		le.tick(ctx)
+
+		select {
+		case <-ctx.Done():
+			le.resign()
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// tick 执行一次抢占或续期
+func (le *LeaderElector) tick(ctx context.Context) {
+	if !le.IsLeader() {
+		ok, err := RentKey(ctx, le.hk)
+		if err == nil && ok {
+			le.elect(ctx)
+		}
+		return
+	}
+
+	ok, err := RenewKey(ctx, le.hk)
+	if err == nil && ok {
+		le.mu.Lock()
+		le.leaseExpire = time.Now().Add(le.hk.Expiration)
+		le.mu.Unlock()
+		return
+	}
+	if err == nil {
+		// key 已过期或被他人持有，明确失去 leader 身份
+		le.demote()
+		return
+	}
+
+	// 网络等错误时 key 可能仍然有效，但在下一次续期前 key 可能过期则主动放弃
+	le.mu.Lock()
+	expired := !time.Now().Add(le.renewInterval).Before(le.leaseExpire)
+	le.mu.Unlock()
+	if expired {
+		le.demote()
+	}
+}
+
+// elect 成为 leader
+func (le *LeaderElector) elect(ctx context.Context) {
+	le.mu.Lock()
+	le.isLeader = true
+	le.leaseExpire = time.Now().Add(le.hk.Expiration)
+	le.leaderCtx, le.leaderCancel = context.WithCancel(ctx)
+	leaderCtx := le.leaderCtx
+	le.mu.Unlock()
+
+	if le.onElected != nil {
+		go le.onElected(leaderCtx)
+	}
+}
+
+// demote 失去 leader 身份
+func (le *LeaderElector) demote() {
+	le.mu.Lock()
+	if !le.isLeader {
+		le.mu.Unlock()
+		return
+	}
+	le.isLeader = false
+	le.leaderCancel()
+	le.leaderCtx, le.leaderCancel = nil, nil
+	le.mu.Unlock()
+
+	if le.onDemoted != nil {
+		le.onDemoted()
+	}
+}
+
+// resign 主动放弃 leader 身份并释放 key
+func (le *LeaderElector) resign() {
+	if !le.IsLeader() {
+		return
+	}
+	le.demote()
+
+	// 原 ctx 已被取消，使用新的 ctx 释放 key
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	_, _ = ReleaseKey(ctx, le.hk)
+}
@@ -0,0 +1,116 @@
+// Package mooonredis
+// Wrote by yijian on 2026/10/18
+package mooonredis
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestLeaderElector_Basic 单个参选者成为 leader，退出时释放 key
+// go test -v -run="TestLeaderElector_Basic"
+func TestLeaderElector_Basic(t *testing.T) {
+	rdb := createRedisClient()
+	defer rdb.Close()
+
+	hk := &HoldKey{
+		RedisClient: rdb,
+		Key:         "test_leader_key",
+		Value:       "pod-1",
+		Expiration:  3 * time.Second,
+	}
+	rdb.Del(ctx, hk.Key)
+
+	var elected, demoted int32
+	le := NewLeaderElector(hk, func(leaderCtx context.Context) {
+		atomic.AddInt32(&elected, 1)
+		<-leaderCtx.Done()
+	}, func() {
+		atomic.AddInt32(&demoted, 1)
+	})
+
+	runCtx, cancel := context.WithCancel(ctx)
+	done := make(chan error)
+	go func() { done <- le.Run(runCtx) }()
+
+	time.Sleep(500 * time.Millisecond)
+	assert.True(t, le.IsLeader())
+	assert.NotNil(t, le.LeaderContext())
+	assert.Equal(t, int32(1), atomic.LoadInt32(&elected))
+
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+	assert.False(t, le.IsLeader())
+	assert.Equal(t, int32(1), atomic.LoadInt32(&demoted))
+	assert.Equal(t, int64(0), rdb.Exists(ctx, hk.Key).Val())
+}
+
+// TestLeaderElector_Failover 两个参选者，leader 退出后另一个接管
+// go test -v -run="TestLeaderElector_Failover"
+func TestLeaderElector_Failover(t *testing.T) {
+	rdb := createRedisClient()
+	defer rdb.Close()
+
+	key := "test_leader_failover_key"
+	rdb.Del(ctx, key)
+
+	le1 := NewLeaderElector(&HoldKey{RedisClient: rdb, Key: key, Value: "pod-1", Expiration: 3 * time.Second}, nil, nil)
+	le2 := NewLeaderElector(&HoldKey{RedisClient: rdb, Key: key, Value: "pod-2", Expiration: 3 * time.Second}, nil, nil)
+
+	ctx1, cancel1 := context.WithCancel(ctx)
+	ctx2, cancel2 := context.WithCancel(ctx)
+	defer cancel2()
+	go le1.Run(ctx1)
+	time.Sleep(200 * time.Millisecond)
+	go le2.Run(ctx2)
+	time.Sleep(200 * time.Millisecond)
+
+	assert.True(t, le1.IsLeader())
+	assert.False(t, le2.IsLeader())
+
+	// le1 优雅退出释放 key，le2 在下一个周期接管
+	cancel1()
+	time.Sleep(1500 * time.Millisecond)
+	assert.False(t, le1.IsLeader())
+	assert.True(t, le2.IsLeader())
+}
+
+// TestLeaderElector_Lost key 被他人抢占时，leader ctx 立即被取消
+// go test -v -run="TestLeaderElector_Lost"
+func TestLeaderElector_Lost(t *testing.T) {
+	rdb := createRedisClient()
+	defer rdb.Close()
+
+	hk := &HoldKey{
+		RedisClient: rdb,
+		Key:         "test_leader_lost_key",
+		Value:       "pod-1",
+		Expiration:  3 * time.Second,
+	}
+	rdb.Del(ctx, hk.Key)
+
+	le := NewLeaderElector(hk, nil, nil)
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go le.Run(runCtx)
+
+	time.Sleep(200 * time.Millisecond)
+	leaderCtx := le.LeaderContext()
+	if !assert.NotNil(t, leaderCtx) {
+		return
+	}
+
+	// 模拟 key 被其它 POD 持有
+	rdb.Set(ctx, hk.Key, "pod-2", time.Minute)
+	select {
+	case <-leaderCtx.Done():
+	case <-time.After(2 * time.Second):
+		t.Error("leader ctx should be cancelled after leadership lost")
+	}
+	assert.False(t, le.IsLeader())
+	rdb.Del(ctx, hk.Key)
+}
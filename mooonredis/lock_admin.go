@@ -0,0 +1,251 @@
+// Package mooonredis
+// Wrote by yijian on 2026/10/18
+package mooonredis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/eyjian/gomooon/mooonerror"
+	"github.com/go-redis/redis/v8"
+)
+
+// 锁的运维接口：列出锁、查看持有者和剩余有效时间、强制释放并留下审计记录
+// 参见命令行工具 cmd/mooonlock
+
+// LockAuditKey 强制释放的审计记录所在的 list，最新的记录在最前
+var LockAuditKey = "mooonredis:lock_audit"
+
+// LockAuditMaxLen 审计记录最多保留的条数
+var LockAuditMaxLen int64 = 1000
+
+// ErrLockChanged 强制释放前锁已被释放或被其他持有者获取
+var ErrLockChanged = mooonerror.NewError(mooonerror.ErrCodeRedisLockChanged, "lock changed before force release")
+
+// 锁的关联 key 的后缀，列出锁时跳过
+var lockRelatedSuffixes = []string{":fence", ":acquired", ":queue", ":waiters", ":queue_seq", ":lease"}
+
+var forceReleaseScript = redis.NewScript(`
+local key = KEYS[1]
+local acquired_key = KEYS[2]
+local holder = ARGV[1]
+
+local t = redis.call("TYPE", key).ok
+if t == "none" then
+	return 0 -- 已释放
+end
+if t == "string" and redis.call("GET", key) ~= holder then
+	return 0 -- 已被其他持有者获取
+end
+if t == "hash" and redis.call("HEXISTS", key, holder) == 0 then
+	return 0 -- 已被其他持有者获取
+end
+redis.call("DEL", key, acquired_key)
+return 1
+`)
+
+// LockInfo 锁的信息
+type LockInfo struct {
+	Key        string        `json:"key"`
+	Type       string        `json:"type"`                  // Redis 类型：string 为 HoldKey、SimDistLock，hash 为 ReentrantLock、RWLock
+	Holders    []string      `json:"holders"`               // 持有者标识，读锁时可能有多个
+	TTL        time.Duration `json:"ttl"`                   // 剩余有效时间，-1 表示永不过期
+	AcquiredAt time.Time     `json:"acquired_at,omitempty"` // 获取时间，只有 HoldKey 和 SimDistLock 记录，未记录时为零值
+}
+
+// LockAudit 强制释放的审计记录
+type LockAudit struct {
+	Lock       *LockInfo `json:"lock"`        // 释放前的锁信息
+	Operator   string    `json:"operator"`    // 操作人
+	Reason     string    `json:"reason"`      // 释放原因
+	ReleasedAt time.Time `json:"released_at"` // 释放时间
+}
+
+// acquiredKeyOf 取得记录 key 获取时间的 key
+func acquiredKeyOf(key string) string {
+	return relatedKeyOf(key, "acquired")
+}
+
+// ListLocks 列出前缀为 prefix 的所有锁，集群模式下扫描所有 master 节点
+// 跳过审计记录（LockAuditKey）和锁的关联 key，类型不是 string 或 hash 的 key 也不视为锁
+// 说明：使用 SCAN 遍历，锁较多时较慢，不要在业务请求中调用
+func ListLocks(ctx context.Context, client redis.UniversalClient, prefix string) ([]*LockInfo, error) {
+	var keys []string
+	if cluster, ok := client.(*redis.ClusterClient); ok {
+		var mu sync.Mutex
+		err := cluster.ForEachMaster(ctx, func(ctx context.Context, node *redis.Client) error {
+			nodeKeys, err := scanLockKeys(ctx, node, prefix)
+			if err != nil {
+				return err
+			}
+			mu.Lock()
+			keys = append(keys, nodeKeys...)
+			mu.Unlock()
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		var err error
+		keys, err = scanLockKeys(ctx, client, prefix)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	locks := make([]*LockInfo, 0, len(keys))
+	for _, key := range keys {
+		info, err := GetLockInfo(ctx, client, key)
+		if err != nil {
+			return nil, err
+		}
+		if info != nil && (info.Type == "string" || info.Type == "hash") { // 扫描后已释放的忽略
+			locks = append(locks, info)
+		}
+	}
+	return locks, nil
+}
+
+// GetLockInfo 取得锁的信息，锁不存在时返回 nil, nil
+func GetLockInfo(ctx context.Context, client redis.UniversalClient, key string) (*LockInfo, error) {
+	typ, err := client.Type(ctx, key).Result()
+	if err != nil {
+		return nil, mooonerror.Wrap(mooonerror.ErrCodeRedis, err)
+	}
+
+	info := &LockInfo{Key: key, Type: typ}
+	switch typ {
+	case "none":
+		return nil, nil
+	case "string":
+		holder, err := client.Get(ctx, key).Result()
+		if errors.Is(err, redis.Nil) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, mooonerror.Wrap(mooonerror.ErrCodeRedis, err)
+		}
+		info.Holders = []string{holder}
+	case "hash":
+		fields, err := client.HKeys(ctx, key).Result()
+		if err != nil {
+			return nil, mooonerror.Wrap(mooonerror.ErrCodeRedis, err)
+		}
+		for _, field := range fields {
+			if field != rwLockModeField { // RWLock 的模式字段
+				info.Holders = append(info.Holders, field)
+			}
+		}
+	}
+
+	ttl, err := client.PTTL(ctx, key).Result()
+	if err != nil {
+		return nil, mooonerror.Wrap(mooonerror.ErrCodeRedis, err)
+	}
+	if ttl == -2 { // 刚刚过期
+		return nil, nil
+	}
+	if ttl < 0 {
+		ttl = -1
+	}
+	info.TTL = ttl
+
+	acquiredAt, err := client.Get(ctx, acquiredKeyOf(key)).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, mooonerror.Wrap(mooonerror.ErrCodeRedis, err)
+	}
+	if ms, err := strconv.ParseInt(acquiredAt, 10, 64); err == nil {
+		info.AcquiredAt = time.UnixMilli(ms)
+	}
+	return info, nil
+}
+
+// ForceRelease 强制释放锁，并在 LockAuditKey 中留下审计记录
+// 返回释放前的锁信息；锁不存在时返回 nil, nil；查看后锁已被其他持有者获取时返回 ErrLockChanged
+// 说明：
+// 1. 不会重置 fencing token，原持有者之后的写入仍会因 token 较小而被拒绝
+// 2. 原持有者如果仍在运行，将在续期时发现锁已丢失
+// 3. 只释放 client 上的这一个 key：多 key 的 SimDistLock 需对每个 key 调用，Redlock 模式需对每个实例调用，
+// 否则剩余 key 仍满足 quorum 时锁并未被释放
+func ForceRelease(ctx context.Context, client redis.UniversalClient, key, operator, reason string) (*LockInfo, error) {
+	info, err := GetLockInfo(ctx, client, key)
+	if err != nil || info == nil {
+		return nil, err
+	}
+
+	holder := ""
+	if len(info.Holders) > 0 {
+		holder = info.Holders[0]
+	}
+	res, err := forceReleaseScript.Run(ctx, client, []string{key, acquiredKeyOf(key)}, holder).Int()
+	if err != nil {
+		return nil, mooonerror.Wrap(mooonerror.ErrCodeRedis, err)
+	}
+	if res != 1 {
+		return nil, ErrLockChanged
+	}
+
+	// 审计 key 与锁 key 在集群模式下可能不在同一个 slot，不能在同一个脚本中写入
+	audit, err := json.Marshal(&LockAudit{
+		Lock:       info,
+		Operator:   operator,
+		Reason:     reason,
+		ReleasedAt: time.Now(),
+	})
+	if err != nil {
+		return info, mooonerror.Wrap(mooonerror.ErrCodeUnknown, err)
+	}
+	pipe := client.TxPipeline()
+	pipe.LPush(ctx, LockAuditKey, audit)
+	pipe.LTrim(ctx, LockAuditKey, 0, LockAuditMaxLen-1)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return info, mooonerror.Wrap(mooonerror.ErrCodeRedis, err)
+	}
+	return info, nil
+}
+
+// ListLockAudits 列出最近 n 条强制释放的审计记录，最新的在最前
+func ListLockAudits(ctx context.Context, client redis.UniversalClient, n int64) ([]*LockAudit, error) {
+	values, err := client.LRange(ctx, LockAuditKey, 0, n-1).Result()
+	if err != nil {
+		return nil, mooonerror.Wrap(mooonerror.ErrCodeRedis, err)
+	}
+
+	audits := make([]*LockAudit, 0, len(values))
+	for _, value := range values {
+		var audit LockAudit
+		if err := json.Unmarshal([]byte(value), &audit); err != nil {
+			return nil, mooonerror.Wrap(mooonerror.ErrCodeUnknown, err)
+		}
+		audits = append(audits, &audit)
+	}
+	return audits, nil
+}
+
+// scanLockKeys 扫描前缀为 prefix 的 key，跳过审计记录和锁的关联 key
+func scanLockKeys(ctx context.Context, client redis.Cmdable, prefix string) ([]string, error) {
+	var keys []string
+	iter := client.Scan(ctx, 0, prefix+"*", 100).Iterator()
+	for iter.Next(ctx) {
+		if iter.Val() != LockAuditKey && !isLockRelatedKey(iter.Val()) {
+			keys = append(keys, iter.Val())
+		}
+	}
+	return keys, mooonerror.Wrap(mooonerror.ErrCodeRedis, iter.Err())
+}
+
+// isLockRelatedKey 是否为锁的关联 key（如 fencing token 计数器）
+func isLockRelatedKey(key string) bool {
+	for _, suffix := range lockRelatedSuffixes {
+		if strings.HasSuffix(key, suffix) {
+			return true
+		}
+	}
+	return false
+}
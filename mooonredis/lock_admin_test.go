@@ -0,0 +1,70 @@
+// Package mooonredis
+// Wrote by yijian on 2026/10/18
+package mooonredis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestIsLockRelatedKey 测试关联 key 的识别
+// go test -v -run="TestIsLockRelatedKey"
+func TestIsLockRelatedKey(t *testing.T) {
+	assert.True(t, isLockRelatedKey(fenceKeyOf("job")))
+	assert.True(t, isLockRelatedKey(acquiredKeyOf("{job}:lock")))
+	assert.True(t, isLockRelatedKey(relatedKeyOf("cache:1", "lease")))
+	assert.False(t, isLockRelatedKey("job"))
+	assert.False(t, isLockRelatedKey("{job}:lock"))
+}
+
+// TestForceRelease 测试查看和强制释放锁
+// go test -v -run="TestForceRelease"
+func TestForceRelease(t *testing.T) {
+	ctx := context.Background()
+	client := getTestRedisClient(t)
+	defer client.Close()
+	key := "test-admin:hold-key"
+	client.Del(ctx, key, acquiredKeyOf(key))
+
+	hk := &HoldKey{RedisClient: client, Key: key, Value: "10.0.0.1", Expiration: time.Minute}
+	startTime := time.Now()
+	ok, err := RentKey(ctx, hk)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	locks, err := ListLocks(ctx, client, "test-admin:")
+	assert.NoError(t, err)
+	if assert.Len(t, locks, 1) {
+		assert.Equal(t, key, locks[0].Key)
+		assert.Equal(t, []string{"10.0.0.1"}, locks[0].Holders)
+		assert.Greater(t, locks[0].TTL, 50*time.Second)
+		assert.WithinDuration(t, startTime, locks[0].AcquiredAt, time.Second)
+	}
+
+	info, err := ForceRelease(ctx, client, key, "tester", "pod crashed")
+	assert.NoError(t, err)
+	assert.Equal(t, key, info.Key)
+	info, err = GetLockInfo(ctx, client, key)
+	assert.NoError(t, err)
+	assert.Nil(t, info)
+
+	audits, err := ListLockAudits(ctx, client, 1)
+	assert.NoError(t, err)
+	if assert.Len(t, audits, 1) {
+		assert.Equal(t, "tester", audits[0].Operator)
+		assert.Equal(t, key, audits[0].Lock.Key)
+	}
+
+	// 审计记录不是锁
+	locks, err = ListLocks(ctx, client, LockAuditKey)
+	assert.NoError(t, err)
+	assert.Empty(t, locks)
+
+	// 原持有者续期失败
+	ok, err = RenewKey(ctx, hk)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
@@ -0,0 +1,45 @@
+// Package mooonredis
+// Wrote by yijian on 2026/10/18
+package mooonredis
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+)
+
+// retryLock 在 timeout 内反复调用 try 直到加锁成功（指数退避）
+// 成功返回 true, nil；超时返回 false, nil；出错或 ctx 被取消返回 false, err
+func retryLock(ctx context.Context, timeout time.Duration, try func() (bool, error)) (bool, error) {
+	timeoutCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// 初始重试间隔
+	retryInterval := 50 * time.Millisecond
+	// 最大重试间隔
+	maxRetryInterval := 500 * time.Millisecond
+
+	for {
+		acquired, err := try()
+		if err != nil {
+			return false, err
+		}
+		if acquired {
+			return true, nil
+		}
+
+		timer := time.NewTimer(retryInterval)
+		select {
+		case <-timeoutCtx.Done():
+			timer.Stop()
+			if errors.Is(timeoutCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil {
+				return false, nil // 超时
+			}
+			return false, timeoutCtx.Err()
+		case <-timer.C:
+			// 指数退避：每次重试间隔翻倍，但不超过最大值
+			retryInterval = time.Duration(math.Min(float64(retryInterval*2), float64(maxRetryInterval)))
+		}
+	}
+}
@@ -0,0 +1,93 @@
+// Package mooonredis
+// Wrote by yijian on 2026/10/18
+package mooonredis
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// 锁操作类型
+const (
+	LockOpAcquire = "acquire" // 加锁：TryLock、TimedLock、RentKey 等
+	LockOpRelease = "release" // 释放：Unlock、ReleaseKey 等
+	LockOpRenew   = "renew"   // 续期：Renew、RenewKey、看门狗续期等
+)
+
+// LockEvent 一次锁操作的观测数据
+type LockEvent struct {
+	Lock      string        // 锁的类型，如 "SimDistLock"、"HoldKey"
+	Op        string        // 操作类型，取值为 LockOpAcquire、LockOpRelease 或 LockOpRenew
+	Keys      []string      // 锁的 key
+	Holder    string        // 持有者标识（锁的 value）
+	StartTime time.Time     // 操作开始时间
+	Latency   time.Duration // 操作耗时，加锁时即为等锁时长
+	Success   bool          // 操作是否成功
+	Contended bool          // 是否发生争用：加锁时因锁被他人持有而失败或等待过
+	Attempts  int           // 尝试次数，TimedLock 时可能大于 1
+	Err       error         // 错误信息
+}
+
+// Observer 锁操作的观测钩子，用于接入指标和链路追踪
+// ObserveLock 在锁操作的调用方协程中同步调用，实现应尽快返回
+type Observer interface {
+	ObserveLock(ctx context.Context, event *LockEvent)
+}
+
+// multiObserver 依次通知多个观测钩子
+type multiObserver []Observer
+
+func (m multiObserver) ObserveLock(ctx context.Context, event *LockEvent) {
+	for _, observer := range m {
+		observer.ObserveLock(ctx, event)
+	}
+}
+
+// NewMultiObserver 组合多个观测钩子，如同时使用 OTELObserver 和 OTELMetricObserver，nil 被忽略
+func NewMultiObserver(observers ...Observer) Observer {
+	m := make(multiObserver, 0, len(observers))
+	for _, observer := range observers {
+		if observer != nil {
+			m = append(m, observer)
+		}
+	}
+	return m
+}
+
+// observerHolder 使 atomic.Value 中存储的类型保持一致
+type observerHolder struct {
+	observer Observer
+}
+
+var defaultObserver atomic.Value
+
+// SetObserver 设置全局默认的观测钩子，未单独指定观测钩子的锁均使用它，为 nil 时关闭观测
+// 单独指定：SimDistLock 使用 WithObserver，ReentrantLock 使用 WithReentrantLockObserver，RWLock 使用 WithRWLockObserver，HoldKey 设置 Observer 字段
+func SetObserver(observer Observer) {
+	defaultObserver.Store(observerHolder{observer: observer})
+}
+
+// observeLock 将锁操作通知给观测钩子，observer 为 nil 时使用全局默认的观测钩子
+func observeLock(ctx context.Context, observer Observer, event *LockEvent) {
+	if observer == nil {
+		if holder, ok := defaultObserver.Load().(observerHolder); ok {
+			observer = holder.observer
+		}
+	}
+	if observer == nil {
+		return
+	}
+	if event.Latency == 0 {
+		event.Latency = time.Since(event.StartTime)
+	}
+	observer.ObserveLock(ctx, event)
+}
+
+// countAttempts 包装 try，每次调用时递增 attempts
+func countAttempts(attempts *int, try func() (bool, error)) func() (bool, error) {
+	return func() (bool, error) {
+		*attempts++
+		return try()
+	}
+}
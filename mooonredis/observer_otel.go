@@ -0,0 +1,63 @@
+// Package mooonredis
+// Wrote by yijian on 2026/10/18
+package mooonredis
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// OTELObserver 基于 OpenTelemetry 链路追踪的观测钩子
+// 1. tracer 不为 nil 时，每次锁操作生成一个 span（名如 "mooonredis.SimDistLock.acquire"），起止时间即操作的起止时间
+// 2. tracer 为 nil 时，只在 ctx 中当前的 span 上添加同名事件
+// 等锁时长、是否争用、续期失败等均作为属性记录（见 LockEvent），指标由 OTELMetricObserver 记录
+// 使用示例：
+//
+//	mooonredis.SetObserver(mooonredis.NewOTELObserver(otel.Tracer("mooonredis")))
+type OTELObserver struct {
+	tracer trace.Tracer
+}
+
+// NewOTELObserver 生成基于 OpenTelemetry 的观测钩子
+func NewOTELObserver(tracer trace.Tracer) *OTELObserver {
+	return &OTELObserver{
+		tracer: tracer,
+	}
+}
+
+// ObserveLock 实现 Observer 接口
+func (o *OTELObserver) ObserveLock(ctx context.Context, event *LockEvent) {
+	name := "mooonredis." + event.Lock + "." + event.Op
+	endTime := event.StartTime.Add(event.Latency)
+	attrs := []attribute.KeyValue{
+		attribute.String("lock.type", event.Lock),
+		attribute.String("lock.op", event.Op),
+		attribute.StringSlice("lock.keys", event.Keys),
+		attribute.String("lock.holder", event.Holder),
+		attribute.Bool("lock.success", event.Success),
+		attribute.Bool("lock.contended", event.Contended),
+		attribute.Int("lock.attempts", event.Attempts),
+		attribute.Int64("lock.latency_ms", event.Latency.Milliseconds()),
+	}
+	if event.Err != nil {
+		attrs = append(attrs, attribute.String("lock.error", event.Err.Error()))
+	}
+
+	if o.tracer == nil {
+		trace.SpanFromContext(ctx).AddEvent(name, trace.WithTimestamp(endTime), trace.WithAttributes(attrs...))
+		return
+	}
+
+	_, span := o.tracer.Start(ctx, name,
+		trace.WithTimestamp(event.StartTime),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...))
+	if event.Err != nil {
+		span.RecordError(event.Err, trace.WithTimestamp(endTime))
+		span.SetStatus(codes.Error, event.Err.Error())
+	}
+	span.End(trace.WithTimestamp(endTime))
+}
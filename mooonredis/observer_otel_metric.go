@@ -0,0 +1,71 @@
+// Package mooonredis
+// Wrote by yijian on 2026/10/18
+package mooonredis
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// OTELMetricObserver 基于 OpenTelemetry 指标的观测钩子，记录以下指标（属性为 lock.type、lock.op 和 lock.success）：
+// 1. mooonredis.lock.duration：锁操作耗时的直方图，单位为秒，lock.op 为 acquire 时即等锁时长
+// 2. mooonredis.lock.contention：加锁时发生争用（因锁被他人持有而失败或等待过）的次数
+// 3. mooonredis.lock.renew.failures：续期失败的次数，含看门狗续期失败
+// 同时需要链路追踪时，可通过 NewMultiObserver 与 OTELObserver 组合
+// 使用示例：
+//
+//	observer, err := mooonredis.NewOTELMetricObserver(otel.Meter("mooonredis"))
+//	if err != nil {
+//		return err
+//	}
+//	mooonredis.SetObserver(observer)
+type OTELMetricObserver struct {
+	duration      metric.Float64Histogram
+	contention    metric.Int64Counter
+	renewFailures metric.Int64Counter
+}
+
+// NewOTELMetricObserver 生成基于 OpenTelemetry 指标的观测钩子，创建指标失败时返回错误
+func NewOTELMetricObserver(meter metric.Meter) (*OTELMetricObserver, error) {
+	duration, err := meter.Float64Histogram("mooonredis.lock.duration",
+		metric.WithDescription("Duration of lock operations, including waiting time for acquire"),
+		metric.WithUnit("s"))
+	if err != nil {
+		return nil, err
+	}
+	contention, err := meter.Int64Counter("mooonredis.lock.contention",
+		metric.WithDescription("Number of contended lock acquisitions"),
+		metric.WithUnit("{acquire}"))
+	if err != nil {
+		return nil, err
+	}
+	renewFailures, err := meter.Int64Counter("mooonredis.lock.renew.failures",
+		metric.WithDescription("Number of failed lock renewals"),
+		metric.WithUnit("{renew}"))
+	if err != nil {
+		return nil, err
+	}
+	return &OTELMetricObserver{
+		duration:      duration,
+		contention:    contention,
+		renewFailures: renewFailures,
+	}, nil
+}
+
+// ObserveLock 实现 Observer 接口
+func (o *OTELMetricObserver) ObserveLock(ctx context.Context, event *LockEvent) {
+	attrs := metric.WithAttributes(
+		attribute.String("lock.type", event.Lock),
+		attribute.String("lock.op", event.Op),
+		attribute.Bool("lock.success", event.Success),
+	)
+	o.duration.Record(ctx, event.Latency.Seconds(), attrs)
+	if event.Op == LockOpAcquire && event.Contended {
+		o.contention.Add(ctx, 1, attrs)
+	}
+	if event.Op == LockOpRenew && !event.Success {
+		o.renewFailures.Add(ctx, 1, attrs)
+	}
+}
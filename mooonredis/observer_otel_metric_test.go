@@ -0,0 +1,66 @@
+// Package mooonredis
+// Wrote by yijian on 2026/10/18
+package mooonredis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/noop"
+)
+
+// recordMeter 记录各指标的值
+type recordMeter struct {
+	noop.Meter
+	values map[string]float64
+}
+
+type recordHistogram struct {
+	noop.Float64Histogram
+	name  string
+	meter *recordMeter
+}
+
+func (h *recordHistogram) Record(ctx context.Context, value float64, opts ...metric.RecordOption) {
+	h.meter.values[h.name] += value
+}
+
+type recordCounter struct {
+	noop.Int64Counter
+	name  string
+	meter *recordMeter
+}
+
+func (c *recordCounter) Add(ctx context.Context, value int64, opts ...metric.AddOption) {
+	c.meter.values[c.name] += float64(value)
+}
+
+func (m *recordMeter) Float64Histogram(name string, options ...metric.Float64HistogramOption) (metric.Float64Histogram, error) {
+	return &recordHistogram{name: name, meter: m}, nil
+}
+
+func (m *recordMeter) Int64Counter(name string, options ...metric.Int64CounterOption) (metric.Int64Counter, error) {
+	return &recordCounter{name: name, meter: m}, nil
+}
+
+// TestOTELMetricObserver 测试等锁时长、争用和续期失败的指标
+// go test -v -run="TestOTELMetricObserver"
+func TestOTELMetricObserver(t *testing.T) {
+	ctx := context.Background()
+	meter := &recordMeter{values: map[string]float64{}}
+	observer, err := NewOTELMetricObserver(meter)
+	assert.NoError(t, err)
+
+	multi := NewMultiObserver(nil, observer)
+	multi.ObserveLock(ctx, &LockEvent{Lock: "SimDistLock", Op: LockOpAcquire, Latency: 2 * time.Second, Success: true, Contended: true})
+	multi.ObserveLock(ctx, &LockEvent{Lock: "SimDistLock", Op: LockOpAcquire, Latency: time.Second, Success: true})
+	multi.ObserveLock(ctx, &LockEvent{Lock: "SimDistLock", Op: LockOpRenew, Latency: time.Second, Success: false})
+	multi.ObserveLock(ctx, &LockEvent{Lock: "SimDistLock", Op: LockOpRenew, Latency: time.Second, Success: true})
+
+	assert.Equal(t, 5.0, meter.values["mooonredis.lock.duration"])
+	assert.Equal(t, 1.0, meter.values["mooonredis.lock.contention"])
+	assert.Equal(t, 1.0, meter.values["mooonredis.lock.renew.failures"])
+}
@@ -0,0 +1,115 @@
+// Package mooonredis
+// Wrote by yijian on 2026/10/18
+package mooonredis
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+)
+
+// recordObserver 记录收到的锁操作
+type recordObserver struct {
+	mu     sync.Mutex
+	events []LockEvent
+}
+
+func (o *recordObserver) ObserveLock(ctx context.Context, event *LockEvent) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.events = append(o.events, *event)
+}
+
+// TestObserveLock 测试观测钩子的选择
+// go test -v -run="TestObserveLock"
+func TestObserveLock(t *testing.T) {
+	ctx := context.Background()
+	defaultRecorder := &recordObserver{}
+	SetObserver(defaultRecorder)
+	defer SetObserver(nil)
+
+	// 未单独指定时使用全局默认的观测钩子，且自动计算耗时
+	startTime := time.Now().Add(-time.Second)
+	observeLock(ctx, nil, &LockEvent{Lock: "HoldKey", Op: LockOpAcquire, StartTime: startTime})
+	assert.Len(t, defaultRecorder.events, 1)
+	assert.GreaterOrEqual(t, defaultRecorder.events[0].Latency, time.Second)
+
+	// 单独指定时不再通知全局默认的观测钩子
+	recorder := &recordObserver{}
+	observeLock(ctx, recorder, &LockEvent{Lock: "HoldKey", Op: LockOpRenew, StartTime: startTime})
+	assert.Len(t, recorder.events, 1)
+	assert.Len(t, defaultRecorder.events, 1)
+
+	// 关闭后不再通知
+	SetObserver(nil)
+	observeLock(ctx, nil, &LockEvent{Lock: "HoldKey", Op: LockOpRelease, StartTime: startTime})
+	assert.Len(t, defaultRecorder.events, 1)
+}
+
+// TestSimDistLockObserver 测试加锁争用和等锁时长的观测
+// go test -v -run="TestSimDistLockObserver"
+func TestSimDistLockObserver(t *testing.T) {
+	ctx := context.Background()
+	client := getTestRedisClient(t)
+	defer client.Close()
+	keys := []string{"test-observer-lock"}
+	client.Del(ctx, keys...)
+
+	recorder := &recordObserver{}
+	lock1 := NewSimDistLock(ctx, client, time.Minute, "", keys, WithObserver(recorder))
+	lock2 := NewSimDistLock(ctx, client, time.Minute, "", keys, WithObserver(recorder))
+
+	acquired, err := lock1.TryLock()
+	assert.NoError(t, err)
+	assert.True(t, acquired)
+	go func() {
+		time.Sleep(300 * time.Millisecond)
+		lock1.Unlock()
+	}()
+	acquired, err = lock2.TimedLock(5 * time.Second)
+	assert.NoError(t, err)
+	assert.True(t, acquired)
+	assert.NoError(t, lock2.Unlock())
+
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	var timed *LockEvent
+	for i := range recorder.events {
+		if recorder.events[i].Holder == lock2.value && recorder.events[i].Op == LockOpAcquire {
+			timed = &recorder.events[i]
+		}
+	}
+	if assert.NotNil(t, timed) {
+		assert.True(t, timed.Success)
+		assert.True(t, timed.Contended)
+		assert.Greater(t, timed.Attempts, 1)
+		assert.Greater(t, timed.Latency, 200*time.Millisecond)
+	}
+}
+
+// TestLockInstanceObserver 测试 ReentrantLock 和 RWLock 单独指定的观测钩子
+// go test -v -run="TestLockInstanceObserver"
+func TestLockInstanceObserver(t *testing.T) {
+	ctx := context.Background()
+	client := redis.NewClient(&redis.Options{Addr: "localhost:1"}) // Redis 不可用时加锁出错，但仍会通知观测钩子
+	defer client.Close()
+	defaultRecorder := &recordObserver{}
+	SetObserver(defaultRecorder)
+	defer SetObserver(nil)
+
+	recorder := &recordObserver{}
+	_, err := NewReentrantLock(ctx, client, time.Minute, "", "test-observer-lock", WithReentrantLockObserver(recorder)).TryLock()
+	assert.Error(t, err)
+	_, err = NewRWLock(ctx, client, time.Minute, "", "test-observer-lock", WithRWLockObserver(recorder)).TryRLock()
+	assert.Error(t, err)
+	if assert.Len(t, recorder.events, 2) {
+		assert.Equal(t, "ReentrantLock", recorder.events[0].Lock)
+		assert.Equal(t, rwLockRead, recorder.events[1].Lock)
+		assert.Error(t, recorder.events[1].Err)
+	}
+	assert.Empty(t, defaultRecorder.events)
+}
@@ -0,0 +1,181 @@
+// Package mooonredis
+// Wrote by yijian on 2026/10/18
+package mooonredis
+
+import (
+	"context"
+	"time"
+
+	"github.com/eyjian/gomooon/mooonerror"
+	"github.com/eyjian/gomooon/mooonutils"
+	"github.com/go-redis/redis/v8"
+)
+
+// 脚本统一使用 Redis 服务端时间，避免多个 POD 之间的时钟偏差
+// 返回 0 表示放行，大于 0 表示需等待的毫秒数
+
+// 滑动窗口：sorted set 的 member 为请求标识，score 为请求时间（微秒）
+var slidingWindowScript = redis.NewScript(`
+redis.replicate_commands()
+local key = KEYS[1]
+local window_us = tonumber(ARGV[1])
+local limit = tonumber(ARGV[2])
+local member = ARGV[3]
+
+local t = redis.call("TIME")
+local now_us = tonumber(t[1]) * 1000000 + tonumber(t[2])
+
+redis.call("ZREMRANGEBYSCORE", key, "-inf", now_us - window_us)
+if redis.call("ZCARD", key) < limit then
+	redis.call("ZADD", key, now_us, member)
+	redis.call("PEXPIRE", key, math.ceil(window_us / 1000))
+	return 0
+end
+
+local oldest = redis.call("ZRANGE", key, 0, 0, "WITHSCORES")
+local wait_ms = math.ceil((tonumber(oldest[2]) + window_us - now_us) / 1000)
+if wait_ms < 1 then
+	wait_ms = 1
+end
+return wait_ms
+`)
+
+// 令牌桶：hash 的 tokens 为剩余令牌数，ts 为上次更新时间（毫秒）
+var tokenBucketScript = redis.NewScript(`
+redis.replicate_commands()
+local key = KEYS[1]
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+
+local t = redis.call("TIME")
+local now_ms = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+
+local data = redis.call("HMGET", key, "tokens", "ts")
+local tokens = tonumber(data[1]) or burst
+local ts = tonumber(data[2]) or now_ms
+tokens = math.min(burst, tokens + math.max(0, now_ms - ts) * rate / 1000)
+
+local wait_ms = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+else
+	wait_ms = math.ceil((1 - tokens) * 1000 / rate)
+end
+
+redis.call("HSET", key, "tokens", tostring(tokens), "ts", now_ms)
+redis.call("PEXPIRE", key, math.ceil(burst * 1000 / rate) + 1000)
+return wait_ms
+`)
+
+// RateLimiter 分布式限流器
+// 多个 POD 使用相同的 key 即共享同一份配额
+type RateLimiter interface {
+	// Allow 是否放行一个请求（非阻塞），放行时消耗一个配额
+	Allow(ctx context.Context) (bool, error)
+
+	// Wait 阻塞直到放行一个请求或 ctx 结束，ctx 结束时返回 ctx.Err()
+	Wait(ctx context.Context) error
+}
+
+// SlidingWindowLimiter 滑动窗口限流器：任意 window 时长内最多放行 limit 个请求
+// 精确但每个请求占用一个 sorted set 成员，适合 limit 不太大的场景（如腾讯云 100 次/秒）
+type SlidingWindowLimiter struct {
+	client redis.UniversalClient
+	key    string
+	limit  int
+	window time.Duration
+}
+
+// NewSlidingWindowLimiter 生成滑动窗口限流器
+// 如腾讯云默认接口请求频率限制 100 次/秒：NewSlidingWindowLimiter(client, "txcloud:faceid", 100, time.Second)
+func NewSlidingWindowLimiter(client redis.UniversalClient, key string, limit int, window time.Duration) *SlidingWindowLimiter {
+	return &SlidingWindowLimiter{
+		client: client,
+		key:    key,
+		limit:  limit,
+		window: window,
+	}
+}
+
+// Allow 是否放行一个请求（非阻塞）
+func (l *SlidingWindowLimiter) Allow(ctx context.Context) (bool, error) {
+	wait, err := l.reserve(ctx)
+	return wait == 0 && err == nil, err
+}
+
+// Wait 阻塞直到放行一个请求或 ctx 结束
+func (l *SlidingWindowLimiter) Wait(ctx context.Context) error {
+	return waitLimiter(ctx, l.reserve)
+}
+
+// reserve 尝试消耗一个配额，返回需等待的时长，为 0 表示已放行
+func (l *SlidingWindowLimiter) reserve(ctx context.Context) (time.Duration, error) {
+	member := mooonutils.GetNonceStr(16)
+	waitMs, err := slidingWindowScript.Run(ctx, l.client, []string{l.key},
+		l.window.Microseconds(), l.limit, member).Int64()
+	if err != nil {
+		return 0, mooonerror.Wrap(mooonerror.ErrCodeRedis, err)
+	}
+	return time.Duration(waitMs) * time.Millisecond, nil
+}
+
+// TokenBucketLimiter 令牌桶限流器：以 rate 个/秒的速度生成令牌，最多积攒 burst 个
+// 允许一定的突发，状态只占一个 hash，适合高 QPS 场景
+type TokenBucketLimiter struct {
+	client redis.UniversalClient
+	key    string
+	rate   float64 // 每秒生成的令牌数
+	burst  int     // 桶的容量
+}
+
+// NewTokenBucketLimiter 生成令牌桶限流器
+func NewTokenBucketLimiter(client redis.UniversalClient, key string, rate float64, burst int) *TokenBucketLimiter {
+	return &TokenBucketLimiter{
+		client: client,
+		key:    key,
+		rate:   rate,
+		burst:  burst,
+	}
+}
+
+// Allow 是否放行一个请求（非阻塞）
+// 说明：未放行时不消耗令牌
+func (l *TokenBucketLimiter) Allow(ctx context.Context) (bool, error) {
+	wait, err := l.reserve(ctx)
+	return wait == 0 && err == nil, err
+}
+
+// Wait 阻塞直到放行一个请求或 ctx 结束
+func (l *TokenBucketLimiter) Wait(ctx context.Context) error {
+	return waitLimiter(ctx, l.reserve)
+}
+
+// reserve 尝试消耗一个令牌，返回需等待的时长，为 0 表示已放行
+func (l *TokenBucketLimiter) reserve(ctx context.Context) (time.Duration, error) {
+	waitMs, err := tokenBucketScript.Run(ctx, l.client, []string{l.key}, l.rate, l.burst).Int64()
+	if err != nil {
+		return 0, mooonerror.Wrap(mooonerror.ErrCodeRedis, err)
+	}
+	return time.Duration(waitMs) * time.Millisecond, nil
+}
+
+// waitLimiter 反复调用 reserve 直到放行或 ctx 结束
+func waitLimiter(ctx context.Context, reserve func(ctx context.Context) (time.Duration, error)) error {
+	for {
+		wait, err := reserve(ctx)
+		if err != nil {
+			return err
+		}
+		if wait == 0 {
+			return nil
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,83 @@
+// Package mooonredis
+// Wrote by yijian on 2026/10/18
+package mooonredis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestSlidingWindowLimiter 测试滑动窗口限流
+// go test -v -run="TestSlidingWindowLimiter"
+func TestSlidingWindowLimiter(t *testing.T) {
+	ctx := context.Background()
+	client := getTestRedisClient(t)
+	defer client.Close()
+	key := "test-sliding-window-limiter"
+	client.Del(ctx, key)
+
+	// 两个 POD 共享同一份配额
+	limiter1 := NewSlidingWindowLimiter(client, key, 5, time.Second)
+	limiter2 := NewSlidingWindowLimiter(client, key, 5, time.Second)
+	for i := 0; i < 5; i++ {
+		limiter := limiter1
+		if i%2 == 1 {
+			limiter = limiter2
+		}
+		allowed, err := limiter.Allow(ctx)
+		assert.NoError(t, err)
+		assert.True(t, allowed)
+	}
+	allowed, err := limiter2.Allow(ctx)
+	assert.NoError(t, err)
+	assert.False(t, allowed, "超过配额应被拒绝")
+
+	// Wait 在窗口滑过后放行
+	startTime := time.Now()
+	assert.NoError(t, limiter1.Wait(ctx))
+	assert.Greater(t, time.Since(startTime), 500*time.Millisecond)
+
+	// ctx 先于放行结束
+	for {
+		if allowed, _ := limiter1.Allow(ctx); !allowed {
+			break
+		}
+	}
+	waitCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, limiter1.Wait(waitCtx), context.DeadlineExceeded)
+}
+
+// TestTokenBucketLimiter 测试令牌桶限流
+// go test -v -run="TestTokenBucketLimiter"
+func TestTokenBucketLimiter(t *testing.T) {
+	ctx := context.Background()
+	client := getTestRedisClient(t)
+	defer client.Close()
+	key := "test-token-bucket-limiter"
+	client.Del(ctx, key)
+
+	limiter := NewTokenBucketLimiter(client, key, 10, 3)
+
+	// 允许 burst 个突发请求
+	for i := 0; i < 3; i++ {
+		allowed, err := limiter.Allow(ctx)
+		assert.NoError(t, err)
+		assert.True(t, allowed)
+	}
+	allowed, err := limiter.Allow(ctx)
+	assert.NoError(t, err)
+	assert.False(t, allowed, "令牌耗尽应被拒绝")
+
+	// 每秒 10 个令牌，5 个请求约需 0.5 秒
+	startTime := time.Now()
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, limiter.Wait(ctx))
+	}
+	elapsed := time.Since(startTime)
+	assert.Greater(t, elapsed, 400*time.Millisecond)
+	assert.Less(t, elapsed, time.Second)
+}
@@ -0,0 +1,162 @@
+// Package mooonredis
+// Wrote by yijian on 2026/10/18
+package mooonredis
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/eyjian/gomooon/mooonerror"
+	"github.com/eyjian/gomooon/mooonutils"
+	"github.com/go-redis/redis/v8"
+)
+
+// ErrLockNotHeld 释放未持有的锁
+var ErrLockNotHeld = mooonerror.NewError(mooonerror.ErrCodeRedisLockNotHeld, "lock not held by this holder")
+
+// 锁以 hash 存储：field 为持有者标识，value 为持有次数
+var reentrantLockScript = redis.NewScript(`
+local key = KEYS[1]
+local holder = ARGV[1]
+local ttl_ms = tonumber(ARGV[2])
+
+if redis.call("EXISTS", key) == 0 or redis.call("HEXISTS", key, holder) == 1 then
+	local count = redis.call("HINCRBY", key, holder, 1)
+	redis.call("PEXPIRE", key, ttl_ms)
+	return count -- 加锁成功，返回持有次数
+end
+return 0 -- 已被其他持有者持有
+`)
+
+var reentrantUnlockScript = redis.NewScript(`
+local key = KEYS[1]
+local holder = ARGV[1]
+local ttl_ms = tonumber(ARGV[2])
+
+if redis.call("HEXISTS", key, holder) == 0 then
+	return -1 -- 未持有
+end
+
+local count = redis.call("HINCRBY", key, holder, -1)
+if count > 0 then
+	redis.call("PEXPIRE", key, ttl_ms)
+	return count -- 仍持有，返回剩余持有次数
+end
+
+redis.call("DEL", key)
+return 0 -- 完全释放
+`)
+
+// ReentrantLock 可重入的分布式锁
+// 同一持有者（value 相同）可多次加锁，需调用同样次数的 Unlock 才完全释放
+// 典型应用场景：批处理任务调用的多个嵌套函数都需要同一把锁
+type ReentrantLock struct {
+	ctx        context.Context
+	client     redis.UniversalClient
+	expiration time.Duration // 设置至少 1 分钟的值，每次加锁和解锁都会重置
+	value      string        // 持有者标识，嵌套调用需使用相同的值（或同一个 ReentrantLock 实例）
+	key        string
+	observer   Observer // 观测钩子，为 nil 时使用全局默认的观测钩子
+}
+
+// ReentrantLockOption ReentrantLock 的可选项
+type ReentrantLockOption func(*ReentrantLock)
+
+// WithReentrantLockObserver 设置观测钩子，未设置时使用全局默认的观测钩子（参见 SetObserver）
+func WithReentrantLockObserver(observer Observer) ReentrantLockOption {
+	return func(rl *ReentrantLock) {
+		rl.observer = observer
+	}
+}
+
+// NewReentrantLock 生成可重入分布式锁实例
+// 参数 value 如果为空，则自动生成生成唯一值用于标识锁的持有者
+func NewReentrantLock(ctx context.Context, client redis.UniversalClient, expiration time.Duration, value string, key string, opts ...ReentrantLockOption) *ReentrantLock {
+	val := value
+	if val == "" {
+		val = mooonutils.GetNonceStr(32)
+	}
+	rl := &ReentrantLock{
+		ctx:        ctx,
+		client:     client,
+		expiration: expiration,
+		value:      val,
+		key:        key,
+	}
+	for _, opt := range opts {
+		opt(rl)
+	}
+	return rl
+}
+
+// TryLock 尝试获取锁（非阻塞）
+// 成功返回 true, nil；已被其他持有者持有返回 false, nil；出错返回 false, err
+func (rl *ReentrantLock) TryLock() (bool, error) {
+	startTime := time.Now()
+	acquired, err := rl.tryLock()
+	rl.observe(LockOpAcquire, startTime, acquired, err == nil && !acquired, 1, err)
+	return acquired, err
+}
+
+func (rl *ReentrantLock) tryLock() (bool, error) {
+	count, err := reentrantLockScript.Run(rl.ctx, rl.client, []string{rl.key}, rl.value, rl.expiration.Milliseconds()).Int64()
+	if err != nil {
+		return false, mooonerror.Wrap(mooonerror.ErrCodeRedis, err)
+	}
+	return count > 0, nil
+}
+
+// TimedLock 获取锁（阻塞）
+// 成功返回 true, nil；超时返回 false, nil；出错返回 false, err
+func (rl *ReentrantLock) TimedLock(timeout time.Duration) (bool, error) {
+	startTime := time.Now()
+	attempts := 0
+	acquired, err := retryLock(rl.ctx, timeout, countAttempts(&attempts, rl.tryLock))
+	rl.observe(LockOpAcquire, startTime, acquired, !acquired || attempts > 1, attempts, err)
+	return acquired, err
+}
+
+// Unlock 释放一次锁，持有次数减为 0 时完全释放
+// 成功返回 nil，未持有返回 ErrLockNotHeld，出错返回 err
+func (rl *ReentrantLock) Unlock() error {
+	startTime := time.Now()
+	err := rl.unlock()
+	rl.observe(LockOpRelease, startTime, err == nil, false, 1, err)
+	return err
+}
+
+func (rl *ReentrantLock) unlock() error {
+	res, err := reentrantUnlockScript.Run(rl.ctx, rl.client, []string{rl.key}, rl.value, rl.expiration.Milliseconds()).Int64()
+	if err != nil {
+		return mooonerror.Wrap(mooonerror.ErrCodeRedis, err)
+	}
+	if res < 0 {
+		return ErrLockNotHeld
+	}
+	return nil
+}
+
+// HoldCount 返回当前持有者的持有次数，未持有时返回 0
+func (rl *ReentrantLock) HoldCount() (int64, error) {
+	count, err := rl.client.HGet(rl.ctx, rl.key, rl.value).Int64()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	return count, mooonerror.Wrap(mooonerror.ErrCodeRedis, err)
+}
+
+// observe 将锁操作通知给观测钩子
+func (rl *ReentrantLock) observe(op string, startTime time.Time, success, contended bool, attempts int, err error) {
+	observeLock(rl.ctx, rl.observer, &LockEvent{
+		Lock:      "ReentrantLock",
+		Op:        op,
+		Keys:      []string{rl.key},
+		Holder:    rl.value,
+		StartTime: startTime,
+		Success:   success,
+		Contended: contended,
+		Attempts:  attempts,
+		Err:       err,
+	})
+}
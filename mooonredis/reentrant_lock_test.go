@@ -0,0 +1,56 @@
+// Package mooonredis
+// Wrote by yijian on 2026/10/18
+package mooonredis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestReentrantLock 测试同一持有者可重入、其他持有者互斥
+// go test -v -run="TestReentrantLock"
+func TestReentrantLock(t *testing.T) {
+	ctx := context.Background()
+	client := getTestRedisClient(t)
+	defer client.Close()
+	key := "test-reentrant-lock"
+	client.Del(ctx, key)
+
+	lock := NewReentrantLock(ctx, client, time.Minute, "job-1", key)
+	other := NewReentrantLock(ctx, client, time.Minute, "job-2", key)
+
+	// 嵌套加锁两次
+	acquired, err := lock.TryLock()
+	assert.NoError(t, err)
+	assert.True(t, acquired)
+	acquired, err = NewReentrantLock(ctx, client, time.Minute, "job-1", key).TimedLock(time.Second)
+	assert.NoError(t, err)
+	assert.True(t, acquired, "同一持有者应可重入")
+
+	count, err := lock.HoldCount()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+
+	// 其他持有者获取失败
+	acquired, err = other.TimedLock(200 * time.Millisecond)
+	assert.NoError(t, err)
+	assert.False(t, acquired)
+	assert.ErrorIs(t, other.Unlock(), ErrLockNotHeld)
+
+	// 释放一次后仍持有
+	assert.NoError(t, lock.Unlock())
+	acquired, err = other.TryLock()
+	assert.NoError(t, err)
+	assert.False(t, acquired, "未完全释放时其他持有者应获取失败")
+
+	// 完全释放
+	assert.NoError(t, lock.Unlock())
+	assert.ErrorIs(t, lock.Unlock(), ErrLockNotHeld)
+	acquired, err = other.TryLock()
+	assert.NoError(t, err)
+	assert.True(t, acquired)
+	assert.NoError(t, other.Unlock())
+}
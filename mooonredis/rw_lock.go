@@ -0,0 +1,224 @@
+// Package mooonredis
+// Wrote by yijian on 2026/10/18
+package mooonredis
+
+import (
+	"context"
+	"time"
+
+	"github.com/eyjian/gomooon/mooonerror"
+	"github.com/eyjian/gomooon/mooonutils"
+	"github.com/go-redis/redis/v8"
+)
+
+// 锁以 hash 存储：
+// field "mode" 为 "read" 或 "write"，因此持有者标识不能为 "mode"
+// 读锁时其它 field 为读者标识，value 为该读者的持有次数
+// 写锁时其它 field 为写者标识
+var rwReadLockScript = redis.NewScript(`
+local key = KEYS[1]
+local holder = ARGV[1]
+local ttl_ms = tonumber(ARGV[2])
+
+local mode = redis.call("HGET", key, "mode")
+if mode == "write" then
+	return 0 -- 已被写者持有
+end
+
+if not mode then
+	redis.call("HSET", key, "mode", "read")
+end
+redis.call("HINCRBY", key, holder, 1)
+redis.call("PEXPIRE", key, ttl_ms)
+return 1
+`)
+
+var rwReadUnlockScript = redis.NewScript(`
+local key = KEYS[1]
+local holder = ARGV[1]
+
+if redis.call("HGET", key, "mode") ~= "read" or redis.call("HEXISTS", key, holder) == 0 then
+	return -1 -- 未持有读锁
+end
+
+if redis.call("HINCRBY", key, holder, -1) <= 0 then
+	redis.call("HDEL", key, holder)
+end
+if redis.call("HLEN", key) <= 1 then
+	redis.call("DEL", key) -- 最后一个读者，完全释放
+end
+return 1
+`)
+
+var rwWriteLockScript = redis.NewScript(`
+local key = KEYS[1]
+local holder = ARGV[1]
+local ttl_ms = tonumber(ARGV[2])
+
+if redis.call("EXISTS", key) == 1 then
+	return 0 -- 已被读者或写者持有
+end
+
+redis.call("HSET", key, "mode", "write", holder, 1)
+redis.call("PEXPIRE", key, ttl_ms)
+return 1
+`)
+
+var rwWriteUnlockScript = redis.NewScript(`
+local key = KEYS[1]
+local holder = ARGV[1]
+
+if redis.call("HGET", key, "mode") ~= "write" or redis.call("HEXISTS", key, holder) == 0 then
+	return -1 -- 未持有写锁
+end
+
+redis.call("DEL", key)
+return 1
+`)
+
+// rwLockModeField 保存读写模式的 field
+const rwLockModeField = "mode"
+
+// 观测时的锁类型
+const (
+	rwLockRead  = "RWLock.read"
+	rwLockWrite = "RWLock.write"
+)
+
+// RWLock 分布式读写锁：同一时刻允许多个读者或一个写者
+// 典型应用场景：报表读取任务之间不互斥，但与数据更新任务互斥
+// 说明：
+// 1. 读者共享同一个过期时间，每个读者加锁时都会重置过期时间
+// 2. 不做写者优先，读者持续不断时写者可能一直获取不到锁
+// 3. 不可重入，同一持有者在持有写锁时再加读锁或写锁均会失败
+type RWLock struct {
+	ctx        context.Context
+	client     redis.UniversalClient
+	expiration time.Duration // 设置至少 1 分钟的值
+	value      string        // 生成唯一值，用于标识锁的持有者
+	key        string
+	observer   Observer // 观测钩子，为 nil 时使用全局默认的观测钩子
+}
+
+// RWLockOption RWLock 的可选项
+type RWLockOption func(*RWLock)
+
+// WithRWLockObserver 设置观测钩子，未设置时使用全局默认的观测钩子（参见 SetObserver）
+func WithRWLockObserver(observer Observer) RWLockOption {
+	return func(rw *RWLock) {
+		rw.observer = observer
+	}
+}
+
+// NewRWLock 生成分布式读写锁实例
+// 参数 value 如果为空，则自动生成生成唯一值用于标识锁的持有者；value 不能为保留的 "mode"，否则 panic
+func NewRWLock(ctx context.Context, client redis.UniversalClient, expiration time.Duration, value string, key string, opts ...RWLockOption) *RWLock {
+	if value == rwLockModeField {
+		panic("value must not be the reserved \"mode\" for RWLock")
+	}
+	val := value
+	if val == "" {
+		val = mooonutils.GetNonceStr(32)
+	}
+	rw := &RWLock{
+		ctx:        ctx,
+		client:     client,
+		expiration: expiration,
+		value:      val,
+		key:        key,
+	}
+	for _, opt := range opts {
+		opt(rw)
+	}
+	return rw
+}
+
+// TryLock 尝试获取写锁（非阻塞）
+// 成功返回 true, nil；已被持有返回 false, nil；出错返回 false, err
+func (rw *RWLock) TryLock() (bool, error) {
+	return rw.tryLock(rwLockWrite, rwWriteLockScript)
+}
+
+// TimedLock 获取写锁（阻塞）
+// 成功返回 true, nil；超时返回 false, nil；出错返回 false, err
+func (rw *RWLock) TimedLock(timeout time.Duration) (bool, error) {
+	return rw.timedLock(rwLockWrite, rwWriteLockScript, timeout)
+}
+
+// Unlock 释放写锁
+// 成功返回 nil，未持有写锁返回 ErrLockNotHeld，出错返回 err
+func (rw *RWLock) Unlock() error {
+	return rw.release(rwLockWrite, rwWriteUnlockScript)
+}
+
+// TryRLock 尝试获取读锁（非阻塞）
+// 成功返回 true, nil；已被写者持有返回 false, nil；出错返回 false, err
+func (rw *RWLock) TryRLock() (bool, error) {
+	return rw.tryLock(rwLockRead, rwReadLockScript)
+}
+
+// TimedRLock 获取读锁（阻塞）
+// 成功返回 true, nil；超时返回 false, nil；出错返回 false, err
+func (rw *RWLock) TimedRLock(timeout time.Duration) (bool, error) {
+	return rw.timedLock(rwLockRead, rwReadLockScript, timeout)
+}
+
+// RUnlock 释放读锁
+// 成功返回 nil，未持有读锁返回 ErrLockNotHeld，出错返回 err
+func (rw *RWLock) RUnlock() error {
+	return rw.release(rwLockRead, rwReadUnlockScript)
+}
+
+// run 执行加锁或解锁脚本，脚本返回 1 表示成功
+func (rw *RWLock) run(script *redis.Script) (bool, error) {
+	res, err := script.Run(rw.ctx, rw.client, []string{rw.key}, rw.value, rw.expiration.Milliseconds()).Int64()
+	if err != nil {
+		return false, mooonerror.Wrap(mooonerror.ErrCodeRedis, err)
+	}
+	return res == 1, nil
+}
+
+// tryLock 执行一次加锁脚本
+func (rw *RWLock) tryLock(lock string, script *redis.Script) (bool, error) {
+	startTime := time.Now()
+	acquired, err := rw.run(script)
+	rw.observe(lock, LockOpAcquire, startTime, acquired, err == nil && !acquired, 1, err)
+	return acquired, err
+}
+
+// timedLock 在 timeout 内反复执行加锁脚本
+func (rw *RWLock) timedLock(lock string, script *redis.Script, timeout time.Duration) (bool, error) {
+	startTime := time.Now()
+	attempts := 0
+	acquired, err := retryLock(rw.ctx, timeout, countAttempts(&attempts, func() (bool, error) {
+		return rw.run(script)
+	}))
+	rw.observe(lock, LockOpAcquire, startTime, acquired, !acquired || attempts > 1, attempts, err)
+	return acquired, err
+}
+
+// release 执行解锁脚本，未持有时返回 ErrLockNotHeld
+func (rw *RWLock) release(lock string, script *redis.Script) error {
+	startTime := time.Now()
+	ok, err := rw.run(script)
+	if err == nil && !ok {
+		err = ErrLockNotHeld
+	}
+	rw.observe(lock, LockOpRelease, startTime, err == nil, false, 1, err)
+	return err
+}
+
+// observe 将锁操作通知给观测钩子
+func (rw *RWLock) observe(lock, op string, startTime time.Time, success, contended bool, attempts int, err error) {
+	observeLock(rw.ctx, rw.observer, &LockEvent{
+		Lock:      lock,
+		Op:        op,
+		Keys:      []string{rw.key},
+		Holder:    rw.value,
+		StartTime: startTime,
+		Success:   success,
+		Contended: contended,
+		Attempts:  attempts,
+		Err:       err,
+	})
+}
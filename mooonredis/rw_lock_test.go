@@ -0,0 +1,93 @@
+// Package mooonredis
+// Wrote by yijian on 2026/10/18
+package mooonredis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRWLock_Readers 测试多个读者共享、读者与写者互斥
+// go test -v -run="TestRWLock_Readers"
+func TestRWLock_Readers(t *testing.T) {
+	ctx := context.Background()
+	client := getTestRedisClient(t)
+	defer client.Close()
+	key := "test-rw-lock"
+	client.Del(ctx, key)
+
+	reader1 := NewRWLock(ctx, client, time.Minute, "", key)
+	reader2 := NewRWLock(ctx, client, time.Minute, "", key)
+	writer := NewRWLock(ctx, client, time.Minute, "", key)
+
+	acquired, err := reader1.TryRLock()
+	assert.NoError(t, err)
+	assert.True(t, acquired)
+	acquired, err = reader2.TimedRLock(time.Second)
+	assert.NoError(t, err)
+	assert.True(t, acquired, "多个读者应可同时持有")
+
+	// 有读者时写者获取失败
+	acquired, err = writer.TimedLock(200 * time.Millisecond)
+	assert.NoError(t, err)
+	assert.False(t, acquired)
+
+	// 读者全部释放后写者获取成功
+	assert.NoError(t, reader1.RUnlock())
+	acquired, err = writer.TryLock()
+	assert.NoError(t, err)
+	assert.False(t, acquired, "仍有读者时写者应获取失败")
+	assert.NoError(t, reader2.RUnlock())
+	assert.ErrorIs(t, reader2.RUnlock(), ErrLockNotHeld)
+
+	acquired, err = writer.TryLock()
+	assert.NoError(t, err)
+	assert.True(t, acquired)
+	assert.NoError(t, writer.Unlock())
+}
+
+// TestRWLock_Writer 测试写者与读者、其他写者互斥
+// go test -v -run="TestRWLock_Writer"
+func TestRWLock_Writer(t *testing.T) {
+	ctx := context.Background()
+	client := getTestRedisClient(t)
+	defer client.Close()
+	key := "test-rw-lock"
+	client.Del(ctx, key)
+
+	writer1 := NewRWLock(ctx, client, time.Minute, "", key)
+	writer2 := NewRWLock(ctx, client, time.Minute, "", key)
+	reader := NewRWLock(ctx, client, time.Minute, "", key)
+
+	acquired, err := writer1.TryLock()
+	assert.NoError(t, err)
+	assert.True(t, acquired)
+
+	acquired, err = writer2.TryLock()
+	assert.NoError(t, err)
+	assert.False(t, acquired)
+	acquired, err = reader.TryRLock()
+	assert.NoError(t, err)
+	assert.False(t, acquired)
+
+	// 非持有者不能释放
+	assert.ErrorIs(t, writer2.Unlock(), ErrLockNotHeld)
+	assert.ErrorIs(t, reader.RUnlock(), ErrLockNotHeld)
+
+	assert.NoError(t, writer1.Unlock())
+	acquired, err = reader.TryRLock()
+	assert.NoError(t, err)
+	assert.True(t, acquired)
+	assert.NoError(t, reader.RUnlock())
+}
+
+// TestNewRWLockReservedValue 测试持有者标识不能为保留的 mode
+// go test -v -run="TestNewRWLockReservedValue"
+func TestNewRWLockReservedValue(t *testing.T) {
+	ctx := context.Background()
+	assert.Panics(t, func() { NewRWLock(ctx, nil, time.Minute, "mode", "test-rw-lock") })
+	assert.NotPanics(t, func() { NewRWLock(ctx, nil, time.Minute, "", "test-rw-lock") })
+}
@@ -0,0 +1,211 @@
+// Package mooonredis
+// Wrote by yijian on 2026/10/18
+package mooonredis
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/eyjian/gomooon/mooonerror"
+	"github.com/eyjian/gomooon/mooonutils"
+	"github.com/go-redis/redis/v8"
+)
+
+// 信号量以 sorted set 存储：member 为许可标识，score 为租约截止时间（毫秒）
+// 每次获取前先回收租约已过期的许可（持有者崩溃或未及时续期）
+var semaphoreAcquireScript = redis.NewScript(`
+redis.replicate_commands()
+local key = KEYS[1]
+local id = ARGV[1]
+local permits = tonumber(ARGV[2])
+local ttl_ms = tonumber(ARGV[3])
+
+local t = redis.call("TIME")
+local now_ms = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+
+redis.call("ZREMRANGEBYSCORE", key, "-inf", now_ms)
+if redis.call("ZCARD", key) >= permits then
+	return 0
+end
+redis.call("ZADD", key, now_ms + ttl_ms, id)
+redis.call("PEXPIRE", key, ttl_ms)
+return 1
+`)
+
+var semaphoreRenewScript = redis.NewScript(`
+redis.replicate_commands()
+local key = KEYS[1]
+local id = ARGV[1]
+local ttl_ms = tonumber(ARGV[2])
+
+local t = redis.call("TIME")
+local now_ms = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+
+local deadline = redis.call("ZSCORE", key, id)
+if not deadline or tonumber(deadline) <= now_ms then
+	return 0 -- 已过期
+end
+redis.call("ZADD", key, now_ms + ttl_ms, id)
+if redis.call("PTTL", key) < ttl_ms then
+	redis.call("PEXPIRE", key, ttl_ms)
+end
+return 1
+`)
+
+// Semaphore 分布式计数信号量：多个 POD 共享 permits 个许可
+// 典型应用场景：多个 POD 上的批处理任务共享腾讯云接口的全局并发上限
+// 每个许可带有租约，持有期间自动续期，持有者崩溃后租约过期的许可会被回收
+// 使用示例：
+//
+//	sem := mooonredis.NewSemaphore(rdb, "txcloud:faceid:concurrency", 20, 10*time.Second)
+//	permit, err := sem.Acquire(ctx)
+//	if err != nil {
+//		return err
+//	}
+//	defer permit.Release(ctx)
+//	ok, desc, requestId, err := face.VerifyIdcardAndName(idcard, name)
+type Semaphore struct {
+	client   redis.UniversalClient
+	key      string
+	permits  int           // 许可数
+	leaseTTL time.Duration // 许可的租约时长，持有期间每 leaseTTL/3 自动续期一次
+}
+
+// NewSemaphore 生成分布式信号量实例，多个 POD 使用相同的 key 和 permits 即共享同一组许可
+// permits 须不小于 1，leaseTTL 须不小于 1 毫秒（续期间隔为 leaseTTL/3，过小会导致续期空转），否则 panic
+func NewSemaphore(client redis.UniversalClient, key string, permits int, leaseTTL time.Duration) *Semaphore {
+	if permits < 1 {
+		panic("permits must be at least 1 for semaphore")
+	}
+	if leaseTTL < time.Millisecond {
+		panic("leaseTTL must be at least 1ms for semaphore")
+	}
+	return &Semaphore{
+		client:   client,
+		key:      key,
+		permits:  permits,
+		leaseTTL: leaseTTL,
+	}
+}
+
+// Permit 已获取的许可
+type Permit struct {
+	sem    *Semaphore
+	id     string
+	cancel context.CancelFunc // 停止自动续期
+	lost   chan struct{}      // 续期失败（许可已被回收）时关闭
+
+	once sync.Once
+}
+
+// TryAcquire 尝试获取一个许可（非阻塞）
+// 成功返回许可；没有空闲许可返回 nil, nil；出错返回 nil, err
+func (s *Semaphore) TryAcquire(ctx context.Context) (*Permit, error) {
+	id := mooonutils.GetNonceStr(32)
+	res, err := semaphoreAcquireScript.Run(ctx, s.client, []string{s.key}, id, s.permits, s.leaseTTL.Milliseconds()).Int()
+	if err != nil {
+		return nil, mooonerror.Wrap(mooonerror.ErrCodeRedis, err)
+	}
+	if res != 1 {
+		return nil, nil
+	}
+
+	keepaliveCtx, cancel := context.WithCancel(context.Background())
+	p := &Permit{
+		sem:    s,
+		id:     id,
+		cancel: cancel,
+		lost:   make(chan struct{}),
+	}
+	go p.keepalive(keepaliveCtx)
+	return p, nil
+}
+
+// Acquire 获取一个许可（阻塞），直到成功或 ctx 结束
+// ctx 结束时返回 ctx.Err()
+func (s *Semaphore) Acquire(ctx context.Context) (*Permit, error) {
+	// 初始重试间隔
+	retryInterval := 20 * time.Millisecond
+	// 最大重试间隔
+	maxRetryInterval := 500 * time.Millisecond
+
+	for {
+		permit, err := s.TryAcquire(ctx)
+		if err != nil || permit != nil {
+			return permit, err
+		}
+
+		timer := time.NewTimer(retryInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+			retryInterval = time.Duration(math.Min(float64(retryInterval*2), float64(maxRetryInterval)))
+		}
+	}
+}
+
+// Release 释放许可，重复调用无副作用
+func (p *Permit) Release(ctx context.Context) error {
+	var err error
+	p.once.Do(func() {
+		p.cancel()
+		err = mooonerror.Wrap(mooonerror.ErrCodeRedis, p.sem.client.ZRem(ctx, p.sem.key, p.id).Err())
+	})
+	return err
+}
+
+// Lost 返回一个在许可被回收（续期失败）时被关闭的 channel，持有者应据此中止操作
+func (p *Permit) Lost() <-chan struct{} {
+	return p.lost
+}
+
+// keepalive 每 leaseTTL/3 续期一次，直到释放或续期失败
+// 网络等错误时在租约到期前继续重试
+func (p *Permit) keepalive(ctx context.Context) {
+	interval := p.sem.leaseTTL / 3
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	deadline := time.Now().Add(p.sem.leaseTTL)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		startTime := time.Now()
+		res, err := semaphoreRenewScript.Run(ctx, p.sem.client, []string{p.sem.key}, p.id, p.sem.leaseTTL.Milliseconds()).Int()
+		if ctx.Err() != nil {
+			return
+		}
+		if err == nil && res == 1 {
+			deadline = startTime.Add(p.sem.leaseTTL)
+			continue
+		}
+		if err != nil && time.Until(deadline) > interval {
+			continue
+		}
+
+		close(p.lost)
+		return
+	}
+}
+
+// Available 返回当前空闲的许可数（不含已过期待回收的许可），仅供观测
+func (s *Semaphore) Available(ctx context.Context) (int, error) {
+	now := time.Now().UnixMilli()
+	held, err := s.client.ZCount(ctx, s.key, "("+strconv.FormatInt(now, 10), "+inf").Result()
+	if err != nil {
+		return 0, mooonerror.Wrap(mooonerror.ErrCodeRedis, err)
+	}
+	if int(held) >= s.permits {
+		return 0, nil
+	}
+	return s.permits - int(held), nil
+}
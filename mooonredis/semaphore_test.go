@@ -0,0 +1,99 @@
+// Package mooonredis
+// Wrote by yijian on 2026/10/18
+package mooonredis
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestSemaphore 测试多个实例共享并发上限
+// go test -v -run="TestSemaphore"
+func TestSemaphore(t *testing.T) {
+	ctx := context.Background()
+	client := getTestRedisClient(t)
+	defer client.Close()
+	key := "test-semaphore"
+	client.Del(ctx, key)
+
+	var running, maxRunning int32
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		// 每个协程使用独立的实例，模拟多个 POD
+		sem := NewSemaphore(client, key, 3, 3*time.Second)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			permit, err := sem.Acquire(ctx)
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer permit.Release(ctx)
+
+			n := atomic.AddInt32(&running, 1)
+			for {
+				m := atomic.LoadInt32(&maxRunning)
+				if n <= m || atomic.CompareAndSwapInt32(&maxRunning, m, n) {
+					break
+				}
+			}
+			time.Sleep(50 * time.Millisecond)
+			atomic.AddInt32(&running, -1)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(3), atomic.LoadInt32(&maxRunning))
+}
+
+// TestSemaphoreReclaim 测试回收崩溃持有者的许可，以及持有期间自动续期
+// go test -v -run="TestSemaphoreReclaim"
+func TestSemaphoreReclaim(t *testing.T) {
+	ctx := context.Background()
+	client := getTestRedisClient(t)
+	defer client.Close()
+	key := "test-semaphore-reclaim"
+	client.Del(ctx, key)
+
+	sem := NewSemaphore(client, key, 1, 600*time.Millisecond)
+	// 模拟崩溃的持有者：直接写入一个不续期的许可
+	client.ZAdd(ctx, key, &redis.Z{Score: float64(time.Now().Add(300 * time.Millisecond).UnixMilli()), Member: "crashed"})
+	permit, err := sem.TryAcquire(ctx)
+	assert.NoError(t, err)
+	assert.Nil(t, permit)
+
+	permit, err = sem.Acquire(ctx)
+	assert.NoError(t, err)
+	if !assert.NotNil(t, permit) {
+		return
+	}
+
+	// 持有超过租约时长仍不会被回收
+	time.Sleep(time.Second)
+	other, err := sem.TryAcquire(ctx)
+	assert.NoError(t, err)
+	assert.Nil(t, other)
+	select {
+	case <-permit.Lost():
+		t.Fatal("permit should not be lost")
+	default:
+	}
+
+	assert.NoError(t, permit.Release(ctx))
+	available, err := sem.Available(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, available)
+}
+
+// TestNewSemaphoreInvalid 测试非法的许可数和租约时长
+// go test -v -run="TestNewSemaphoreInvalid"
+func TestNewSemaphoreInvalid(t *testing.T) {
+	assert.Panics(t, func() { NewSemaphore(nil, "test-semaphore", 0, time.Second) })
+	assert.Panics(t, func() { NewSemaphore(nil, "test-semaphore", 1, 0) })
+	assert.NotPanics(t, func() { NewSemaphore(nil, "test-semaphore", 1, time.Millisecond) })
+}
@@ -5,201 +5,440 @@ package mooonredis
 import (
 	"context"
 	"fmt"
+	"github.com/eyjian/gomooon/mooonerror"
 	"github.com/eyjian/gomooon/mooonutils"
 	"github.com/go-redis/redis/v8"
-	"math"
 	"sync"
 	"time"
 )
 
+var lockWithFenceScript = redis.NewScript(`
+local key = KEYS[1]
+local fence_key = KEYS[2]
+local acquired_key = KEYS[3]
+local val = ARGV[1]
+local ttl_ms = tonumber(ARGV[2])
+local now_ms = ARGV[3]
+
+if redis.call("SET", key, val, "PX", ttl_ms, "NX") then
+	redis.call("SET", acquired_key, now_ms, "PX", ttl_ms)
+	return redis.call("INCR", fence_key) -- 获取成功，返回新 token
+end
+return 0 -- 已被持有
+`)
+
+// 计数器小于 ARGV[1] 时抬高到 ARGV[1]，不会降低
+var raiseFenceScript = redis.NewScript(`
+local fence = tonumber(ARGV[1])
+if tonumber(redis.call("GET", KEYS[1]) or "0") < fence then
+	redis.call("SET", KEYS[1], fence)
+end
+return 1
+`)
+
+var unlockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1], KEYS[2])
+end
+return 0
+`)
+
+// 默认的时钟漂移因子，参考 Redlock 算法的建议值
+const defaultDriftFactor = 0.01
+
 // SimDistLock 简单的分布式锁
+// 支持两种模式：
+// 1. 单 Redis 部署多 key 模式（NewSimDistLock）：对同一个 client 上的多个 key 加锁
+// 2. Redlock 模式（NewRedlock）：对 N 个独立 Redis 实例上的同一个 key 加锁，能容忍少数实例故障
+// 在 quorum 个 key 上加锁成功，且剩余有效时间大于 0 才算成功
 type SimDistLock struct {
-	ctx        context.Context
-	client     redis.UniversalClient
-	expiration time.Duration // 设置至少 1 分钟的值
-	value      string        // 生成唯一值，用于标识锁的持有者
-	keys       []string      // 独一无二的 Key 数组（固定为 3 个，对 3 个 keys 都成功才算成功，同 redis 集群节点数无关）
+	ctx         context.Context
+	clients     []redis.UniversalClient // 与 keys 一一对应，keys[i] 在 clients[i] 上加锁
+	expiration  time.Duration           // 未开启看门狗时设置至少 1 分钟的值
+	value       string                  // 生成唯一值，用于标识锁的持有者
+	keys        []string                // 独一无二的 Key 数组
+	quorum      int                     // 加锁成功所需的最少 key 数
+	driftFactor float64                 // 时钟漂移因子，计算有效时间时扣除 expiration*driftFactor
+
+	watchdogInterval time.Duration   // 看门狗续期间隔，为 0 表示不开启看门狗
+	onLost           func(err error) // 看门狗续期失败时的回调
+	fairQueue        bool            // TimedLock 是否公平排队
+	observer         Observer        // 观测钩子，为 nil 时使用全局默认的观测钩子
+
+	mu             sync.Mutex
+	validUntil     time.Time          // 锁的有效截止时间
+	watchdogCancel context.CancelFunc // 停止看门狗
+	lost           chan struct{}      // 看门狗续期失败时关闭
+}
+
+// SimDistLockOption SimDistLock 的可选项
+type SimDistLockOption func(*SimDistLock)
+
+// WithQuorum 设置加锁成功所需的最少 key 数，取值范围为 [1, len(keys)]
+// 使用 fencing token 时，quorum 须大于 key 数的一半，否则 TryLockWithFence 和 TimedLockWithFence 返回 ErrCodeInvalidParam 错误
+func WithQuorum(quorum int) SimDistLockOption {
+	return func(dl *SimDistLock) {
+		dl.quorum = quorum
+	}
+}
+
+// WithDriftFactor 设置时钟漂移因子，默认 0.01
+func WithDriftFactor(driftFactor float64) SimDistLockOption {
+	return func(dl *SimDistLock) {
+		dl.driftFactor = driftFactor
+	}
+}
+
+// WithObserver 设置观测钩子，未设置时使用全局默认的观测钩子（参见 SetObserver）
+func WithObserver(observer Observer) SimDistLockOption {
+	return func(dl *SimDistLock) {
+		dl.observer = observer
+	}
 }
 
 // NewSimDistLock 生成分布式锁实例
 // 参数 value 如果为空，则自动生成生成唯一值用于标识锁的持有者
-func NewSimDistLock(ctx context.Context, client redis.UniversalClient, expiration time.Duration, value string, keys []string) *SimDistLock {
-	if len(keys) != 3 {
-		panic("keys must be exactly 3 for this distributed lock implementation")
+// 默认需对所有 keys 都加锁成功才算成功，可通过 WithQuorum 指定
+func NewSimDistLock(ctx context.Context, client redis.UniversalClient, expiration time.Duration, value string, keys []string, opts ...SimDistLockOption) *SimDistLock {
+	clients := make([]redis.UniversalClient, len(keys))
+	for i := range keys {
+		clients[i] = client
+	}
+	return newSimDistLock(ctx, clients, expiration, value, keys, len(keys), opts...)
+}
+
+// NewRedlock 生成 Redlock 模式的分布式锁实例
+// clients 为 N 个相互独立的 Redis 实例（非同一集群的节点），在每个实例上对同一个 key 加锁
+// 默认需在多数（N/2+1）实例上加锁成功才算成功，可通过 WithQuorum 指定
+// 参数 value 如果为空，则自动生成生成唯一值用于标识锁的持有者
+func NewRedlock(ctx context.Context, clients []redis.UniversalClient, expiration time.Duration, value string, key string, opts ...SimDistLockOption) *SimDistLock {
+	keys := make([]string, len(clients))
+	for i := range clients {
+		keys[i] = key
+	}
+	return newSimDistLock(ctx, clients, expiration, value, keys, len(clients)/2+1, opts...)
+}
+
+func newSimDistLock(ctx context.Context, clients []redis.UniversalClient, expiration time.Duration, value string, keys []string, quorum int, opts ...SimDistLockOption) *SimDistLock {
+	if len(keys) == 0 {
+		panic("keys must not be empty for this distributed lock implementation")
 	}
 	val := value
 	if val == "" {
 		val = mooonutils.GetNonceStr(32)
 	}
-	return &SimDistLock{
-		ctx:        ctx,
-		client:     client,
-		expiration: expiration,
-		value:      value,
-		keys:       keys,
+	dl := &SimDistLock{
+		ctx:         ctx,
+		clients:     clients,
+		expiration:  expiration,
+		value:       val,
+		keys:        keys,
+		quorum:      quorum,
+		driftFactor: defaultDriftFactor,
+	}
+	for _, opt := range opts {
+		opt(dl)
 	}
+	if dl.quorum < 1 || dl.quorum > len(keys) {
+		panic(fmt.Sprintf("quorum must be in [1, %d] for this distributed lock implementation", len(keys)))
+	}
+	return dl
+}
+
+// Validity 返回锁的剩余有效时间，未持有锁或已过期时返回 0
+// 持有者应在剩余有效时间内完成临界区操作
+func (dl *SimDistLock) Validity() time.Duration {
+	dl.mu.Lock()
+	defer dl.mu.Unlock()
+	remaining := time.Until(dl.validUntil)
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
 }
 
 // TryLock 尝试获取锁（非阻塞）
 // 成功返回 true, nil；超时返回 false, nil；出错返回 false, err
+// 出错的 key 不多于 len(keys)-quorum 时（如 Redlock 模式下少数实例故障）不返回错误，按锁被持有处理
 func (dl *SimDistLock) TryLock() (bool, error) {
+	acquired, _, err := dl.tryLock(false)
+	return acquired, err
+}
+
+// TryLockWithFence 同 TryLock，但成功时额外返回 fencing token
+// token 取各 key 上 token 的最大值，并在返回前将 quorum 个 key 的计数器抬高到该值，每次成功获取锁时单调递增，失败时为 0
+// quorum 不大于 key 数的一半时返回 ErrCodeInvalidParam 错误
+func (dl *SimDistLock) TryLockWithFence() (bool, int64, error) {
+	if err := dl.checkFenceQuorum(); err != nil {
+		return false, 0, err
+	}
+	return dl.tryLock(true)
+}
+
+func (dl *SimDistLock) tryLock(withFence bool) (bool, int64, error) {
+	startTime := time.Now()
+	acquired, fence, err := dl.tryLockWithFence(withFence)
+	dl.observe(LockOpAcquire, startTime, acquired, err == nil && !acquired, 1, err)
+	return acquired, fence, err
+}
+
+// tryLockWithFence 尝试获取锁一次，不通知观测钩子
+// withFence 为 true 时将加锁成功的 key 的计数器抬高到返回的 token，保证之后加锁成功得到的 token 更大
+func (dl *SimDistLock) tryLockWithFence(withFence bool) (bool, int64, error) {
 	var (
-		acquiredKeys []string
-		mu           sync.Mutex
+		acquired  []int         // 加锁成功的 key 下标
+		keyFences map[int]int64 // 加锁成功的 key 上的 token
+		fence     int64
+		errorList []error
+		mu        sync.Mutex
 	)
+	keyFences = make(map[int]int64, len(dl.keys))
 
 	var wg sync.WaitGroup
-	errs := make(chan error, len(dl.keys))
+	startTime := time.Now()
 
 	// 并行尝试在每个key上获取锁
-	for _, key := range dl.keys {
+	for i := range dl.keys {
 		wg.Add(1)
-		go func(k string) {
+		go func(i int) {
 			defer wg.Done()
 
-			// 使用SET命令的NX和PX选项原子性地获取锁
-			set, err := dl.client.SetNX(dl.ctx, k, dl.value, dl.expiration).Result()
+			// 使用SET命令的NX和PX选项原子性地获取锁，成功时同时递增 fencing token
+			k := dl.keys[i]
+			keyFence, err := lockWithFenceScript.Run(dl.ctx, dl.clients[i],
+				[]string{k, fenceKeyOf(k), acquiredKeyOf(k)}, dl.value, dl.expiration.Milliseconds(), time.Now().UnixMilli()).Int64()
+
+			mu.Lock()
+			defer mu.Unlock()
 			if err != nil {
-				errs <- err
+				errorList = append(errorList, err)
 				return
 			}
-
-			if set {
-				mu.Lock()
-				acquiredKeys = append(acquiredKeys, k)
-				mu.Unlock()
+			if keyFence > 0 {
+				acquired = append(acquired, i)
+				keyFences[i] = keyFence
+				if keyFence > fence {
+					fence = keyFence
+				}
 			}
-		}(key)
+		}(i)
 	}
 
 	// 等待所有尝试完成
 	wg.Wait()
-	close(errs)
 
-	// 检查是否有错误
-	var errorList []error
-	for err := range errs {
-		errorList = append(errorList, err)
+	// 各 key 的计数器相互独立，取最大值作为 token 时，
+	// 需在 quorum 个 key 上将计数器抬高到该值，之后加锁成功的持有者与本次至少有一个相同的 key，其 token 才一定更大
+	if withFence && len(acquired) >= dl.quorum {
+		raised, errs := dl.raiseFence(acquired, keyFences, fence)
+		errorList = append(errorList, errs...)
+		if raised < dl.quorum {
+			fence = 0
+			dl.unlockKeys(acquired)
+			acquired = nil
+		}
 	}
 
-	if len(errorList) > 0 {
-		// 释放已获取的锁
-		dl.unlockKeys(acquiredKeys)
-		return false, fmt.Errorf("errors while acquiring lock: %v", errorList)
+	// 扣除加锁耗时和时钟漂移，得到锁的剩余有效时间
+	drift := time.Duration(float64(dl.expiration)*dl.driftFactor) + 2*time.Millisecond
+	validity := dl.expiration - time.Since(startTime) - drift
+
+	// 检查是否在 quorum 个 key 上加锁成功，少数 key 出错不影响结果
+	if len(acquired) >= dl.quorum && validity > 0 {
+		dl.mu.Lock()
+		dl.validUntil = startTime.Add(dl.expiration - drift)
+		dl.mu.Unlock()
+		if dl.watchdogInterval > 0 {
+			dl.startWatchdog()
+		}
+		return true, fence, nil
 	}
 
-	// 检查是否成功获取了所有3个key的锁
-	if len(acquiredKeys) == len(dl.keys) {
-		return true, nil
+	// 获取锁失败，释放已获取的部分锁
+	if len(acquired) > 0 {
+		dl.unlockKeys(acquired)
 	}
 
-	// 获取锁失败，释放已获取的部分锁
-	if len(acquiredKeys) > 0 {
-		dl.unlockKeys(acquiredKeys)
+	// 出错的 key 不多于 len(keys)-quorum 时，其余 key 仍可能凑够 quorum，视为锁被持有，以便 TimedLock 继续等待
+	if len(errorList) > len(dl.keys)-dl.quorum {
+		return false, 0, mooonerror.Errorf(mooonerror.ErrCodeRedis, "errors while acquiring lock: %v", errorList)
+	}
+
+	return false, 0, nil
+}
+
+// raiseFence 将 acquired 中 token 小于 fence 的 key 的计数器抬高到 fence，返回计数器不小于 fence 的 key 数
+func (dl *SimDistLock) raiseFence(acquired []int, keyFences map[int]int64, fence int64) (int, []error) {
+	var (
+		raised    int
+		errorList []error
+		mu        sync.Mutex
+		wg        sync.WaitGroup
+	)
+
+	for _, i := range acquired {
+		if keyFences[i] >= fence {
+			raised++
+			continue
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			k := dl.keys[i]
+			err := raiseFenceScript.Run(dl.ctx, dl.clients[i], []string{fenceKeyOf(k)}, fence).Err()
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errorList = append(errorList, err)
+				return
+			}
+			raised++
+		}(i)
 	}
 
-	return false, nil
+	wg.Wait()
+	return raised, errorList
+}
+
+// checkFenceQuorum 使用 fencing token 时，quorum 须大于 key 数的一半，
+// 否则先后两个持有者加锁成功的 key 可能没有交集，token 不能保证单调递增
+func (dl *SimDistLock) checkFenceQuorum() error {
+	if dl.quorum*2 <= len(dl.keys) {
+		return mooonerror.Errorf(mooonerror.ErrCodeInvalidParam, "fencing token requires quorum greater than half of %d keys, got %d", len(dl.keys), dl.quorum)
+	}
+	return nil
 }
 
 // TimedLock 获取锁（阻塞）
 // 成功返回 true, nil；超时返回 false, nil；出错返回 false, err
 func (dl *SimDistLock) TimedLock(timeout time.Duration) (bool, error) {
-	ctx, cancel := context.WithTimeout(dl.ctx, timeout)
-	defer cancel()
-
-	// 初始重试间隔
-	retryInterval := 50 * time.Millisecond
-	// 最大重试间隔
-	maxRetryInterval := 500 * time.Millisecond
-
-	for {
-		// 尝试获取锁
-		acquired, err := dl.TryLock()
-		if err != nil {
-			return false, err
-		}
+	acquired, _, err := dl.timedLock(timeout, false)
+	return acquired, err
+}
 
-		if acquired {
-			return true, nil
-		}
+// TimedLockWithFence 同 TimedLock，但成功时额外返回 fencing token
+// 开启公平排队（WithFairQueue）时按先来先得的顺序获取锁，否则以指数退避的方式轮询
+// quorum 不大于 key 数的一半时返回 ErrCodeInvalidParam 错误
+func (dl *SimDistLock) TimedLockWithFence(timeout time.Duration) (bool, int64, error) {
+	if err := dl.checkFenceQuorum(); err != nil {
+		return false, 0, err
+	}
+	return dl.timedLock(timeout, true)
+}
 
-		// 检查是否超时
-		select {
-		case <-ctx.Done():
-			return false, ctx.Err()
-		default:
-			// 指数退避：每次重试间隔翻倍，但不超过最大值
-			time.Sleep(retryInterval)
-			retryInterval = time.Duration(math.Min(float64(retryInterval*2), float64(maxRetryInterval)))
-		}
+func (dl *SimDistLock) timedLock(timeout time.Duration, withFence bool) (bool, int64, error) {
+	var (
+		fence    int64
+		attempts int
+	)
+	try := countAttempts(&attempts, func() (bool, error) {
+		var (
+			acquired bool
+			err      error
+		)
+		acquired, fence, err = dl.tryLockWithFence(withFence)
+		return acquired, err
+	})
+
+	startTime := time.Now()
+	var (
+		acquired bool
+		err      error
+	)
+	if dl.fairQueue {
+		acquired, err = dl.fairLock(timeout, try)
+	} else {
+		acquired, err = retryLock(dl.ctx, timeout, try)
 	}
+	dl.observe(LockOpAcquire, startTime, acquired, !acquired || attempts > 1, attempts, err)
+	if !acquired {
+		return false, 0, err
+	}
+	return true, fence, nil
 }
 
 // Unlock 释放锁（原子操作）
-// 成功返回 nil，出错返回 err
+// 成功返回 nil，出错返回 err；出错的 key 不多于 len(keys)-quorum 时视为成功，这些 key 上的锁到期后自动释放
 func (dl *SimDistLock) Unlock() error {
+	startTime := time.Now()
+	err := dl.unlock()
+	dl.observe(LockOpRelease, startTime, err == nil, false, 1, err)
+	return err
+}
+
+func (dl *SimDistLock) unlock() error {
 	var wg sync.WaitGroup
 	errs := make(chan error, len(dl.keys))
 
+	dl.stopWatchdog()
+	dl.mu.Lock()
+	dl.validUntil = time.Time{}
+	dl.mu.Unlock()
+
 	// 并行释放所有key上的锁
-	for _, key := range dl.keys {
+	for i := range dl.keys {
 		wg.Add(1)
-		go func(k string) {
+		go func(i int) {
 			defer wg.Done()
 
 			// 使用Lua脚本确保原子性释放锁
-			script := `
-				if redis.call("GET", KEYS[1]) == ARGV[1] then
-					return redis.call("DEL", KEYS[1])
-				else
-					return 0
-				end
-			`
-
-			_, err := dl.client.Eval(dl.ctx, script, []string{k}, dl.value).Result()
+			_, err := unlockScript.Run(dl.ctx, dl.clients[i], []string{dl.keys[i], acquiredKeyOf(dl.keys[i])}, dl.value).Result()
 			if err != nil {
 				errs <- err
 			}
-		}(key)
+		}(i)
 	}
 
 	// 等待所有释放操作完成
 	wg.Wait()
 	close(errs)
 
+	// 通知排队等待的持有者
+	if dl.fairQueue {
+		dl.notifyWaiters()
+	}
+
 	// 收集并返回所有错误
 	var errorList []error
 	for err := range errs {
 		errorList = append(errorList, err)
 	}
 
-	if len(errorList) > 0 {
-		return fmt.Errorf("failed to unlock on %d keys: %v", len(errorList), errorList)
+	if len(errorList) > len(dl.keys)-dl.quorum {
+		return mooonerror.Errorf(mooonerror.ErrCodeRedis, "failed to unlock on %d keys: %v", len(errorList), errorList)
 	}
 
 	return nil
 }
 
-// 释放指定keys上的锁
-func (dl *SimDistLock) unlockKeys(keys []string) {
+// 释放指定下标的keys上的锁
+func (dl *SimDistLock) unlockKeys(indexes []int) {
 	var wg sync.WaitGroup
 
-	for _, key := range keys {
+	for _, i := range indexes {
 		wg.Add(1)
-		go func(k string) {
+		go func(i int) {
 			defer wg.Done()
 
-			script := `
-				if redis.call("GET", KEYS[1]) == ARGV[1] then
-					return redis.call("DEL", KEYS[1])
-				else
-					return 0
-				end
-			`
-
-			dl.client.Eval(dl.ctx, script, []string{k}, dl.value).Result()
-		}(key)
+			unlockScript.Run(dl.ctx, dl.clients[i], []string{dl.keys[i], acquiredKeyOf(dl.keys[i])}, dl.value).Result()
+		}(i)
 	}
 
 	wg.Wait()
-}
\ No newline at end of file
+}
+
+// observe 将锁操作通知给观测钩子
+func (dl *SimDistLock) observe(op string, startTime time.Time, success, contended bool, attempts int, err error) {
+	observeLock(dl.ctx, dl.observer, &LockEvent{
+		Lock:      "SimDistLock",
+		Op:        op,
+		Keys:      dl.keys,
+		Holder:    dl.value,
+		StartTime: startTime,
+		Success:   success,
+		Contended: contended,
+		Attempts:  attempts,
+		Err:       err,
+	})
+}
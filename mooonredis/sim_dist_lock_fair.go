@@ -0,0 +1,152 @@
+// Package mooonredis
+// Wrote by yijian on 2026/10/18
+package mooonredis
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	fairPollInterval = 500 * time.Millisecond // 未收到释放通知时的兜底轮询间隔
+	fairWaiterAlive  = 5 * time.Second        // 等待者的心跳有效期，超过未刷新视为已崩溃，从队列中移除
+)
+
+// 等待队列：
+// KEYS[1] 为 sorted set，member 为等待者标识，score 为入队序号（保证先来先得）
+// KEYS[2] 为 hash，field 为等待者标识，value 为心跳截止时间（毫秒）
+// KEYS[3] 为入队序号计数器
+// 返回 1 表示调用者位于队首，0 表示需继续等待
+var fairEnqueueScript = redis.NewScript(`
+local queue_key = KEYS[1]
+local waiters_key = KEYS[2]
+local seq_key = KEYS[3]
+local waiter = ARGV[1]
+local now_ms = tonumber(ARGV[2])
+local alive_ms = tonumber(ARGV[3])
+
+redis.call("HSET", waiters_key, waiter, now_ms + alive_ms)
+if not redis.call("ZSCORE", queue_key, waiter) then
+	redis.call("ZADD", queue_key, redis.call("INCR", seq_key), waiter)
+end
+
+while true do
+	local head = redis.call("ZRANGE", queue_key, 0, 0)[1]
+	if not head then
+		return 0
+	end
+	if head == waiter then
+		return 1
+	end
+
+	-- 移除已崩溃的队首等待者
+	local deadline = tonumber(redis.call("HGET", waiters_key, head) or "0")
+	if deadline >= now_ms then
+		return 0
+	end
+	redis.call("ZREM", queue_key, head)
+	redis.call("HDEL", waiters_key, head)
+end
+`)
+
+var fairDequeueScript = redis.NewScript(`
+redis.call("ZREM", KEYS[1], ARGV[1])
+redis.call("HDEL", KEYS[2], ARGV[1])
+if redis.call("ZCARD", KEYS[1]) == 0 then
+	redis.call("DEL", KEYS[1], KEYS[2], KEYS[3])
+end
+return 1
+`)
+
+// WithFairQueue 开启公平排队：TimedLock 的等待者在 Redis 中排队，按先来先得的顺序获取锁
+// 锁释放时通过 pub/sub 唤醒等待者，未收到通知时（如持有者崩溃导致锁过期）以轮询兜底
+// 队列存放在第一个 key 所在的 Redis 上，不可用时退化为轮询
+// 说明：TryLock 不排队，可能插到等待者之前
+func WithFairQueue() SimDistLockOption {
+	return func(dl *SimDistLock) {
+		dl.fairQueue = true
+	}
+}
+
+// fairQueueKeys 返回等待队列、等待者心跳和入队序号的 key
+func (dl *SimDistLock) fairQueueKeys() []string {
+	return []string{
+		relatedKeyOf(dl.keys[0], "queue"),
+		relatedKeyOf(dl.keys[0], "waiters"),
+		relatedKeyOf(dl.keys[0], "queue_seq"),
+	}
+}
+
+// releasedChannel 返回锁释放通知的 pub/sub channel
+func (dl *SimDistLock) releasedChannel() string {
+	return relatedKeyOf(dl.keys[0], "released")
+}
+
+// notifyWaiters 通知等待者锁已释放或队首已变化
+func (dl *SimDistLock) notifyWaiters() {
+	dl.clients[0].Publish(dl.ctx, dl.releasedChannel(), dl.value)
+}
+
+// fairLock 排队获取锁，轮到自己时调用 try 加锁，严格在 timeout 到期时返回
+func (dl *SimDistLock) fairLock(timeout time.Duration, try func() (bool, error)) (bool, error) {
+	ctx, cancel := context.WithTimeout(dl.ctx, timeout)
+	defer cancel()
+
+	client := dl.clients[0]
+	queueKeys := dl.fairQueueKeys()
+
+	// 先订阅再入队，避免错过入队和订阅之间的释放通知
+	var released <-chan *redis.Message
+	pubsub := client.Subscribe(ctx, dl.releasedChannel())
+	defer pubsub.Close()
+	if _, err := pubsub.Receive(ctx); err == nil {
+		released = pubsub.Channel()
+	}
+
+	// 无论成功与否，离开时出队，并通知下一个等待者
+	defer func() {
+		dequeueCtx, dequeueCancel := context.WithTimeout(context.Background(), time.Second)
+		defer dequeueCancel()
+		if fairDequeueScript.Run(dequeueCtx, client, queueKeys, dl.value).Err() == nil {
+			client.Publish(dequeueCtx, dl.releasedChannel(), dl.value)
+		}
+	}()
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) && dl.ctx.Err() == nil {
+				return false, nil // 超时
+			}
+			return false, ctx.Err()
+		case <-released:
+		case <-timer.C:
+		}
+
+		// 队列不可用时退化为轮询，直接尝试加锁
+		isHead, err := fairEnqueueScript.Run(ctx, client, queueKeys,
+			dl.value, time.Now().UnixMilli(), fairWaiterAlive.Milliseconds()).Int()
+		if err != nil || isHead == 1 {
+			acquired, err := try()
+			if err != nil {
+				return false, err
+			}
+			if acquired {
+				return true, nil
+			}
+		}
+
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(fairPollInterval)
+	}
+}
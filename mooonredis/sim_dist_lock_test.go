@@ -4,6 +4,7 @@ package mooonredis
 
 import (
 	"context"
+	"github.com/eyjian/gomooon/mooonerror"
 	"github.com/go-redis/redis/v8"
 	"github.com/stretchr/testify/assert"
 	"math/rand"
@@ -268,4 +269,264 @@ func TestClusterSupport(t *testing.T) {
 	acquired, err = lock.TryLock()
 	assert.NoError(t, err)
 	assert.True(t, acquired)
-}
\ No newline at end of file
+}
+
+// TestTryLockWithFence 测试 fencing token 单调递增
+// go test -v -run="TestTryLockWithFence"
+func TestTryLockWithFence(t *testing.T) {
+	ctx := context.Background()
+	client := getTestRedisClient(t)
+	keys := getTestKeys()
+
+	lock1 := NewSimDistLock(ctx, client, 2*time.Minute, "", keys)
+	acquired, fence1, err := lock1.TryLockWithFence()
+	assert.NoError(t, err)
+	assert.True(t, acquired)
+	assert.Greater(t, fence1, int64(0))
+
+	// 锁被持有时获取失败，token 为 0
+	lock2 := NewSimDistLock(ctx, client, 2*time.Minute, "", keys)
+	acquired, fence2, err := lock2.TryLockWithFence()
+	assert.NoError(t, err)
+	assert.False(t, acquired)
+	assert.Equal(t, int64(0), fence2)
+
+	// 释放后再次获取，token 变大
+	assert.NoError(t, lock1.Unlock())
+	acquired, fence2, err = lock2.TimedLockWithFence(time.Second)
+	assert.NoError(t, err)
+	assert.True(t, acquired)
+	assert.Greater(t, fence2, fence1)
+	assert.NoError(t, lock2.Unlock())
+}
+
+// TestNewSimDistLock_Quorum 测试 quorum 参数校验
+// go test -v -run="TestNewSimDistLock_Quorum"
+func TestNewSimDistLock_Quorum(t *testing.T) {
+	ctx := context.Background()
+	client := redis.NewClient(&redis.Options{Addr: "localhost:6379"})
+	defer client.Close()
+	keys := getTestKeys()
+
+	lock := NewSimDistLock(ctx, client, 2*time.Minute, "", keys)
+	assert.Equal(t, len(keys), lock.quorum, "默认需所有key加锁成功")
+
+	lock = NewSimDistLock(ctx, client, 2*time.Minute, "", keys, WithQuorum(2))
+	assert.Equal(t, 2, lock.quorum)
+
+	assert.Panics(t, func() { NewSimDistLock(ctx, client, 2*time.Minute, "", keys, WithQuorum(4)) })
+	assert.Panics(t, func() { NewSimDistLock(ctx, client, 2*time.Minute, "", nil) })
+
+	redlock := NewRedlock(ctx, []redis.UniversalClient{client, client, client, client, client}, 2*time.Minute, "", "test-redlock")
+	assert.Equal(t, 3, redlock.quorum, "Redlock 默认需多数实例加锁成功")
+	assert.Equal(t, time.Duration(0), redlock.Validity(), "未加锁时剩余有效时间应为0")
+
+	// quorum 不大于 key 数的一半时不支持 fencing token
+	lock = NewSimDistLock(ctx, client, 2*time.Minute, "", keys, WithQuorum(1))
+	_, _, err := lock.TryLockWithFence()
+	assert.Equal(t, mooonerror.ErrCodeInvalidParam, mooonerror.Code(err))
+	_, _, err = lock.TimedLockWithFence(time.Second)
+	assert.Equal(t, mooonerror.ErrCodeInvalidParam, mooonerror.Code(err))
+}
+
+// TestRedlockFence 测试 Redlock 模式下各实例计数器不同时 token 仍单调递增
+// go test -v -run="TestRedlockFence"
+func TestRedlockFence(t *testing.T) {
+	ctx := context.Background()
+	getTestRedisClient(t).Close()
+
+	const key = "test-redlock-fence"
+	var clients []redis.UniversalClient
+	for db := 0; db < 3; db++ {
+		client := redis.NewClient(&redis.Options{Addr: "localhost:6379", DB: db})
+		defer client.Close()
+		client.Del(ctx, key, fenceKeyOf(key), acquiredKeyOf(key))
+		clients = append(clients, client)
+	}
+	clients[0].Set(ctx, fenceKeyOf(key), 100, 0)
+	clients[1].Set(ctx, fenceKeyOf(key), 100, 0)
+
+	// 实例 1 被占用，在实例 0 和 2 上加锁成功
+	clients[1].Set(ctx, key, "other", 10*time.Second)
+	lock1 := NewRedlock(ctx, clients, 10*time.Second, "", key)
+	acquired, fence1, err := lock1.TryLockWithFence()
+	assert.NoError(t, err)
+	assert.True(t, acquired)
+	assert.Equal(t, int64(101), fence1)
+	assert.NoError(t, lock1.Unlock())
+	clients[1].Del(ctx, key)
+
+	// 实例 0 被占用，在实例 1 和 2 上加锁成功，token 仍需更大
+	clients[0].Set(ctx, key, "other", 10*time.Second)
+	lock2 := NewRedlock(ctx, clients, 10*time.Second, "", key)
+	acquired, fence2, err := lock2.TryLockWithFence()
+	assert.NoError(t, err)
+	assert.True(t, acquired)
+	assert.Greater(t, fence2, fence1)
+	assert.NoError(t, lock2.Unlock())
+	clients[0].Del(ctx, key)
+}
+
+// TestRedlock 测试 Redlock 模式（用同一 Redis 的不同 DB 模拟独立实例）
+// go test -v -run="TestRedlock"
+func TestRedlock(t *testing.T) {
+	ctx := context.Background()
+	getTestRedisClient(t).Close()
+
+	var clients []redis.UniversalClient
+	for db := 0; db < 3; db++ {
+		client := redis.NewClient(&redis.Options{Addr: "localhost:6379", DB: db})
+		defer client.Close()
+		clients = append(clients, client)
+	}
+
+	// 模拟一个实例故障
+	broken := redis.NewClient(&redis.Options{Addr: "localhost:6379", DB: 3})
+	broken.Close()
+	clients[2] = broken
+
+	lock1 := NewRedlock(ctx, clients, 10*time.Second, "", "test-redlock")
+	acquired, err := lock1.TryLock()
+	assert.NoError(t, err, "少数实例故障不应影响加锁")
+	assert.True(t, acquired)
+	validity := lock1.Validity()
+	assert.Greater(t, validity, time.Duration(0))
+	assert.LessOrEqual(t, validity, 10*time.Second)
+
+	// 其他持有者加锁失败，少数实例故障不返回错误，TimedLock 等待到超时
+	lock2 := NewRedlock(ctx, clients, 10*time.Second, "", "test-redlock")
+	acquired, err = lock2.TryLock()
+	assert.NoError(t, err)
+	assert.False(t, acquired)
+	startTime := time.Now()
+	acquired, err = lock2.TimedLock(300 * time.Millisecond)
+	assert.NoError(t, err)
+	assert.False(t, acquired)
+	assert.GreaterOrEqual(t, time.Since(startTime), 300*time.Millisecond)
+
+	lock1.Unlock()
+	assert.Equal(t, time.Duration(0), lock1.Validity())
+	acquired, _ = lock2.TryLock()
+	assert.True(t, acquired, "释放后应能再次获取")
+	lock2.Unlock()
+}
+
+// TestWatchdog 测试看门狗自动续期
+// go test -v -run="TestWatchdog"
+func TestWatchdog(t *testing.T) {
+	ctx := context.Background()
+	client := getTestRedisClient(t)
+	keys := getTestKeys()
+
+	lock := NewSimDistLock(ctx, client, 2*time.Second, "", keys, WithWatchdog(500*time.Millisecond, nil))
+	assert.Nil(t, lock.Lost(), "未加锁时应返回nil")
+	acquired, err := lock.TryLock()
+	assert.NoError(t, err)
+	assert.True(t, acquired)
+
+	// 超过过期时间后锁仍被持有
+	time.Sleep(3 * time.Second)
+	other := NewSimDistLock(ctx, client, 2*time.Second, "", keys)
+	acquired, err = other.TryLock()
+	assert.NoError(t, err)
+	assert.False(t, acquired, "看门狗应自动续期")
+	assert.Greater(t, lock.Validity(), time.Second)
+
+	// Unlock 后看门狗停止
+	assert.NoError(t, lock.Unlock())
+	acquired, err = other.TryLock()
+	assert.NoError(t, err)
+	assert.True(t, acquired)
+	assert.NoError(t, other.Unlock())
+}
+
+// TestWatchdog_Lost 测试续期失败时通知持有者
+// go test -v -run="TestWatchdog_Lost"
+func TestWatchdog_Lost(t *testing.T) {
+	ctx := context.Background()
+	client := getTestRedisClient(t)
+	keys := getTestKeys()
+
+	lostErr := make(chan error, 1)
+	lock := NewSimDistLock(ctx, client, 2*time.Second, "", keys, WithWatchdog(300*time.Millisecond, func(err error) {
+		lostErr <- err
+	}))
+	acquired, err := lock.TryLock()
+	assert.NoError(t, err)
+	assert.True(t, acquired)
+
+	// 模拟锁被删除
+	client.Del(ctx, keys...)
+	select {
+	case <-lock.Lost():
+	case <-time.After(time.Second):
+		t.Fatal("看门狗续期失败时应关闭Lost()")
+	}
+	assert.ErrorIs(t, <-lostErr, ErrLockLost)
+}
+
+// TestFairQueue 测试公平排队：等待者按先来先得的顺序获取锁
+// go test -v -run="TestFairQueue"
+func TestFairQueue(t *testing.T) {
+	ctx := context.Background()
+	client := getTestRedisClient(t)
+	keys := getTestKeys()
+
+	holder := NewSimDistLock(ctx, client, time.Minute, "", keys, WithFairQueue())
+	acquired, err := holder.TryLock()
+	assert.NoError(t, err)
+	assert.True(t, acquired)
+
+	var (
+		order []int
+		mu    sync.Mutex
+		wg    sync.WaitGroup
+	)
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			lock := NewSimDistLock(ctx, client, time.Minute, "", keys, WithFairQueue())
+			acquired, err := lock.TimedLock(5 * time.Second)
+			if !assert.NoError(t, err) || !assert.True(t, acquired) {
+				return
+			}
+			mu.Lock()
+			order = append(order, id)
+			mu.Unlock()
+			time.Sleep(50 * time.Millisecond)
+			assert.NoError(t, lock.Unlock())
+		}(i)
+		time.Sleep(100 * time.Millisecond) // 保证入队顺序
+	}
+
+	startTime := time.Now()
+	assert.NoError(t, holder.Unlock())
+	wg.Wait()
+	assert.Equal(t, []int{0, 1, 2}, order, "应按入队顺序获取锁")
+	assert.Less(t, time.Since(startTime), time.Second, "应通过释放通知及时唤醒，而非等待轮询")
+}
+
+// TestFairQueue_Timeout 测试公平排队严格按超时时间返回
+// go test -v -run="TestFairQueue_Timeout"
+func TestFairQueue_Timeout(t *testing.T) {
+	ctx := context.Background()
+	client := getTestRedisClient(t)
+	keys := getTestKeys()
+
+	holder := NewSimDistLock(ctx, client, time.Minute, "", keys)
+	acquired, err := holder.TryLock()
+	assert.NoError(t, err)
+	assert.True(t, acquired)
+	defer holder.Unlock()
+
+	lock := NewSimDistLock(ctx, client, time.Minute, "", keys, WithFairQueue())
+	startTime := time.Now()
+	acquired, err = lock.TimedLock(700 * time.Millisecond)
+	elapsed := time.Since(startTime)
+	assert.NoError(t, err)
+	assert.False(t, acquired)
+	assert.GreaterOrEqual(t, elapsed, 700*time.Millisecond)
+	assert.Less(t, elapsed, 800*time.Millisecond, "应在超时时间到期时立即返回")
+	assert.Equal(t, int64(0), client.Exists(ctx, lock.fairQueueKeys()[0]).Val(), "超时后应已出队")
+}
@@ -0,0 +1,157 @@
+// Package mooonredis
+// Wrote by yijian on 2026/10/18
+package mooonredis
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/eyjian/gomooon/mooonerror"
+	"github.com/go-redis/redis/v8"
+)
+
+// ErrLockLost 续期失败，锁已过期或被他人持有
+var ErrLockLost = mooonerror.NewError(mooonerror.ErrCodeRedisLockLost, "lock lost: failed to extend before expiration")
+
+var extendLockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	redis.call("PEXPIRE", KEYS[2], ARGV[2])
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// WithWatchdog 开启看门狗：加锁成功后每隔 interval 自动续期，直到 Unlock 或 ctx 被取消
+// interval 为 0 时取 expiration/3；开启看门狗后 expiration 可以设置得较短（如 10 秒），持有者崩溃后锁能较快释放
+// onLost 在续期失败（锁已丢失）时被调用，可为 nil；也可通过 Lost() 返回的 channel 感知
+func WithWatchdog(interval time.Duration, onLost func(err error)) SimDistLockOption {
+	return func(dl *SimDistLock) {
+		dl.watchdogInterval = interval
+		if dl.watchdogInterval <= 0 {
+			dl.watchdogInterval = dl.expiration / 3
+		}
+		dl.onLost = onLost
+	}
+}
+
+// Renew 对持有的锁续期（重置为 expiration）
+// 在 quorum 个 key 上续期成功返回 true, nil；锁已丢失返回 false, nil；出错且续期 key 数不足 quorum 时返回 false, err
+func (dl *SimDistLock) Renew() (bool, error) {
+	startTime := time.Now()
+	renewed, err := dl.renew()
+	dl.observe(LockOpRenew, startTime, renewed, false, 1, err)
+	return renewed, err
+}
+
+func (dl *SimDistLock) renew() (bool, error) {
+	var (
+		renewed int
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+	)
+	errs := make(chan error, len(dl.keys))
+	startTime := time.Now()
+
+	for i := range dl.keys {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			res, err := extendLockScript.Run(dl.ctx, dl.clients[i], []string{dl.keys[i], acquiredKeyOf(dl.keys[i])}, dl.value, dl.expiration.Milliseconds()).Int64()
+			if err != nil {
+				errs <- err
+				return
+			}
+			if res == 1 {
+				mu.Lock()
+				renewed++
+				mu.Unlock()
+			}
+		}(i)
+	}
+
+	wg.Wait()
+	close(errs)
+
+	drift := time.Duration(float64(dl.expiration)*dl.driftFactor) + 2*time.Millisecond
+	if renewed >= dl.quorum && dl.expiration-time.Since(startTime)-drift > 0 {
+		dl.mu.Lock()
+		dl.validUntil = startTime.Add(dl.expiration - drift)
+		dl.mu.Unlock()
+		return true, nil
+	}
+
+	var errorList []error
+	for err := range errs {
+		errorList = append(errorList, err)
+	}
+	if len(errorList) > 0 {
+		return false, mooonerror.Errorf(mooonerror.ErrCodeRedis, "errors while renewing lock: %v", errorList)
+	}
+	return false, nil
+}
+
+// Lost 返回一个在看门狗续期失败时被关闭的 channel，持有者应据此中止临界区操作
+// 未开启看门狗或未持有锁时返回 nil（从 nil channel 读取会一直阻塞）
+func (dl *SimDistLock) Lost() <-chan struct{} {
+	dl.mu.Lock()
+	defer dl.mu.Unlock()
+	return dl.lost
+}
+
+// startWatchdog 启动看门狗协程
+func (dl *SimDistLock) startWatchdog() {
+	dl.stopWatchdog()
+
+	ctx, cancel := context.WithCancel(dl.ctx)
+	lost := make(chan struct{})
+	dl.mu.Lock()
+	dl.watchdogCancel = cancel
+	dl.lost = lost
+	dl.mu.Unlock()
+
+	go dl.watchdog(ctx, lost)
+}
+
+// stopWatchdog 停止看门狗协程
+func (dl *SimDistLock) stopWatchdog() {
+	dl.mu.Lock()
+	defer dl.mu.Unlock()
+	if dl.watchdogCancel != nil {
+		dl.watchdogCancel()
+		dl.watchdogCancel = nil
+	}
+}
+
+// watchdog 定期续期，续期失败时关闭 lost 并回调 onLost
+func (dl *SimDistLock) watchdog(ctx context.Context, lost chan struct{}) {
+	ticker := time.NewTicker(dl.watchdogInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		ok, err := dl.Renew()
+		if ok || ctx.Err() != nil {
+			continue
+		}
+		// 网络等错误时锁可能仍然有效，在下一次续期前不会过期则等待下一次续期
+		if err != nil && dl.Validity() > dl.watchdogInterval {
+			continue
+		}
+		if err == nil {
+			err = ErrLockLost
+		}
+
+		close(lost)
+		if dl.onLost != nil {
+			dl.onLost(err)
+		}
+		return
+	}
+}
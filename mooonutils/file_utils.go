@@ -8,6 +8,7 @@ import (
 	"crypto/md5"
 	"encoding/hex"
 	"fmt"
+	"github.com/eyjian/gomooon/mooonerror"
 	"golang.org/x/text/encoding/simplifiedchinese"
 	"golang.org/x/text/transform"
 	"io"
@@ -119,6 +120,7 @@ func CopyFile(src, dst string, overwrite bool) error {
 // options[0]：是否覆盖解压后的同名文件，默认是 true
 // options[1]：返回结果是否忽略目录，仅包含文件，默认是 true
 // destDir：解压后文件的存放目录，如果不存在会自动创建
+// 出错时返回的 error 可通过 errors.As 转换为 *mooonerror.CError，错误码为 ErrCodeZipOpen 或 ErrCodeZipExtract
 func Unzip(zipFile, destDir string, options ...bool) ([]string, error) {
 	// 设置默认值
 	overwrite := true
@@ -132,7 +134,7 @@ func Unzip(zipFile, destDir string, options ...bool) ([]string, error) {
 
 	reader, err := zip.OpenReader(zipFile)
 	if err != nil {
-		return nil, fmt.Errorf("open zipfile://%s error: %s", zipFile, err.Error())
+		return nil, mooonerror.Errorf(mooonerror.ErrCodeZipOpen, "open zipfile://%s error: %w", zipFile, err)
 	}
 	defer reader.Close()
 
@@ -161,7 +163,7 @@ func Unzip(zipFile, destDir string, options ...bool) ([]string, error) {
 			paths = append(paths, path)
 			dirPath := filepath.Dir(path)
 			if err = os.MkdirAll(dirPath, os.ModePerm); err != nil {
-				return nil, fmt.Errorf("open dir://%s error: %s", dirPath, err.Error())
+				return nil, mooonerror.Errorf(mooonerror.ErrCodeZipExtract, "open dir://%s error: %w", dirPath, err)
 			}
 
 			flag := os.O_WRONLY | os.O_CREATE
@@ -170,14 +172,14 @@ func Unzip(zipFile, destDir string, options ...bool) ([]string, error) {
 			}
 			outFile, err := os.OpenFile(path, flag, file.Mode())
 			if err != nil {
-				return nil, fmt.Errorf("open outfile://%s with flag://0x%x error: %s", path, flag, err.Error())
+				return nil, mooonerror.Errorf(mooonerror.ErrCodeZipExtract, "open outfile://%s with flag://0x%x error: %w", path, flag, err)
 			}
 
 			rc, err := file.Open()
 			if err != nil {
 				outFile.Close()
 				os.Remove(path)
-				return nil, fmt.Errorf("open infile://%s error: %s", file.Name, err.Error())
+				return nil, mooonerror.Errorf(mooonerror.ErrCodeZipExtract, "open infile://%s error: %w", file.Name, err)
 			}
 
 			_, err = io.Copy(outFile, rc)
@@ -188,7 +190,7 @@ func Unzip(zipFile, destDir string, options ...bool) ([]string, error) {
 				if _, err := os.Stat(path); !os.IsNotExist(err) {
 					os.Remove(path)
 				}
-				return nil, fmt.Errorf("copy infile://%s to outfile://%s error: %s", file.Name, path, err.Error())
+				return nil, mooonerror.Errorf(mooonerror.ErrCodeZipExtract, "copy infile://%s to outfile://%s error: %w", file.Name, path, err)
 			}
 		}
 	}
@@ -199,10 +201,11 @@ func Unzip(zipFile, destDir string, options ...bool) ([]string, error) {
 // ZipFiles 压缩指定文件到ZIP
 // zipFilePath: 生成的ZIP文件路径
 // srcFilePaths: 需要压缩的文件路径列表
+// 出错时返回的 error 可通过 errors.As 转换为 *mooonerror.CError，错误码为 ErrCodeZipCreate
 func ZipFiles(zipFilePath string, srcFilePaths []string) error {
 	zipFile, err := os.Create(zipFilePath)
 	if err != nil {
-		return err
+		return mooonerror.Wrap(mooonerror.ErrCodeZipCreate, err)
 	}
 	defer zipFile.Close()
 
@@ -211,7 +214,7 @@ func ZipFiles(zipFilePath string, srcFilePaths []string) error {
 
 	for _, srcPath := range srcFilePaths {
 		if err := addFileToZip(zw, srcPath, ""); err != nil {
-			return err
+			return mooonerror.Wrap(mooonerror.ErrCodeZipCreate, err)
 		}
 	}
 	return nil
@@ -223,7 +226,7 @@ func ZipFiles(zipFilePath string, srcFilePaths []string) error {
 func ZipDir(zipFilePath, srcDir string) error {
 	srcFilePaths, err := filepath.Glob(filepath.Join(srcDir, "*"))
 	if err != nil {
-		return err
+		return mooonerror.Wrap(mooonerror.ErrCodeZipCreate, err)
 	}
 	return ZipFiles(zipFilePath, srcFilePaths)
 }
@@ -234,7 +237,7 @@ func ZipDir(zipFilePath, srcDir string) error {
 func ZipDirEx(zipFilePath, srcDir string) error {
 	zipFile, err := os.Create(zipFilePath)
 	if err != nil {
-		return err
+		return mooonerror.Wrap(mooonerror.ErrCodeZipCreate, err)
 	}
 	defer zipFile.Close()
 
@@ -242,7 +245,7 @@ func ZipDirEx(zipFilePath, srcDir string) error {
 	defer zw.Close()
 
 	basePath, _ := filepath.Abs(srcDir)
-	return filepath.Walk(srcDir, func(path string, info os.FileInfo, err error) error {
+	err = filepath.Walk(srcDir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
@@ -260,6 +263,7 @@ func ZipDirEx(zipFilePath, srcDir string) error {
 		// 添加文件
 		return addFileToZip(zw, path, relPath)
 	})
+	return mooonerror.Wrap(mooonerror.ErrCodeZipCreate, err)
 }
 
 func addFileToZip(zw *zip.Writer, srcPath, relPath string) error {
@@ -5,10 +5,8 @@ package mooonwepay
 import (
 	"context"
 	"crypto/rsa"
-	"encoding/json"
 	"fmt"
-	"github.com/eyjian/gomooon/moooncrypto"
-	"io"
+	"github.com/eyjian/gomooon/mooonerror"
 	"net/http"
 )
 
@@ -53,10 +51,7 @@ type ApplyBillResp struct {
 	HashValue   string `json:"hash_value,omitempty"`   // 用于校验文件的完整性
 	DownloadUrl string `json:"download_url,omitempty"` // 5 分钟内有效（下载后为 gzip 压缩过的 csv 文件）
 
-	Code    string `json:"code,omitempty"`
-	Message string `json:"message,omitempty"`
-
-	HttpStatusCode int `json:"http_status_code,omitempty"`
+	WepayStatus
 }
 
 var (
@@ -92,88 +87,38 @@ func IsFundBill(billType string) bool {
 }
 
 // ApplyBill 申请交易账单
+// 仍保留以兼容旧的调用方式，新代码请使用 Client.ApplyBill
 func ApplyBill(req *ApplyBillReq) (*ApplyBillResp, error) {
-	ctx := req.Ctx
-	url := getApplyBillUrl(req)
+	client := newLegacyClient(req.HttpClient, req.PrivateKey, req.Host, req.Mchid, req.SerialNo, req.NonceStr, req.Timestamp)
+	return client.ApplyBill(req.Ctx, req.BillType, req.CompressionType, req.Date)
+}
 
+// ApplyBill 申请交易账单或资金账单，billType 取值参见 ApplyBillReq.BillType
+// compressionType 为压缩类型，目前仅支持取值 GZIP；date 为账单日期（格式：yyyy-MM-DD，仅支持三个月内的账单下载申请）
+func (c *Client) ApplyBill(ctx context.Context, billType, compressionType, date string) (*ApplyBillResp, error) {
 	// 检查传入的账单类型
-	if !IsTradeBill(req.BillType) && !IsFundBill(req.BillType) {
-		return nil, fmt.Errorf("%s: bill type unsupported: %s", applyBillErrTag, req.BillType)
-	}
-
-	// 计算签名
-	signatureString := makeApplyBillSignatureString(req)
-	signature, err := moooncrypto.RsaSha256SignWithPrivateKey(req.PrivateKey, []byte(signatureString))
-	if err != nil {
-		return nil, fmt.Errorf("%s: rsa sha256 sign error: %s", applyBillErrTag, err.Error())
-	}
-
-	// 生成 Authorization
-	authorization := makeChangeBillAuthorization(req.Mchid, req.SerialNo, req.NonceStr, signature, req.Timestamp)
-
-	// 构建请求
-	httpReq, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("%s: new http request error: %s", applyBillErrTag, err.Error())
-	}
-
-	// 设置请求头
-	httpReq.Header.Set("Authorization", authorization)
-	httpReq.Header.Set("Accept", "application/json")
-	httpReq.Header.Set("Content-Type", "application/json")
-	httpResp, err := req.HttpClient.Do(httpReq)
-	if err != nil {
-		return nil, fmt.Errorf("%s: do http request error: %s", applyBillErrTag, err.Error())
+	if !IsTradeBill(billType) && !IsFundBill(billType) {
+		return nil, mooonerror.Errorf(mooonerror.ErrCodeInvalidParam, "%s: bill type unsupported: %s", applyBillErrTag, billType)
 	}
-	defer httpResp.Body.Close()
 
-	// 读取响应
-	resp := &ApplyBillResp{
-		HttpStatusCode: httpResp.StatusCode,
-	}
-	respBodyBytes, err := io.ReadAll(httpResp.Body)
+	resp := &ApplyBillResp{}
+	url := getApplyBillUrl(c.host, billType, compressionType, date)
+	err := c.doJSON(ctx, http.MethodGet, url, "", applyBillErrTag, resp, &resp.WepayStatus)
 	if err != nil {
-		return nil, fmt.Errorf("%s: read http body error: %s", applyBillErrTag, err.Error())
-	}
-
-	// 解析响应
-	err = json.Unmarshal(respBodyBytes, resp)
-	if httpResp.StatusCode != http.StatusOK {
-		if httpResp.StatusCode == http.StatusUnauthorized {
-			return resp, fmt.Errorf("%s: unauthorized, possible authorization incorrect", applyBillErrTag)
-		} else {
-			return resp, fmt.Errorf("%s: http response %d", applyBillErrTag, httpResp.StatusCode)
+		if isWepayResponseError(err) {
+			return resp, err
 		}
+		return nil, err
 	}
-	if err != nil {
-		return nil, fmt.Errorf("%s: json unmarshal http response error: %s\n", applyBillErrTag, err.Error())
-	}
-
 	return resp, nil
 }
 
-// makeApplyBillSignatureString 生成签名串
-//HTTP请求方法\n
-//URL\n
-//请求时间戳\n
-//请求随机串\n
-//请求报文主体\n
-func makeApplyBillSignatureString(req *ApplyBillReq) string {
-	if IsTradeBill(req.BillType) {
-		return fmt.Sprintf("GET\n%s?bill_date=%s&bill_type=%s&tar_type=%s\n%d\n%s\n\n",
-			ApplyTradeBillPath, req.Date, req.BillType, req.CompressionType, req.Timestamp, req.NonceStr)
-	} else {
-		return fmt.Sprintf("GET\n%s?bill_date=%s&bill_type=%s&tar_type=%s\n%d\n%s\n\n",
-			ApplyFundBillPath, req.Date, req.BillType, req.CompressionType, req.Timestamp, req.NonceStr)
-	}
-}
-
-func getApplyBillUrl(req *ApplyBillReq) string {
-	if IsTradeBill(req.BillType) {
+func getApplyBillUrl(host, billType, compressionType, date string) string {
+	if IsTradeBill(billType) {
 		return fmt.Sprintf("%s%s?bill_date=%s&bill_type=%s&tar_type=%s",
-			req.Host, ApplyTradeBillPath, req.Date, req.BillType, req.CompressionType)
+			host, ApplyTradeBillPath, date, billType, compressionType)
 	} else {
 		return fmt.Sprintf("%s%s?bill_date=%s&bill_type=%s&tar_type=%s",
-			req.Host, ApplyFundBillPath, req.Date, req.BillType, req.CompressionType)
+			host, ApplyFundBillPath, date, billType, compressionType)
 	}
 }
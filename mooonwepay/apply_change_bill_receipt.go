@@ -3,13 +3,9 @@
 package mooonwepay
 
 import (
-	"bytes"
 	"context"
 	"crypto/rsa"
-	"encoding/json"
 	"fmt"
-	"github.com/eyjian/gomooon/moooncrypto"
-	"io"
 	"net/http"
 )
 
@@ -49,102 +45,46 @@ type ApplyChangeBillReceiptResp struct {
 	CreateTime      string `json:"create_time,omitempty"`      // 电子签章单创建时间，按照使用 rfc3339 所定义的格式，格式为 YYYY-MM-DDThh:mm:ss+TIMEZONE
 	UpdateTime      string `json:"update_time,omitempty"`      // 电子签章单最近一次状态变更的时间，按照使用 rfc3339 所定义的格式，格式为 YYYY-MM-DDThh:mm:ss+TIMEZONE
 
-	Code    string `json:"code,omitempty"`
-	Message string `json:"message,omitempty"`
-
-	HttpStatusCode int `json:"http_status_code,omitempty"`
+	WepayStatus
 }
 
 // ApplyChangeBillReceipt 转账电子回单申请受理
+// 仍保留以兼容旧的调用方式，新代码请使用 Client.ApplyChangeBillReceipt
 func ApplyChangeBillReceipt(req *ApplyChangeBillReceiptReq) (*ApplyChangeBillReceiptResp, error) {
-	ctx := req.Ctx
-	url := getApplyChangeBillReceiptUrl(req)
-	httpReqBody := getApplyChangeBillReceiptRequestBody(req)
-
-	// 计算签名
-	signatureString := makeApplyChangeBillReceiptSignatureString(req, httpReqBody)
-	signature, err := moooncrypto.RsaSha256SignWithPrivateKey(req.PrivateKey, []byte(signatureString))
-	if err != nil {
-		return nil, fmt.Errorf("%s: rsa sha256 sign error: %s", applyChangeBillReceiptErrTag, err.Error())
-	}
-
-	// 生成 Authorization
-	authorization := makeChangeBillAuthorization(req.Mchid, req.SerialNo, req.NonceStr, signature, req.Timestamp)
-
-	// 构建请求
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer([]byte(httpReqBody)))
-	if err != nil {
-		return nil, fmt.Errorf("%s: new http request error: %s", applyChangeBillReceiptErrTag, err.Error())
-	}
-
-	// 设置请求头
-	httpReq.Header.Set("Authorization", authorization)
-	httpReq.Header.Set("Accept", "application/json")
-	httpReq.Header.Set("Content-Type", "application/json")
-
-	// 发送请求
-	httpResp, err := req.HttpClient.Do(httpReq)
-	if err != nil {
-		return nil, fmt.Errorf("%s: do http request error: %s", applyChangeBillReceiptErrTag, err.Error())
-	}
-	defer httpResp.Body.Close()
+	client := newLegacyClient(req.HttpClient, req.PrivateKey, req.Host, req.Mchid, req.SerialNo, req.NonceStr, req.Timestamp)
+	return client.ApplyChangeBillReceipt(req.Ctx, req.OutBatchNo, req.OutDetailNo, req.AcceptType)
+}
 
-	// 读取响应
-	resp := &ApplyChangeBillReceiptResp{
-		HttpStatusCode: httpResp.StatusCode,
-	}
-	respBodyBytes, err := io.ReadAll(httpResp.Body)
+// ApplyChangeBillReceipt 转账电子回单申请受理
+// outBatchNo 为商家转账批次单号；outDetailNo 和 acceptType 均不为空时表示单笔转账电子回单，取值参见 ApplyChangeBillReceiptReq
+func (c *Client) ApplyChangeBillReceipt(ctx context.Context, outBatchNo, outDetailNo, acceptType string) (*ApplyChangeBillReceiptResp, error) {
+	resp := &ApplyChangeBillReceiptResp{}
+	url := getApplyChangeBillReceiptUrl(c.host, outDetailNo, acceptType)
+	httpReqBody := getApplyChangeBillReceiptRequestBody(outBatchNo, outDetailNo, acceptType)
+	err := c.doJSON(ctx, http.MethodPost, url, httpReqBody, applyChangeBillReceiptErrTag, resp, &resp.WepayStatus)
 	if err != nil {
-		return nil, fmt.Errorf("%s: read http body error: %s", applyChangeBillReceiptErrTag, err.Error())
-	}
-	//fmt.Printf("http response body: %s\n", string(respBodyBytes))
-
-	// 解析响应
-	err = json.Unmarshal(respBodyBytes, resp)
-	if httpResp.StatusCode != http.StatusOK {
-		// {"code":"RESOURCE_ALREADY_EXISTS","message":"该批次回单已申请，您可在通过查询电子回单接口来获取单据信息"}
-		if httpResp.StatusCode == http.StatusUnauthorized {
-			return resp, fmt.Errorf("%s: unauthorized, possible authorization incorrect or out_batch_no error", applyChangeBillReceiptErrTag)
-		} else {
-			return resp, fmt.Errorf("%s: http response %d", applyChangeBillReceiptErrTag, httpResp.StatusCode)
+		if isWepayResponseError(err) {
+			return resp, err
 		}
+		return nil, err
 	}
-	if err != nil {
-		return nil, fmt.Errorf("%s: json unmarshal http response error: %s\n", applyChangeBillReceiptErrTag, err.Error())
-	}
-
 	return resp, nil
 }
 
-// MakeApplyBillReceiptSignatureString 生成签名串
-//HTTP请求方法\n
-//URL\n
-//请求时间戳\n
-//请求随机串\n
-//请求报文主体\n
-func makeApplyChangeBillReceiptSignatureString(req *ApplyChangeBillReceiptReq, httpReqBody string) string {
-	//return fmt.Sprintf(`POST\n%s\n%d\n%s\n%s\n`, GetBillPath, timestamp, nonceStr, httpReqBody) // 不可以
-	if req.AcceptType == "" || req.OutDetailNo == "" {
-		return fmt.Sprintf("POST\n%s\n%d\n%s\n%s\n", ApplyChangeBillBatchReceiptPath, req.Timestamp, req.NonceStr, httpReqBody)
-	} else {
-		return fmt.Sprintf("POST\n%s\n%d\n%s\n%s\n", ApplyChangeBillDetailReceiptPath, req.Timestamp, req.NonceStr, httpReqBody)
-	}
-}
-
-func getApplyChangeBillReceiptUrl(req *ApplyChangeBillReceiptReq) string {
-	if req.AcceptType == "" || req.OutDetailNo == "" {
-		return req.Host + ApplyChangeBillBatchReceiptPath
+func getApplyChangeBillReceiptUrl(host, outDetailNo, acceptType string) string {
+	if acceptType == "" || outDetailNo == "" {
+		return host + ApplyChangeBillBatchReceiptPath
 	} else {
-		return req.Host + ApplyChangeBillDetailReceiptPath
+		return host + ApplyChangeBillDetailReceiptPath
 	}
 }
 
-func getApplyChangeBillReceiptRequestBody(req *ApplyChangeBillReceiptReq) string {
-	if req.AcceptType == "" || req.OutDetailNo == "" {
+func getApplyChangeBillReceiptRequestBody(outBatchNo, outDetailNo, acceptType string) string {
+	if acceptType == "" || outDetailNo == "" {
 		return fmt.Sprintf(`{"out_batch_no":"%s"}`,
-			req.OutBatchNo)
+			outBatchNo)
 	} else {
 		return fmt.Sprintf(`{"accept_type":"%s","out_batch_no":"%s","out_detail_no":"%s"}`,
-			req.AcceptType, req.OutBatchNo, req.OutDetailNo)
+			acceptType, outBatchNo, outDetailNo)
 	}
 }
@@ -5,9 +5,10 @@ package mooonwepay
 import (
 	"context"
 	"encoding/json"
-	"fmt"
 	"io"
+	"net/http"
 
+	"github.com/eyjian/gomooon/mooonerror"
 	"github.com/wechatpay-apiv3/wechatpay-go/core"
 )
 
@@ -24,51 +25,75 @@ type ApplyReceiptResponse struct {
 	CreateTime string `json:"create_time"`
 }
 
+var (
+	ApplyReceiptByOutBillNoPath      = "/v3/fund-app/mch-transfer/elecsign/out-bill-no"      // 按商户单号申请电子回单
+	ApplyReceiptByTransferBillNoPath = "/v3/fund-app/mch-transfer/elecsign/transfer-bill-no" // 按微信单号申请电子回单
+	applyReceiptErrTag               = "ApplyReceipt failed to apply electronic receipt"
+)
+
 // ApplyReceipt 申请电子回单
 func ApplyReceipt(client *core.Client, req *ApplyReceiptRequest) (*ApplyReceiptResponse, error) {
-	// 定义请求 URL
-	var url string
-	if req.OutBillNo != "" {
-		url = "https://api.mch.weixin.qq.com/v3/fund-app/mch-transfer/elecsign/out-bill-no"
-	} else if req.WepayBillNo != "" {
-		url = "https://api.mch.weixin.qq.com/v3/fund-app/mch-transfer/elecsign/transfer-bill-no"
-	} else {
-		return nil, fmt.Errorf("ApplyReceipt with invalid request: out_bill_no and wepay_bill_no are both empty")
-	}
-
-	// 构建请求体
-	requestBody := map[string]string{}
-	if req.OutBillNo != "" {
-		requestBody["out_bill_no"] = req.OutBillNo
-	} else if req.WepayBillNo != "" {
-		requestBody["transfer_bill_no"] = req.WepayBillNo
-	}
-
-	// 将请求体转换为 JSON 格式
-	bodyBytes, err := json.Marshal(requestBody)
+	path, bodyBytes, err := getApplyReceiptPathAndBody(req)
 	if err != nil {
-		return nil, fmt.Errorf("ApplyReceipt failed to marshal request body: %w", err)
+		return nil, err
 	}
 
 	// 发送 POST 请求
-	apiResult, err := client.Post(req.Ctx, url, bodyBytes)
+	apiResult, err := client.Post(req.Ctx, PrimaryHost+path, bodyBytes)
 	if err != nil {
-		return nil, fmt.Errorf("ApplyReceipt failed to apply electronic receipt: %w", err)
+		return nil, newCoreError(mooonerror.ErrCodeWepayRequest, applyReceiptErrTag, err)
 	}
 
 	// 读取响应体内容
 	defer apiResult.Response.Body.Close()
 	respBody, err := io.ReadAll(apiResult.Response.Body)
 	if err != nil {
-		return nil, fmt.Errorf("ApplyReceipt failed to read response body: %w", err)
+		return nil, mooonerror.Errorf(mooonerror.ErrCodeWepayDecode, "ApplyReceipt failed to read response body: %w", err)
 	}
 
 	// 解析响应
 	var resp ApplyReceiptResponse
 	if err := json.Unmarshal(respBody, &resp); err != nil {
-		return nil, fmt.Errorf("ApplyReceipt failed to unmarshal response: %w", err)
+		return nil, mooonerror.Errorf(mooonerror.ErrCodeWepayDecode, "ApplyReceipt failed to unmarshal response: %w", err)
 	}
 
 	// 返回响应
 	return &resp, nil
-}
\ No newline at end of file
+}
+
+// ApplyReceipt 申请电子回单，同 ApplyReceipt 函数，但使用 Client 的商户号和私钥签名
+func (c *Client) ApplyReceipt(req *ApplyReceiptRequest) (*ApplyReceiptResponse, error) {
+	path, bodyBytes, err := getApplyReceiptPathAndBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp ApplyReceiptResponse
+	if err := c.doJSON(req.Ctx, http.MethodPost, c.host+path, string(bodyBytes), applyReceiptErrTag, &resp, &WepayStatus{}); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// getApplyReceiptPathAndBody 取得申请电子回单的请求路径和请求体
+func getApplyReceiptPathAndBody(req *ApplyReceiptRequest) (string, []byte, error) {
+	// 定义请求路径和请求体
+	var path string
+	requestBody := map[string]string{}
+	if req.OutBillNo != "" {
+		path = ApplyReceiptByOutBillNoPath
+		requestBody["out_bill_no"] = req.OutBillNo
+	} else if req.WepayBillNo != "" {
+		path = ApplyReceiptByTransferBillNoPath
+		requestBody["transfer_bill_no"] = req.WepayBillNo
+	} else {
+		return "", nil, mooonerror.Errorf(mooonerror.ErrCodeInvalidParam, "ApplyReceipt with invalid request: out_bill_no and wepay_bill_no are both empty")
+	}
+
+	// 将请求体转换为 JSON 格式
+	bodyBytes, err := json.Marshal(requestBody)
+	if err != nil {
+		return "", nil, mooonerror.Errorf(mooonerror.ErrCodeWepayRequest, "ApplyReceipt failed to marshal request body: %w", err)
+	}
+	return path, bodyBytes, nil
+}
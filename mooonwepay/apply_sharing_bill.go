@@ -5,14 +5,9 @@ package mooonwepay
 import (
 	"context"
 	"crypto/rsa"
-	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 )
-import (
-	"github.com/eyjian/gomooon/moooncrypto"
-)
 
 var (
 	ApplySharingBillPath   = "/v3/profitsharing/bills" // 分账账单
@@ -39,80 +34,32 @@ type ApplySharingBillResp struct {
 	HashValue   string `json:"hash_value,omitempty"`   // 用于校验文件的完整性
 	DownloadUrl string `json:"download_url,omitempty"` // 5 分钟内有效（下载后为 gzip 压缩过的 csv 文件）
 
-	Code    string `json:"code,omitempty"`
-	Message string `json:"message,omitempty"`
-
-	HttpStatusCode int `json:"http_status_code,omitempty"`
+	WepayStatus
 }
 
 // ApplySharingBill 申请分账账单
+// 仍保留以兼容旧的调用方式，新代码请使用 Client.ApplySharingBill
 func ApplySharingBill(req *ApplySharingBillReq) (*ApplySharingBillResp, error) {
-	ctx := req.Ctx
-	url := getApplySharingBillUrl(req)
-
-	// 计算签名
-	signatureString := makeApplySharingBillSignatureString(req)
-	signature, err := moooncrypto.RsaSha256SignWithPrivateKey(req.PrivateKey, []byte(signatureString))
-	if err != nil {
-		return nil, fmt.Errorf("%s: rsa sha256 sign error: %s", applySharingBillErrTag, err.Error())
-	}
-
-	// 生成 Authorization
-	authorization := makeChangeBillAuthorization(req.Mchid, req.SerialNo, req.NonceStr, signature, req.Timestamp)
-
-	// 构建请求
-	httpReq, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("%s: new http request error: %s", applySharingBillErrTag, err.Error())
-	}
-
-	// 设置请求头
-	httpReq.Header.Set("Authorization", authorization)
-	httpReq.Header.Set("Accept", "application/json")
-	httpReq.Header.Set("Content-Type", "application/json")
-	httpResp, err := req.HttpClient.Do(httpReq)
-	if err != nil {
-		return nil, fmt.Errorf("%s: do http request error: %s", applySharingBillErrTag, err.Error())
-	}
-	defer httpResp.Body.Close()
+	client := newLegacyClient(req.HttpClient, req.PrivateKey, req.Host, req.Mchid, req.SerialNo, req.NonceStr, req.Timestamp)
+	return client.ApplySharingBill(req.Ctx, req.CompressionType, req.Date)
+}
 
-	// 读取响应
-	resp := &ApplySharingBillResp{
-		HttpStatusCode: httpResp.StatusCode,
-	}
-	respBodyBytes, err := io.ReadAll(httpResp.Body)
+// ApplySharingBill 申请分账账单
+// compressionType 为压缩类型，目前仅支持取值 GZIP；date 为账单日期（格式：yyyy-MM-DD，仅支持三个月内的账单下载申请）
+func (c *Client) ApplySharingBill(ctx context.Context, compressionType, date string) (*ApplySharingBillResp, error) {
+	resp := &ApplySharingBillResp{}
+	url := getApplySharingBillUrl(c.host, compressionType, date)
+	err := c.doJSON(ctx, http.MethodGet, url, "", applySharingBillErrTag, resp, &resp.WepayStatus)
 	if err != nil {
-		return nil, fmt.Errorf("%s: read http body error: %s", applySharingBillErrTag, err.Error())
-	}
-
-	// 解析响应
-	err = json.Unmarshal(respBodyBytes, resp)
-	if httpResp.StatusCode != http.StatusOK {
-		if httpResp.StatusCode == http.StatusUnauthorized {
-			return resp, fmt.Errorf("%s: unauthorized, possible authorization incorrect", applySharingBillErrTag)
-		} else {
-			return resp, fmt.Errorf("%s: http response %d", applySharingBillErrTag, httpResp.StatusCode)
+		if isWepayResponseError(err) {
+			return resp, err
 		}
+		return nil, err
 	}
-	if err != nil {
-		return nil, fmt.Errorf("%s: json unmarshal http response error: %s\n", applySharingBillErrTag, err.Error())
-	}
-
 	return resp, nil
 }
 
-// makeApplySharingBillSignatureString 生成签名串
-//HTTP请求方法\n
-//URL\n
-//请求时间戳\n
-//请求随机串\n
-//请求报文主体\n
-func makeApplySharingBillSignatureString(req *ApplySharingBillReq) string {
-	return fmt.Sprintf("GET\n%s?bill_date=%s&tar_type=%s\n%d\n%s\n\n",
-		ApplyFundBillPath, req.Date, req.CompressionType, req.Timestamp, req.NonceStr)
-}
-
-func getApplySharingBillUrl(req *ApplySharingBillReq) string {
+func getApplySharingBillUrl(host, compressionType, date string) string {
 	return fmt.Sprintf("%s%s?bill_date=%s&tar_type=%s",
-		req.Host, ApplyFundBillPath, req.Date, req.CompressionType)
+		host, ApplyFundBillPath, date, compressionType)
 }
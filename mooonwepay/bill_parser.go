@@ -0,0 +1,297 @@
+// Package mooonwepay
+// Wrote by yijian on 2026/10/18
+package mooonwepay
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/csv"
+	"hash"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/eyjian/gomooon/mooonerror"
+)
+
+// 微信支付账单文件的格式：
+// 1. 第一行为表头，之后每行一条记录，记录的每个字段以反引号（`）开头，防止被 Excel 转换格式
+// 2. 记录之后为汇总：一行汇总表头（不以反引号开头）和一行汇总数据
+// 3. 同一种账单的列随账单类型变化（如交易账单的 ALL、SUCCESS 和 REFUND），故按表头名取字段，不按列序号
+// 4. 金额单位为元，保留两位小数，解析为以分为单位的 int64；时间为北京时间，格式为 2006-01-02 15:04:05
+
+// billLocation 账单中时间的时区
+var billLocation = time.FixedZone("CST", 8*3600)
+
+const billTimeLayout = "2006-01-02 15:04:05"
+
+// BillParserOption 账单解析器的可选项
+type BillParserOption func(*billParser)
+
+// WithBillHash 设置账单文件的摘要，取自 ApplyBill 或 ApplySharingBill 应答的 HashType 和 HashValue
+// 摘要按解压后的原始账单计算，读完所有记录时校验，不匹配时返回 ErrCodeWepayHashMismatch 错误而非 io.EOF
+// hashType 支持 SHA1、SHA256 和 SM3（微信支付的账单目前仅有 SHA1），为空表示不校验
+func WithBillHash(hashType, hashValue string) BillParserOption {
+	return func(p *billParser) {
+		p.hashType = strings.ToUpper(hashType)
+		p.hashValue = hashValue
+	}
+}
+
+// billParser 各种账单共用的流式解析器
+type billParser struct {
+	reader  *csv.Reader
+	closer  io.Closer // gzip 压缩时为 *gzip.Reader
+	header  map[string]int
+	line    int
+	summary *billRow // 汇总，未读到时为 nil
+	done    bool
+
+	hashType  string
+	hashValue string
+	hasher    hash.Hash
+}
+
+// newBillParser 生成解析器并读取表头，r 为 gzip 压缩的账单时自动解压
+func newBillParser(r io.Reader, opts ...BillParserOption) (*billParser, error) {
+	p := &billParser{}
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	// 以 gzip 的魔数判断是否压缩
+	br := bufio.NewReader(r)
+	var src io.Reader = br
+	if magic, err := br.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, mooonerror.Errorf(mooonerror.ErrCodeWepayDecode, "gunzip bill error: %w", err)
+		}
+		p.closer = gz
+		src = gz
+	}
+
+	hasher, err := newHasher(p.hashType)
+	if err != nil {
+		return nil, err
+	}
+	if hasher != nil {
+		p.hasher = hasher
+		src = io.TeeReader(src, hasher)
+	}
+
+	p.reader = csv.NewReader(src)
+	p.reader.FieldsPerRecord = -1
+	p.reader.LazyQuotes = true
+	p.reader.ReuseRecord = false
+
+	// 读取表头
+	fields, err := p.read()
+	if err != nil {
+		if err == io.EOF {
+			return nil, mooonerror.NewError(mooonerror.ErrCodeWepayDecode, "empty bill")
+		}
+		return nil, err
+	}
+	p.header = make(map[string]int, len(fields))
+	for i, name := range fields {
+		p.header[trimBillField(name)] = i
+	}
+	return p, nil
+}
+
+// Close 释放解压使用的资源，不关闭传入的 io.Reader
+func (p *billParser) Close() error {
+	if p.closer != nil {
+		return p.closer.Close()
+	}
+	return nil
+}
+
+// read 读取一行，跳过空行
+func (p *billParser) read() ([]string, error) {
+	for {
+		fields, err := p.reader.Read()
+		if err != nil {
+			if err == io.EOF {
+				return nil, io.EOF
+			}
+			return nil, mooonerror.Errorf(mooonerror.ErrCodeWepayDecode, "read bill line %d error: %w", p.line+1, err)
+		}
+		p.line++
+		if p.line == 1 && len(fields) > 0 {
+			fields[0] = strings.TrimPrefix(fields[0], "\ufeff") // 去掉 UTF-8 BOM
+		}
+		if len(fields) == 1 && strings.TrimSpace(fields[0]) == "" {
+			continue
+		}
+		return fields, nil
+	}
+}
+
+// next 读取下一条记录，读完所有记录后解析汇总、校验摘要并返回 io.EOF
+func (p *billParser) next() (*billRow, error) {
+	if p.done {
+		return nil, io.EOF
+	}
+	fields, err := p.read()
+	if err == io.EOF {
+		p.done = true
+		return nil, p.finish()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// 记录的字段以反引号开头，否则为汇总表头
+	if !strings.HasPrefix(fields[0], "`") {
+		p.done = true
+		if err := p.readSummary(fields); err != nil {
+			return nil, err
+		}
+		return nil, p.finish()
+	}
+	return &billRow{header: p.header, fields: fields, line: p.line}, nil
+}
+
+// readSummary 读取汇总表头之后的汇总数据
+func (p *billParser) readSummary(summaryHeader []string) error {
+	fields, err := p.read()
+	if err == io.EOF {
+		return mooonerror.Errorf(mooonerror.ErrCodeWepayDecode, "bill summary data missing after line %d", p.line)
+	}
+	if err != nil {
+		return err
+	}
+	header := make(map[string]int, len(summaryHeader))
+	for i, name := range summaryHeader {
+		header[trimBillField(name)] = i
+	}
+	p.summary = &billRow{header: header, fields: fields, line: p.line}
+
+	// 读完剩余内容，以计算完整的摘要
+	for {
+		if _, err = p.read(); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+// finish 校验摘要，匹配时返回 io.EOF
+func (p *billParser) finish() error {
+	if p.hasher == nil {
+		return io.EOF
+	}
+	if err := checkHash(p.hasher, p.hashType, p.hashValue, "parse bill error"); err != nil {
+		return err
+	}
+	return io.EOF
+}
+
+// billRow 账单中的一行，按表头名取字段
+type billRow struct {
+	header map[string]int
+	fields []string
+	line   int
+	err    error // 第一个解析错误
+}
+
+// str 取第一个存在的表头对应的字段，去掉反引号和空白
+func (r *billRow) str(names ...string) string {
+	for _, name := range names {
+		if i, ok := r.header[name]; ok {
+			if i < len(r.fields) {
+				return trimBillField(r.fields[i])
+			}
+			return ""
+		}
+	}
+	return ""
+}
+
+// amount 取金额字段，单位转为分，字段不存在或为空时返回 0
+func (r *billRow) amount(names ...string) int64 {
+	value := r.str(names...)
+	fen, err := parseYuanToFen(value)
+	if err != nil && r.err == nil {
+		r.err = mooonerror.Errorf(mooonerror.ErrCodeWepayDecode, "bill line %d %s: %w", r.line, names[0], err)
+	}
+	return fen
+}
+
+// count 取笔数字段
+func (r *billRow) count(names ...string) int64 {
+	value := r.str(names...)
+	if value == "" {
+		return 0
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil && r.err == nil {
+		r.err = mooonerror.Errorf(mooonerror.ErrCodeWepayDecode, "bill line %d %s: %w", r.line, names[0], err)
+	}
+	return n
+}
+
+// time 取时间字段，字段不存在或为空时返回零值
+func (r *billRow) time(names ...string) time.Time {
+	value := r.str(names...)
+	if value == "" {
+		return time.Time{}
+	}
+	t, err := time.ParseInLocation(billTimeLayout, value, billLocation)
+	if err != nil && r.err == nil {
+		r.err = mooonerror.Errorf(mooonerror.ErrCodeWepayDecode, "bill line %d %s: %w", r.line, names[0], err)
+	}
+	return t
+}
+
+// fieldMap 所有字段，键为表头
+func (r *billRow) fieldMap() map[string]string {
+	fields := make(map[string]string, len(r.header))
+	for name, i := range r.header {
+		if i < len(r.fields) {
+			fields[name] = trimBillField(r.fields[i])
+		}
+	}
+	return fields
+}
+
+// trimBillField 去掉字段的反引号和空白
+func trimBillField(s string) string {
+	return strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(s), "`"))
+}
+
+// parseYuanToFen 将以元为单位的金额（如 -12.3、¥0.01）转为分，空字符串为 0
+func parseYuanToFen(s string) (int64, error) {
+	s = strings.TrimPrefix(strings.ReplaceAll(s, ",", ""), "¥")
+	if s == "" {
+		return 0, nil
+	}
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimLeft(s, "+-")
+
+	yuan, cents, _ := strings.Cut(s, ".")
+	if len(cents) > 2 {
+		return 0, mooonerror.Errorf(mooonerror.ErrCodeWepayDecode, "invalid amount: %s", s)
+	}
+	cents += strings.Repeat("0", 2-len(cents))
+	if yuan == "" {
+		yuan = "0"
+	}
+	y, err := strconv.ParseInt(yuan, 10, 64)
+	if err != nil {
+		return 0, mooonerror.Errorf(mooonerror.ErrCodeWepayDecode, "invalid amount: %s", s)
+	}
+	c, err := strconv.ParseInt(cents, 10, 64)
+	if err != nil {
+		return 0, mooonerror.Errorf(mooonerror.ErrCodeWepayDecode, "invalid amount: %s", s)
+	}
+	fen := y*100 + c
+	if negative {
+		fen = -fen
+	}
+	return fen, nil
+}
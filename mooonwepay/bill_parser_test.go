@@ -0,0 +1,270 @@
+// Package mooonwepay
+// Wrote by yijian on 2026/10/18
+package mooonwepay
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha1"
+	"encoding/hex"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eyjian/gomooon/mooonerror"
+)
+
+const testTradeBill = "\ufeff交易时间,公众账号ID,商户号,特约商户号,设备号,微信订单号,商户订单号,用户标识,交易类型,交易状态,付款银行,货币种类,应结订单金额,代金券金额,微信退款单号,商户退款单号,退款金额,充值券退款金额,退款类型,退款状态,商品名称,商户数据包,手续费,费率,订单金额,申请退款金额,费率备注\n" +
+	"`2026-10-17 10:11:12,`wx1234567890,`1900000001,`0,`,`4200000001,`T001,`oUser1,`JSAPI,`SUCCESS,`OTHERS,`CNY,`12.34,`0.00,`0,`0,`0.00,`0.00,`,`,`商品A,`,`0.07,`0.60%,`12.34,`0.00,`\n" +
+	"`2026-10-17 11:00:00,`wx1234567890,`1900000001,`0,`,`4200000002,`T002,`oUser2,`NATIVE,`REFUND,`OTHERS,`CNY,`0.00,`0.00,`5030000001,`R001,`1.50,`0.00,`ORIGINAL,`SUCCESS,`商品B,`attach,`-0.01,`0.60%,`0.00,`1.50,`\n" +
+	"总交易单数,应结订单总金额,退款总金额,充值券退款总金额,手续费总金额,订单总金额,申请退款总金额\n" +
+	"`2,`12.34,`1.50,`0.00,`0.06,`12.34,`1.50\n"
+
+const testFundFlowBill = "记账时间,微信支付业务单号,资金流水单号,业务名称,业务类型,收支类型,收支金额（元）,账户结余（元）,资金变更提交申请人,备注,业务凭证号\n" +
+	"`2026-10-17 10:11:12,`4200000001,`F001,`交易,`交易,`收入,`12.34,`112.34,`system,`,`V001\n" +
+	"`2026-10-17 11:00:00,`4200000002,`F002,`退款,`退款,`支出,`1.50,`110.84,`system,`,`V002\n" +
+	"资金流水总笔数,收入笔数,收入金额,支出笔数,支出金额\n" +
+	"`2,`1,`12.34,`1,`1.50\n"
+
+const testSharingBill = "分账时间,分账发起方,分账方,微信订单号,微信分账/回退单号,商户分账/回退单号,分账接收方,分账金额（元）,业务类型,处理结果,分账描述\n" +
+	"`2026-10-17 10:11:12,`1900000001,`1900000001,`4200000001,`3008450740201411110007820472,`P001,`1900000109,`1.00,`分账,`成功,`分给商户\n" +
+	"总笔数,总金额（元）\n" +
+	"`1,`1.00\n"
+
+func gzipBill(t *testing.T, content string) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write([]byte(content)); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func sha1Hex(content string) string {
+	sum := sha1.Sum([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// go test -v -run="TestTradeBillParser$"
+func TestTradeBillParser(t *testing.T) {
+	parser, err := NewTradeBillParser(bytes.NewReader(gzipBill(t, testTradeBill)), WithBillHash("sha1", sha1Hex(testTradeBill)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer parser.Close()
+
+	var records []*TradeBillRecord
+	for {
+		record, err := parser.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, record)
+	}
+	if len(records) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(records))
+	}
+
+	first := records[0]
+	expectedTime := time.Date(2026, 10, 17, 10, 11, 12, 0, billLocation)
+	if !first.TradeTime.Equal(expectedTime) || first.OutTradeNo != "T001" || first.TradeState != "SUCCESS" {
+		t.Errorf("unexpected record: %+v", first)
+	}
+	if first.SettlementAmount != 1234 || first.Fee != 7 || first.FeeRate != "0.60%" || first.GoodsName != "商品A" {
+		t.Errorf("unexpected record amounts: %+v", first)
+	}
+	if first.Fields["交易时间"] != "2026-10-17 10:11:12" {
+		t.Errorf("unexpected fields: %v", first.Fields)
+	}
+
+	second := records[1]
+	if second.RefundId != "5030000001" || second.RefundAmount != 150 || second.Fee != -1 || second.Attach != "attach" {
+		t.Errorf("unexpected refund record: %+v", second)
+	}
+	if !second.RefundApplyTime.IsZero() {
+		t.Errorf("unexpected refund apply time: %v", second.RefundApplyTime)
+	}
+
+	summary, err := parser.Summary()
+	if err != nil || summary == nil {
+		t.Fatalf("summary missing: %v", err)
+	}
+	if summary.TotalCount != 2 || summary.TotalSettlementAmount != 1234 || summary.TotalRefundAmount != 150 || summary.TotalFee != 6 {
+		t.Errorf("unexpected summary: %+v", summary)
+	}
+
+	// 再次调用仍返回 io.EOF
+	if _, err := parser.Next(); err != io.EOF {
+		t.Errorf("expected io.EOF, got %v", err)
+	}
+}
+
+// go test -v -run="TestTradeBillParserRefund$"
+func TestTradeBillParserRefund(t *testing.T) {
+	// REFUND 账单多出退款申请时间和退款成功时间，且不压缩
+	content := "交易时间,商户订单号,退款申请时间,退款成功时间,微信退款单号,商户退款单号,退款金额\n" +
+		"`2026-10-17 10:11:12,`T001,`2026-10-17 12:00:00,`2026-10-17 12:00:05,`5030000001,`R001,`-1.5\n"
+	parser, err := NewTradeBillParser(strings.NewReader(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer parser.Close()
+
+	record, err := parser.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record.RefundAmount != -150 || record.RefundSuccessTime.Sub(record.RefundApplyTime) != 5*time.Second {
+		t.Errorf("unexpected record: %+v", record)
+	}
+	if _, err = parser.Next(); err != io.EOF {
+		t.Errorf("expected io.EOF, got %v", err)
+	}
+	if summary, err := parser.Summary(); summary != nil || err != nil {
+		t.Errorf("unexpected summary: %+v, %v", summary, err)
+	}
+}
+
+// go test -v -run="TestTradeBillParserHashMismatch$"
+func TestTradeBillParserHashMismatch(t *testing.T) {
+	parser, err := NewTradeBillParser(bytes.NewReader(gzipBill(t, testTradeBill)), WithBillHash("SHA1", sha1Hex("other")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer parser.Close()
+
+	for {
+		_, err = parser.Next()
+		if err != nil {
+			break
+		}
+	}
+	if mooonerror.Code(err) != mooonerror.ErrCodeWepayHashMismatch {
+		t.Errorf("expected hash mismatch, got %v", err)
+	}
+
+	if _, err = NewTradeBillParser(strings.NewReader(testTradeBill), WithBillHash("MD5", "x")); mooonerror.Code(err) != mooonerror.ErrCodeInvalidParam {
+		t.Errorf("expected invalid param, got %v", err)
+	}
+}
+
+// go test -v -run="TestTradeBillParserBadAmount$"
+func TestTradeBillParserBadAmount(t *testing.T) {
+	content := "交易时间,商户订单号,应结订单金额\n`2026-10-17 10:11:12,`T001,`1.234\n"
+	parser, err := NewTradeBillParser(strings.NewReader(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer parser.Close()
+
+	if _, err = parser.Next(); mooonerror.Code(err) != mooonerror.ErrCodeWepayDecode {
+		t.Errorf("expected decode error, got %v", err)
+	}
+}
+
+// go test -v -run="TestTradeBillParserBadSummary$"
+func TestTradeBillParserBadSummary(t *testing.T) {
+	content := "交易时间,商户订单号,应结订单金额\n`2026-10-17 10:11:12,`T001,`1.23\n" +
+		"总交易单数,应结订单总金额\n`x,`1.23\n"
+	parser, err := NewTradeBillParser(strings.NewReader(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer parser.Close()
+
+	for {
+		if _, err = parser.Next(); err != nil {
+			break
+		}
+	}
+	if err != io.EOF {
+		t.Fatalf("expected io.EOF, got %v", err)
+	}
+	if summary, err := parser.Summary(); summary != nil || mooonerror.Code(err) != mooonerror.ErrCodeWepayDecode {
+		t.Errorf("expected decode error, got %+v, %v", summary, err)
+	}
+}
+
+// go test -v -run="TestFundFlowBillParser$"
+func TestFundFlowBillParser(t *testing.T) {
+	parser, err := NewFundFlowBillParser(bytes.NewReader(gzipBill(t, testFundFlowBill)), WithBillHash("SHA1", sha1Hex(testFundFlowBill)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer parser.Close()
+
+	var income, expense int64
+	for {
+		record, err := parser.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if record.FlowType == "收入" {
+			income += record.Amount
+		} else {
+			expense += record.Amount
+		}
+	}
+	summary, err := parser.Summary()
+	if err != nil || summary == nil || summary.TotalCount != 2 || summary.IncomeAmount != income || summary.ExpenseAmount != expense || summary.ExpenseCount != 1 {
+		t.Errorf("unexpected summary: %+v, income %d, expense %d", summary, income, expense)
+	}
+}
+
+// go test -v -run="TestSharingBillParser$"
+func TestSharingBillParser(t *testing.T) {
+	parser, err := NewSharingBillParser(bytes.NewReader(gzipBill(t, testSharingBill)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer parser.Close()
+
+	record, err := parser.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record.OutOrderNo != "P001" || record.Receiver != "1900000109" || record.Amount != 100 || record.Description != "分给商户" {
+		t.Errorf("unexpected record: %+v", record)
+	}
+	if _, err = parser.Next(); err != io.EOF {
+		t.Fatalf("expected io.EOF, got %v", err)
+	}
+	summary, err := parser.Summary()
+	if err != nil || summary == nil || summary.TotalCount != 1 || summary.TotalAmount != 100 {
+		t.Errorf("unexpected summary: %+v", summary)
+	}
+}
+
+// go test -v -run="TestParseYuanToFen$"
+func TestParseYuanToFen(t *testing.T) {
+	cases := map[string]int64{
+		"":          0,
+		"0":         0,
+		"0.01":      1,
+		"12.3":      1230,
+		"-12.34":    -1234,
+		"¥1,234.56": 123456,
+		".5":        50,
+	}
+	for s, expected := range cases {
+		fen, err := parseYuanToFen(s)
+		if err != nil || fen != expected {
+			t.Errorf("parseYuanToFen(%q) = %d, %v; expected %d", s, fen, err, expected)
+		}
+	}
+	for _, s := range []string{"1.234", "abc", "1.x"} {
+		if _, err := parseYuanToFen(s); err == nil {
+			t.Errorf("parseYuanToFen(%q) expected error", s)
+		}
+	}
+}
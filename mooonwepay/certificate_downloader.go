@@ -0,0 +1,195 @@
+// Package mooonwepay
+// Wrote by yijian on 2026/10/18
+package mooonwepay
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/eyjian/gomooon/moooncrypto"
+	"github.com/eyjian/gomooon/mooonerror"
+)
+
+var (
+	CertificatesPath           = "/v3/certificates" // 下载平台证书
+	downloadCertificatesErrTag = "download certificates error"
+)
+
+// 遇到未知的平台证书序列号时，两次刷新之间的最小间隔，避免伪造的序列号引起频繁下载
+const minCertificateRefreshInterval = time.Minute
+
+// CertificateDownloader 平台证书模式的验签器，自动下载、解密并缓存平台证书
+// 平台证书有效期 5 年，微信支付会在到期前启用新证书，新旧证书并存期间应答可能由其中任一证书签名：
+// 1. 遇到未缓存的证书序列号时立即刷新一次（间隔不小于 1 分钟）
+// 2. Run 定期刷新，刷新时丢弃已过期的证书
+//
+//	client := mooonwepay.NewClient(mchid, serialNo, privateKey)
+//	downloader := mooonwepay.NewCertificateDownloader(client, apiV3Key)
+//	if err := downloader.Refresh(ctx); err != nil {
+//		return err
+//	}
+//	go downloader.Run(ctx, 12*time.Hour)
+//	client.SetVerifier(downloader)
+type CertificateDownloader struct {
+	client   *Client
+	apiV3Key string // 商户平台设置的 APIv3 密钥，32 字节
+
+	mu           sync.RWMutex
+	certificates certificateVerifier
+
+	refreshMu   sync.Mutex
+	lastRefresh time.Time
+}
+
+// encryptCertificate 加密的平台证书，算法为 AEAD_AES_256_GCM
+type encryptCertificate struct {
+	Algorithm      string `json:"algorithm"`
+	Nonce          string `json:"nonce"`
+	AssociatedData string `json:"associated_data"`
+	Ciphertext     string `json:"ciphertext"`
+}
+
+// certificatesResp 下载平台证书的应答
+type certificatesResp struct {
+	Data []struct {
+		SerialNo           string             `json:"serial_no"`
+		EffectiveTime      string             `json:"effective_time"`
+		ExpireTime         string             `json:"expire_time"`
+		EncryptCertificate encryptCertificate `json:"encrypt_certificate"`
+	} `json:"data"`
+}
+
+// NewCertificateDownloader 生成平台证书下载器，client 用于签名下载平台证书的请求
+// 生成后尚未下载证书，可先调用 Refresh，否则在第一次验签时下载
+func NewCertificateDownloader(client *Client, apiV3Key string) *CertificateDownloader {
+	return &CertificateDownloader{
+		client:       client,
+		apiV3Key:     apiV3Key,
+		certificates: certificateVerifier{},
+	}
+}
+
+// Certificates 返回当前缓存的平台证书，键为证书序列号
+func (d *CertificateDownloader) Certificates() map[string]*x509.Certificate {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	certificates := make(map[string]*x509.Certificate, len(d.certificates))
+	for serialNo, cert := range d.certificates {
+		certificates[serialNo] = cert
+	}
+	return certificates
+}
+
+// Verify 实现 Verifier 接口，未缓存 serialNo 对应的平台证书时先刷新
+func (d *CertificateDownloader) Verify(ctx context.Context, serialNo, message, signature string) error {
+	d.mu.RLock()
+	_, ok := d.certificates[serialNo]
+	d.mu.RUnlock()
+	if !ok {
+		if err := d.refreshIfStale(ctx); err != nil {
+			return err
+		}
+	}
+
+	d.mu.RLock()
+	certificates := d.certificates
+	d.mu.RUnlock()
+	return certificates.Verify(ctx, serialNo, message, signature)
+}
+
+// Run 每隔 interval 刷新一次平台证书，直到 ctx 结束，返回 ctx.Err()
+// 刷新失败时继续使用已缓存的证书，下一周期再次刷新
+func (d *CertificateDownloader) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			_ = d.Refresh(ctx)
+		}
+	}
+}
+
+// Refresh 下载平台证书，以 APIv3 密钥解密，并以下载的证书验证应答签名后替换缓存
+func (d *CertificateDownloader) Refresh(ctx context.Context) error {
+	d.refreshMu.Lock()
+	defer d.refreshMu.Unlock()
+	return d.refresh(ctx)
+}
+
+// refreshIfStale 距上次刷新超过 minCertificateRefreshInterval 时刷新
+func (d *CertificateDownloader) refreshIfStale(ctx context.Context) error {
+	d.refreshMu.Lock()
+	defer d.refreshMu.Unlock()
+
+	if time.Since(d.lastRefresh) < minCertificateRefreshInterval {
+		return nil
+	}
+	return d.refresh(ctx)
+}
+
+func (d *CertificateDownloader) refresh(ctx context.Context) error {
+	d.lastRefresh = time.Now()
+
+	httpResp, err := d.client.do(ctx, http.MethodGet, d.client.host+CertificatesPath, "", downloadCertificatesErrTag, nil)
+	if err != nil {
+		return err
+	}
+	defer httpResp.Body.Close()
+
+	// 读取响应
+	respBodyBytes, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return mooonerror.Errorf(mooonerror.ErrCodeWepayDecode, "%s: read http body error: %w", downloadCertificatesErrTag, err)
+	}
+	if httpResp.StatusCode != http.StatusOK {
+		status := &WepayStatus{HttpStatusCode: httpResp.StatusCode}
+		_ = json.Unmarshal(respBodyBytes, status)
+		return newWepayError(mooonerror.ErrCodeWepayResponse, downloadCertificatesErrTag, status)
+	}
+
+	// 解析响应并解密证书
+	var resp certificatesResp
+	if err = json.Unmarshal(respBodyBytes, &resp); err != nil {
+		return mooonerror.Errorf(mooonerror.ErrCodeWepayDecode, "%s: json unmarshal http response error: %w", downloadCertificatesErrTag, err)
+	}
+	now := d.client.clock()
+	certificates := certificateVerifier{}
+	for _, item := range resp.Data {
+		encrypted := item.EncryptCertificate
+		certPEM, err := moooncrypto.AesGCMDecryptText(d.apiV3Key, encrypted.Nonce, encrypted.AssociatedData, encrypted.Ciphertext)
+		if err != nil {
+			return mooonerror.Errorf(mooonerror.ErrCodeWepayVerify, "%s: decrypt certificate %s error: %w", downloadCertificatesErrTag, item.SerialNo, err)
+		}
+		cert, err := moooncrypto.String2Certificate(certPEM)
+		if err != nil {
+			return mooonerror.Errorf(mooonerror.ErrCodeWepayVerify, "%s: parse certificate %s error: %w", downloadCertificatesErrTag, item.SerialNo, err)
+		}
+		if now.After(cert.NotAfter) {
+			continue // 丢弃已过期的证书
+		}
+		certificates[item.SerialNo] = cert
+	}
+	if len(certificates) == 0 {
+		return mooonerror.Errorf(mooonerror.ErrCodeWepayVerify, "%s: no valid certificate", downloadCertificatesErrTag)
+	}
+
+	// 以下载的证书验证应答签名，能以 APIv3 密钥解密说明证书来自微信支付，验签则确保应答未被篡改
+	if err = verifyResponse(ctx, certificates, httpResp.Header, respBodyBytes, now); err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	d.certificates = certificates
+	d.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,269 @@
+// Package mooonwepay
+// Wrote by yijian on 2026/10/18
+package mooonwepay
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/eyjian/gomooon/moooncrypto"
+	"github.com/eyjian/gomooon/mooonerror"
+	"github.com/eyjian/gomooon/mooonutils"
+)
+
+const (
+	PrimaryHost = "https://api.mch.weixin.qq.com"  // 主域名
+	BackupHost  = "https://api2.mch.weixin.qq.com" // 备域名
+)
+
+// Client 微信支付 APIv3 客户端，以商户号、证书序列号和私钥创建一次，之后的每个请求自动生成随机串和时间戳并签名：
+//
+//	client := mooonwepay.NewClient(mchid, serialNo, privateKey)
+//	resp, err := client.ApplyBill(ctx, "ALL", "GZIP", "2026-10-17")
+//
+// Client 可被多个协程共用
+type Client struct {
+	mchid      string
+	serialNo   string // 商户 API 证书序列号
+	privateKey *rsa.PrivateKey
+
+	host          string
+	backupHost    string // 备域名，为空表示不切换
+	backupHostSet bool   // 是否调用了 WithBackupHost
+	breaker       *hostBreaker
+	httpClient    *http.Client
+	nonceFunc     func() string    // 生成请求随机串
+	clock         func() time.Time // 生成请求时间戳
+	verifier      Verifier         // 验证应答签名，为 nil 表示不验证
+
+	downloadRetries  int           // 下载账单和回单的最多重试次数
+	downloadInterval time.Duration // 下载账单和回单的重试间隔
+}
+
+// ClientOption Client 的可选项
+type ClientOption func(*Client)
+
+// WithHost 设置域名，默认为主域名 PrimaryHost，为空时不修改
+func WithHost(host string) ClientOption {
+	return func(c *Client) {
+		if host != "" {
+			c.host = host
+		}
+	}
+}
+
+// WithHttpClient 设置发送请求的 http.Client，默认为 http.DefaultClient，为 nil 时不修改
+func WithHttpClient(httpClient *http.Client) ClientOption {
+	return func(c *Client) {
+		if httpClient != nil {
+			c.httpClient = httpClient
+		}
+	}
+}
+
+// WithNonceFunc 设置生成请求随机串的函数，默认生成 32 个字符的随机串
+func WithNonceFunc(nonceFunc func() string) ClientOption {
+	return func(c *Client) {
+		c.nonceFunc = nonceFunc
+	}
+}
+
+// WithClock 设置生成请求时间戳的函数，默认为 time.Now
+func WithClock(clock func() time.Time) ClientOption {
+	return func(c *Client) {
+		c.clock = clock
+	}
+}
+
+// WithVerifier 设置应答的验签器，参见 SetVerifier
+func WithVerifier(verifier Verifier) ClientOption {
+	return func(c *Client) {
+		c.verifier = verifier
+	}
+}
+
+// NewClient 生成微信支付客户端
+// serialNo 为商户 API 证书序列号，privateKey 为商户 API 证书私钥，可用 moooncrypto.Filepath2PrivateKey 从文件加载
+func NewClient(mchid, serialNo string, privateKey *rsa.PrivateKey, opts ...ClientOption) *Client {
+	c := &Client{
+		mchid:      mchid,
+		serialNo:   serialNo,
+		privateKey: privateKey,
+		host:       PrimaryHost,
+		httpClient: http.DefaultClient,
+		nonceFunc: func() string {
+			return mooonutils.GetNonceStr(32)
+		},
+		clock:            time.Now,
+		downloadRetries:  defaultDownloadRetries,
+		downloadInterval: defaultDownloadInterval,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if !c.backupHostSet && c.host == PrimaryHost {
+		c.backupHost = BackupHost
+	}
+	if c.breaker == nil {
+		c.breaker = newHostBreaker(defaultFailureThreshold, defaultOpenDuration)
+	}
+	return c
+}
+
+// SetVerifier 设置应答的验签器，设置后 Apply*、Query* 等 JSON 应答的签名验证失败时返回 ErrCodeWepayVerify 错误
+// 平台证书模式使用 NewCertificateDownloader（它需要 Client 下载证书，故无法在 NewClient 时传入），微信支付公钥模式使用 NewPublicKeyVerifier
+// 账单和回单文件的下载应答没有签名，以摘要校验文件的完整性
+// 应在使用 Client 之前设置
+func (c *Client) SetVerifier(verifier Verifier) {
+	c.verifier = verifier
+}
+
+// newLegacyClient 以旧接口请求中的参数生成 Client，供各 *Req 函数使用
+// 首次请求使用请求中指定的随机串和时间戳，切换到备域名和下载重试时重新生成，避免重放同一签名
+// host 为主域名时同样自动切换到备域名，熔断器由所有 *Req 函数共用
+func newLegacyClient(httpClient *http.Client, privateKey *rsa.PrivateKey, host, mchid, serialNo, nonceStr string, timestamp int64) *Client {
+	nonceUsed, timestampUsed := false, false
+	return NewClient(mchid, serialNo, privateKey,
+		withBreaker(legacyBreaker),
+		WithHost(host),
+		WithHttpClient(httpClient),
+		WithNonceFunc(func() string {
+			if nonceUsed || nonceStr == "" {
+				return mooonutils.GetNonceStr(32)
+			}
+			nonceUsed = true
+			return nonceStr
+		}),
+		WithClock(func() time.Time {
+			if timestampUsed || timestamp == 0 {
+				return time.Now()
+			}
+			timestampUsed = true
+			return time.Unix(timestamp, 0)
+		}))
+}
+
+// newRequest 生成已签名的请求，url 为完整的请求地址，body 为空表示没有请求报文主体
+// 签名串格式：
+// HTTP请求方法\n
+// URL\n
+// 请求时间戳\n
+// 请求随机串\n
+// 请求报文主体\n
+func (c *Client) newRequest(ctx context.Context, method, url, body, errTag string) (*http.Request, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	nonceStr := c.nonceFunc()
+	timestamp := c.clock().Unix()
+
+	// 计算签名
+	signatureString := fmt.Sprintf("%s\n%s\n%d\n%s\n%s\n", method, mooonutils.ExtractUrlPath(url), timestamp, nonceStr, body)
+	signature, err := moooncrypto.RsaSha256SignWithPrivateKey(c.privateKey, []byte(signatureString))
+	if err != nil {
+		return nil, mooonerror.Errorf(mooonerror.ErrCodeWepaySign, "%s: rsa sha256 sign error: %w", errTag, err)
+	}
+
+	// 构建请求
+	var bodyReader io.Reader
+	if body != "" {
+		bodyReader = strings.NewReader(body)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, method, url, bodyReader)
+	if err != nil {
+		return nil, mooonerror.Errorf(mooonerror.ErrCodeWepayRequest, "%s: new http request error: %w", errTag, err)
+	}
+
+	// 设置请求头
+	httpReq.Header.Set("Authorization", makeChangeBillAuthorization(c.mchid, c.serialNo, nonceStr, signature, timestamp))
+	httpReq.Header.Set("Accept", "application/json")
+	httpReq.Header.Set("Content-Type", "application/json")
+	return httpReq, nil
+}
+
+// do 签名并发送请求，header 为附加的请求头（如 Range），调用者负责关闭应答的 Body
+// 主域名连接失败、超时或返回 5xx 时，重新签名后请求备域名；主域名熔断期间直接请求备域名
+func (c *Client) do(ctx context.Context, method, url, body, errTag string, header http.Header) (*http.Response, error) {
+	backupUrl := c.getBackupUrl(url)
+	if backupUrl != "" && !c.breaker.allow() {
+		// 主域名熔断中，直接请求备域名
+		url, backupUrl = backupUrl, ""
+	}
+
+	httpResp, err := c.doOnce(ctx, method, url, body, errTag, header)
+	if backupUrl == "" || (err != nil && !mooonerror.IsRetryable(err)) {
+		return httpResp, err // 不切换，或签名等失败而未发出请求
+	}
+	if !shouldFailover(ctx, httpResp, err) {
+		c.breaker.success()
+		return httpResp, err
+	}
+
+	c.breaker.failure()
+	if httpResp != nil {
+		httpResp.Body.Close()
+	}
+	return c.doOnce(ctx, method, backupUrl, body, errTag, header)
+}
+
+// doOnce 签名并发送一次请求
+func (c *Client) doOnce(ctx context.Context, method, url, body, errTag string, header http.Header) (*http.Response, error) {
+	httpReq, err := c.newRequest(ctx, method, url, body, errTag)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range header {
+		httpReq.Header[key] = values
+	}
+	httpResp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, mooonerror.Errorf(mooonerror.ErrCodeWepayRequest, "%s: do http request error: %w", errTag, err).WithRetryable()
+	}
+	return httpResp, nil
+}
+
+// doJSON 签名并发送请求，设置了验签器时验证应答签名，将 JSON 应答解析到 resp，HTTP 状态码记录在 status 中
+// HTTP 状态码非 200 时，微信支付的错误码和错误描述也解析到 status 中，并返回带 *WepayError 的错误
+func (c *Client) doJSON(ctx context.Context, method, url, body, errTag string, resp interface{}, status *WepayStatus) error {
+	httpResp, err := c.do(ctx, method, url, body, errTag, nil)
+	if err != nil {
+		return err
+	}
+	defer httpResp.Body.Close()
+
+	// 读取响应
+	status.HttpStatusCode = httpResp.StatusCode
+	respBodyBytes, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return mooonerror.Errorf(mooonerror.ErrCodeWepayDecode, "%s: read http body error: %w", errTag, err)
+	}
+
+	// 解析响应
+	if httpResp.StatusCode != http.StatusOK {
+		// {"code":"RESOURCE_ALREADY_EXISTS","message":"该批次回单已申请，您可在通过查询电子回单接口来获取单据信息"}
+		_ = json.Unmarshal(respBodyBytes, resp)
+		_ = json.Unmarshal(respBodyBytes, status)
+		return newWepayError(mooonerror.ErrCodeWepayResponse, errTag, status)
+	}
+	if c.verifier != nil {
+		if err = verifyResponse(ctx, c.verifier, httpResp.Header, respBodyBytes, c.clock()); err != nil {
+			return err
+		}
+	}
+	if err = json.Unmarshal(respBodyBytes, resp); err != nil {
+		return mooonerror.Errorf(mooonerror.ErrCodeWepayDecode, "%s: json unmarshal http response error: %w", errTag, err)
+	}
+	return nil
+}
+
+// isWepayResponseError err 是否为微信支付返回的错误，此时应答中含错误码和错误描述，应同 err 一起返回给调用者
+func isWepayResponseError(err error) bool {
+	_, ok := AsWepayError(err)
+	return ok
+}
@@ -0,0 +1,151 @@
+// Package mooonwepay
+// Wrote by yijian on 2026/10/18
+package mooonwepay
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/eyjian/gomooon/moooncrypto"
+)
+
+const (
+	testMchid     = "1900000001"
+	testSerialNo  = "5157F09EFDC096DE15EBE81A47057A7232F1B8E1"
+	testNonceStr  = "593BEC0C930BF1AFEB40B4A08C8FB242"
+	testTimestamp = int64(1554208460)
+)
+
+func newTestClient(t *testing.T, host string) (*Client, *rsa.PrivateKey) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := NewClient(testMchid, testSerialNo, privateKey,
+		WithHost(host),
+		WithNonceFunc(func() string { return testNonceStr }),
+		WithClock(func() time.Time { return time.Unix(testTimestamp, 0) }))
+	return client, privateKey
+}
+
+func expectedAuthorization(t *testing.T, privateKey *rsa.PrivateKey, signatureString string) string {
+	signature, err := moooncrypto.RsaSha256SignWithPrivateKey(privateKey, []byte(signatureString))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return makeChangeBillAuthorization(testMchid, testSerialNo, testNonceStr, signature, testTimestamp)
+}
+
+// go test -v -run="TestClientApplyBill$"
+func TestClientApplyBill(t *testing.T) {
+	var authorizations []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorizations = append(authorizations, r.Header.Get("Authorization"))
+		fmt.Fprint(w, `{"hash_type":"SHA1","hash_value":"79bb0f45fc4c42234a918000b2668d689e2bde04","download_url":"https://api.mch.weixin.qq.com/v3/billdownload/file?token=xxx"}`)
+	}))
+	defer server.Close()
+
+	client, privateKey := newTestClient(t, server.URL)
+	resp, err := client.ApplyBill(context.Background(), "ALL", "GZIP", "2026-10-17")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.HttpStatusCode != http.StatusOK || resp.HashType != "SHA1" {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+
+	// 旧的函数应生成相同的签名
+	_, err = ApplyBill(&ApplyBillReq{
+		Ctx:        context.Background(),
+		HttpClient: &http.Client{},
+		PrivateKey: privateKey,
+		Host:       server.URL,
+		NonceStr:   testNonceStr,
+		Timestamp:  testTimestamp,
+		Mchid:      testMchid,
+		SerialNo:   testSerialNo,
+
+		BillType:        "ALL",
+		CompressionType: "GZIP",
+		Date:            "2026-10-17",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := expectedAuthorization(t, privateKey,
+		fmt.Sprintf("GET\n%s?bill_date=2026-10-17&bill_type=ALL&tar_type=GZIP\n%d\n%s\n\n", ApplyTradeBillPath, testTimestamp, testNonceStr))
+	for i, authorization := range authorizations {
+		if authorization != expected {
+			t.Errorf("authorization %d mismatch:\n%s\n%s", i, authorization, expected)
+		}
+	}
+}
+
+// go test -v -run="TestClientApplyChangeBillReceipt$"
+func TestClientApplyChangeBillReceipt(t *testing.T) {
+	var body, authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bodyBytes, _ := io.ReadAll(r.Body)
+		body = string(bodyBytes)
+		authorization = r.Header.Get("Authorization")
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"code":"RESOURCE_ALREADY_EXISTS","message":"该批次回单已申请"}`)
+	}))
+	defer server.Close()
+
+	client, privateKey := newTestClient(t, server.URL)
+	resp, err := client.ApplyChangeBillReceipt(context.Background(), "plfk2020042013", "", "")
+	if resp == nil || resp.Code != "RESOURCE_ALREADY_EXISTS" || resp.HttpStatusCode != http.StatusBadRequest {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+	if wepayErr, ok := AsWepayError(err); !ok || wepayErr.Retryable {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	expectedBody := `{"out_batch_no":"plfk2020042013"}`
+	if body != expectedBody {
+		t.Errorf("unexpected body: %s", body)
+	}
+	expected := expectedAuthorization(t, privateKey,
+		fmt.Sprintf("POST\n%s\n%d\n%s\n%s\n", ApplyChangeBillBatchReceiptPath, testTimestamp, testNonceStr, expectedBody))
+	if authorization != expected {
+		t.Errorf("authorization mismatch:\n%s\n%s", authorization, expected)
+	}
+}
+
+// go test -v -run="TestClientDownloadSharingBill$"
+func TestClientDownloadSharingBill(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v3/billdownload/file" {
+			fmt.Fprint(w, "bill content")
+			return
+		}
+		fmt.Fprintf(w, `{"download_url":"%s/v3/billdownload/file?token=xxx"}`, server.URL)
+	}))
+	defer server.Close()
+
+	client, _ := newTestClient(t, server.URL)
+	path := filepath.Join(t.TempDir(), "sharing_bill.gz")
+	resp, err := client.DownloadSharingBill(context.Background(), "GZIP", "2026-10-17", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.HttpStatusCode != http.StatusOK {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil || string(content) != "bill content" {
+		t.Errorf("unexpected file content: %s, %v", content, err)
+	}
+}
@@ -0,0 +1,231 @@
+// Package mooonwepay
+// Wrote by yijian on 2026/10/18
+package mooonwepay
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/eyjian/gomooon/mooonerror"
+	"github.com/tjfoc/gmsm/sm3"
+)
+
+// 下载账单和回单：
+// 1. 先写入目标文件所在目录下的临时文件，下载完成且摘要匹配后才原子地重命名为目标文件，失败时删除临时文件，目标文件不会是残缺的
+// 2. 连接失败、超时、5xx 或读取中途中断时，等待后以 Range 请求从已下载的位置续传；服务端不支持 Range 而返回 200 时从头下载
+// 3. 摘要取自申请账单或回单的应答，gzip 压缩的账单按解压后的内容计算（微信支付对账单摘要的定义），其它文件按原始内容计算
+
+const (
+	defaultDownloadRetries  = 3           // 默认最多重试 3 次
+	defaultDownloadInterval = time.Second // 默认重试间隔 1 秒
+)
+
+// WithDownloadRetry 设置下载账单和回单的重试：连接失败、超时、5xx 或读取中断时，等待 interval 后续传，最多重试 maxRetries 次
+// 默认最多重试 3 次、间隔 1 秒，maxRetries 为 0 表示不重试
+func WithDownloadRetry(maxRetries int, interval time.Duration) ClientOption {
+	return func(c *Client) {
+		if maxRetries >= 0 {
+			c.downloadRetries = maxRetries
+		}
+		c.downloadInterval = interval
+	}
+}
+
+// downloadFetcher 发送一次下载请求，header 为附加的请求头（如 Range）
+type downloadFetcher func(ctx context.Context, header http.Header) (*http.Response, error)
+
+// download 签名并下载 downloadUrl 指向的文件，校验摘要后存放到 localPath，HTTP 状态码记录在 status 中
+// hashType 为空时不校验摘要，续传成功时 status 中的 HTTP 状态码同样记为 200
+func (c *Client) download(ctx context.Context, downloadUrl, localPath, hashType, hashValue, errTag string, status *WepayStatus) error {
+	fetch := func(ctx context.Context, header http.Header) (*http.Response, error) {
+		return c.do(ctx, http.MethodGet, downloadUrl, "", errTag, header)
+	}
+	return downloadFile(ctx, fetch, localPath, hashType, hashValue, errTag, c.downloadRetries, c.downloadInterval, status)
+}
+
+// downloadFile 以 fetch 下载文件，失败时等待 interval 后续传，最多重试 maxRetries 次
+func downloadFile(ctx context.Context, fetch downloadFetcher, localPath, hashType, hashValue, errTag string, maxRetries int, interval time.Duration, status *WepayStatus) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if _, err := newHasher(hashType); err != nil {
+		return err
+	}
+
+	// 临时文件和目标文件在同一目录下，才能原子地重命名
+	tmpFile, err := os.CreateTemp(filepath.Dir(localPath), "."+filepath.Base(localPath)+".*.tmp")
+	if err != nil {
+		return mooonerror.Errorf(mooonerror.ErrCodeFileOperate, "%s: create temp file for %s error: %w", errTag, localPath, err)
+	}
+	tmpPath := tmpFile.Name()
+	succeeded := false
+	defer func() {
+		if !succeeded {
+			_ = tmpFile.Close()
+			_ = os.Remove(tmpPath)
+		}
+	}()
+
+	var offset int64
+	for retries := 0; ; retries++ {
+		offset, err = downloadRange(ctx, fetch, tmpFile, offset, errTag, status)
+		if err == nil {
+			break
+		}
+		if retries >= maxRetries || !mooonerror.IsRetryable(err) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(interval):
+		}
+	}
+
+	if err = tmpFile.Sync(); err != nil {
+		return mooonerror.Errorf(mooonerror.ErrCodeFileOperate, "%s: sync %s error: %w", errTag, tmpPath, err)
+	}
+	if err = tmpFile.Close(); err != nil {
+		return mooonerror.Errorf(mooonerror.ErrCodeFileOperate, "%s: close %s error: %w", errTag, tmpPath, err)
+	}
+	if err = verifyFileHash(tmpPath, hashType, hashValue, errTag); err != nil {
+		return err
+	}
+	if err = os.Rename(tmpPath, localPath); err != nil {
+		return mooonerror.Errorf(mooonerror.ErrCodeFileOperate, "%s: rename %s to %s error: %w", errTag, tmpPath, localPath, err)
+	}
+	succeeded = true
+	return nil
+}
+
+// downloadRange 从 offset 处下载并写入 file，返回已下载的长度
+// offset 大于 0 时发送 Range 请求，应答为 200 时说明服务端忽略了 Range，清空 file 后从头写入
+func downloadRange(ctx context.Context, fetch downloadFetcher, file *os.File, offset int64, errTag string, status *WepayStatus) (int64, error) {
+	var header http.Header
+	if offset > 0 {
+		header = http.Header{"Range": {fmt.Sprintf("bytes=%d-", offset)}}
+	}
+	httpResp, err := fetch(ctx, header)
+	if err != nil {
+		return offset, err
+	}
+	defer httpResp.Body.Close()
+
+	status.HttpStatusCode = httpResp.StatusCode
+	switch httpResp.StatusCode {
+	case http.StatusOK:
+		offset = 0
+		if err = file.Truncate(0); err != nil {
+			return 0, mooonerror.Errorf(mooonerror.ErrCodeFileOperate, "%s: truncate %s error: %w", errTag, file.Name(), err)
+		}
+	case http.StatusPartialContent:
+		status.HttpStatusCode = http.StatusOK
+		contentRange := httpResp.Header.Get("Content-Range")
+		if !strings.HasPrefix(contentRange, fmt.Sprintf("bytes %d-", offset)) {
+			// 返回的区间与请求的不符，下次从头下载
+			return 0, mooonerror.Errorf(mooonerror.ErrCodeWepayDownload, "%s: unexpected content range %q for offset %d", errTag, contentRange, offset).WithRetryable()
+		}
+	default:
+		respBodyBytes, err := io.ReadAll(httpResp.Body)
+		if err == nil {
+			_ = json.Unmarshal(respBodyBytes, status)
+		}
+		return offset, newWepayError(mooonerror.ErrCodeWepayDownload, errTag, status)
+	}
+
+	if _, err = file.Seek(offset, io.SeekStart); err != nil {
+		return offset, mooonerror.Errorf(mooonerror.ErrCodeFileOperate, "%s: seek %s error: %w", errTag, file.Name(), err)
+	}
+	body := &downloadBodyReader{reader: httpResp.Body}
+	n, err := io.Copy(file, body)
+	offset += n
+	if err != nil {
+		if body.err != nil {
+			// 读取中途中断，如 unexpected EOF，可续传
+			return offset, mooonerror.Errorf(mooonerror.ErrCodeWepayDownload, "%s: read http body error after %d bytes: %w", errTag, offset, err).WithRetryable()
+		}
+		return offset, mooonerror.Errorf(mooonerror.ErrCodeFileOperate, "%s: write %s error: %w", errTag, file.Name(), err)
+	}
+	return offset, nil
+}
+
+// downloadBodyReader 记录读取应答的错误，以区分 io.Copy 返回的是读错误还是写错误
+type downloadBodyReader struct {
+	reader io.Reader
+	err    error
+}
+
+func (r *downloadBodyReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	if err != nil && err != io.EOF {
+		r.err = err
+	}
+	return n, err
+}
+
+// newHasher 按摘要类型生成 hash.Hash，支持 SHA1、SHA256 和 SM3，hashType 为空时返回 nil
+func newHasher(hashType string) (hash.Hash, error) {
+	switch strings.ToUpper(hashType) {
+	case "":
+		return nil, nil
+	case "SHA1":
+		return sha1.New(), nil
+	case "SHA256":
+		return sha256.New(), nil
+	case "SM3":
+		return sm3.New(), nil
+	default:
+		return nil, mooonerror.Errorf(mooonerror.ErrCodeInvalidParam, "unsupported hash type: %s", hashType)
+	}
+}
+
+// verifyFileHash 校验文件的摘要，gzip 压缩的文件按解压后的内容计算，hashType 为空时不校验
+func verifyFileHash(path, hashType, hashValue, errTag string) error {
+	hasher, err := newHasher(hashType)
+	if err != nil || hasher == nil {
+		return err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return mooonerror.Errorf(mooonerror.ErrCodeFileOperate, "%s: open %s error: %w", errTag, path, err)
+	}
+	defer file.Close()
+
+	br := bufio.NewReader(file)
+	var src io.Reader = br
+	if magic, err := br.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return mooonerror.Errorf(mooonerror.ErrCodeWepayHashMismatch, "%s: gunzip %s error: %w", errTag, path, err)
+		}
+		defer gz.Close()
+		src = gz
+	}
+	if _, err = io.Copy(hasher, src); err != nil {
+		return mooonerror.Errorf(mooonerror.ErrCodeWepayHashMismatch, "%s: read %s error: %w", errTag, path, err)
+	}
+	return checkHash(hasher, hashType, hashValue, errTag)
+}
+
+// checkHash 比较摘要，不区分大小写
+func checkHash(hasher hash.Hash, hashType, hashValue, errTag string) error {
+	actual := hex.EncodeToString(hasher.Sum(nil))
+	if !strings.EqualFold(actual, hashValue) {
+		return mooonerror.Errorf(mooonerror.ErrCodeWepayHashMismatch, "%s: %s mismatch: expected %s, actual %s", errTag, strings.ToUpper(hashType), hashValue, actual)
+	}
+	return nil
+}
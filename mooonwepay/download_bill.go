@@ -5,15 +5,7 @@ package mooonwepay
 import (
 	"context"
 	"crypto/rsa"
-	"encoding/json"
-	"fmt"
-	"io"
 	"net/http"
-	"os"
-)
-import (
-	"github.com/eyjian/gomooon/moooncrypto"
-	"github.com/eyjian/gomooon/mooonutils"
 )
 
 var (
@@ -53,116 +45,40 @@ type DownloadBillReq struct {
 }
 
 type DownloadBillResp struct {
-	Code    string `json:"code,omitempty"`
-	Message string `json:"message,omitempty"`
+	HashType  string // 账单文件的哈希类型，取自申请账单的应答，可传给 WithBillHash 校验文件
+	HashValue string // 账单文件的哈希值
 
-	HttpStatusCode int `json:"http_status_code,omitempty"`
+	WepayStatus
 }
 
-// DownloadBill 下载转账电子回单
+// DownloadBill 下载账单
+// 仍保留以兼容旧的调用方式，新代码请使用 Client.DownloadBill
 // 账单文件格式，参加微信支付官方文档：https://pay.weixin.qq.com/docs/merchant/apis/bill-download/download-bill.html
 func DownloadBill(req *DownloadBillReq) (*DownloadBillResp, error) {
-	ctx := req.Ctx
+	client := newLegacyClient(req.HttpClient, req.PrivateKey, req.Host, req.Mchid, req.SerialNo, req.NonceStr, req.Timestamp)
+	return client.DownloadBill(req.Ctx, req.BillType, req.CompressionType, req.Date, req.Filepath)
+}
 
+// DownloadBill 申请并下载交易账单或资金账单，存放到 filepath（下载后为 gzip 压缩过的 csv 文件），其它参数同 ApplyBill
+func (c *Client) DownloadBill(ctx context.Context, billType, compressionType, date, filepath string) (*DownloadBillResp, error) {
 	// 通过调用 ApplyBill，取得下载 url
-	applyBillResp, err := getBillDownloadUrl(req)
+	applyBillResp, err := c.ApplyBill(ctx, billType, compressionType, date)
 	if err != nil {
 		if applyBillResp != nil {
 			return &DownloadBillResp{
-				Code:           applyBillResp.Code,
-				Message:        applyBillResp.Message,
-				HttpStatusCode: applyBillResp.HttpStatusCode,
+				WepayStatus: applyBillResp.WepayStatus,
 			}, err
 		}
 		return nil, err
 	}
 
-	// 计算签名
-	downloadPath := mooonutils.ExtractUrlPath(applyBillResp.DownloadUrl)
-	signatureString := makeDownloadBillSignatureString(req.NonceStr, downloadPath, req.Timestamp)
-	signature, err := moooncrypto.RsaSha256SignWithPrivateKey(req.PrivateKey, []byte(signatureString))
-	if err != nil {
-		return nil, fmt.Errorf("%s: RSA SHA256 sign error: %s", downloadBillErrTag, err.Error())
-	}
-
-	// 生成 Authorization
-	authorization := makeChangeBillAuthorization(req.Mchid, req.SerialNo, req.NonceStr, signature, req.Timestamp)
-
-	// 构建请求
-	httpReq, err := http.NewRequestWithContext(ctx, "GET", applyBillResp.DownloadUrl, nil)
-	if err != nil {
-		return nil, fmt.Errorf("%s: new http request error: %s", downloadBillErrTag, err.Error())
-	}
-
-	// 设置请求头
-	httpReq.Header.Set("Authorization", authorization)
-	httpReq.Header.Set("Accept", "application/json")
-	httpReq.Header.Set("Content-Type", "application/json")
-
-	// 发送请求
-	httpResp, err := req.HttpClient.Do(httpReq)
-	if err != nil {
-		return nil, fmt.Errorf("%s: do http request error: %s", downloadBillErrTag, err.Error())
-	}
-	defer httpResp.Body.Close()
-
 	resp := &DownloadBillResp{
-		HttpStatusCode: httpResp.StatusCode,
-	}
-	if httpResp.StatusCode != http.StatusOK {
-		respBodyBytes, err := io.ReadAll(httpResp.Body)
-		if err == nil {
-			json.Unmarshal(respBodyBytes, resp)
-		}
-		return resp, fmt.Errorf("%s: http get %s status code error: %d", downloadBillErrTag, applyBillResp.DownloadUrl, httpResp.StatusCode)
-	}
-
-	// 创建文件
-	file, err := os.Create(req.Filepath)
-	if err != nil {
-		return resp, fmt.Errorf("%s: create %s error: %s", downloadBillErrTag, req.Filepath, err.Error())
+		HashType:  applyBillResp.HashType,
+		HashValue: applyBillResp.HashValue,
 	}
-	defer file.Close()
-
-	// 写入文件
-	_, err = io.Copy(file, httpResp.Body)
-	if err != nil {
-		return resp, fmt.Errorf("%s: write response to %s error: %s", downloadBillErrTag, req.Filepath, err.Error())
-	}
-
-	return resp, nil
-}
-
-// makeDownloadBillSignatureString 生成签名串
-//HTTP请求方法\n
-//URL\n
-//请求时间戳\n
-//请求随机串\n
-//请求报文主体\n
-func makeDownloadBillSignatureString(nonceStr, downloadUrl string, timestamp int64) string {
-	return fmt.Sprintf("GET\n%s\n%d\n%s\n\n", downloadUrl, timestamp, nonceStr)
-}
-
-func getBillDownloadUrl(req *DownloadBillReq) (*ApplyBillResp, error) {
-	// 调用 ApplyBill，取得下载 url
-	applyBillResp, err := ApplyBill(
-		&ApplyBillReq{
-			Ctx:        req.Ctx,
-			HttpClient: req.HttpClient,
-			PrivateKey: req.PrivateKey,
-
-			Host:      req.Host,
-			NonceStr:  req.NonceStr,
-			Timestamp: req.Timestamp,
-			Mchid:     req.Mchid,
-			SerialNo:  req.SerialNo,
-
-			BillType:        req.BillType,
-			CompressionType: req.CompressionType,
-			Date:            req.Date,
-		})
-	if err != nil {
+	err = c.download(ctx, applyBillResp.DownloadUrl, filepath, applyBillResp.HashType, applyBillResp.HashValue, downloadBillErrTag, &resp.WepayStatus)
+	if err != nil && resp.HttpStatusCode == 0 {
 		return nil, err
 	}
-	return applyBillResp, nil
+	return resp, err
 }
@@ -5,15 +5,7 @@ package mooonwepay
 import (
 	"context"
 	"crypto/rsa"
-	"encoding/json"
-	"fmt"
-	"github.com/eyjian/gomooon/mooonutils"
-	"io"
 	"net/http"
-	"os"
-)
-import (
-	"github.com/eyjian/gomooon/moooncrypto"
 )
 
 var (
@@ -39,114 +31,42 @@ type DownloadChangeBillReceiptReq struct {
 }
 
 type DownloadChangeBillReceiptResp struct {
-	Code    string `json:"code,omitempty"`
-	Message string `json:"message,omitempty"`
-
-	HttpStatusCode int `json:"http_status_code,omitempty"`
+	WepayStatus
 }
 
 // DownloadChangeBillReceipt 下载转账电子回单
+// 仍保留以兼容旧的调用方式，新代码请使用 Client.DownloadChangeBillReceipt
 // 转账电子回单文件格式，参加微信支付官方文档：https://pay.weixin.qq.com/docs/merchant/apis/batch-transfer-to-balance/download-receipt.html
 func DownloadChangeBillReceipt(req *DownloadChangeBillReceiptReq) (*DownloadChangeBillReceiptResp, error) {
-	ctx := req.Ctx
+	client := newLegacyClient(req.HttpClient, req.PrivateKey, req.Host, req.Mchid, req.SerialNo, req.NonceStr, req.Timestamp)
+	return client.DownloadChangeBillReceipt(req.Ctx, req.OutBatchNo, req.OutDetailNo, req.AcceptType, req.Filepath)
+}
 
+// DownloadChangeBillReceipt 申请并下载转账电子回单，存放到 filepath，其它参数同 ApplyChangeBillReceipt
+// 回单已申请过时（ALREADY_EXISTS 或 RESOURCE_ALREADY_EXISTS），通过 QueryChangeBillReceipt 取得下载地址
+func (c *Client) DownloadChangeBillReceipt(ctx context.Context, outBatchNo, outDetailNo, acceptType, filepath string) (*DownloadChangeBillReceiptResp, error) {
 	// 通过调用 QueryBill，取得下载 url
-	queryBillResp, err := getChangeBillReceiptDownloadUrl(req)
+	queryBillResp, err := c.getChangeBillReceiptDownloadUrl(ctx, outBatchNo, outDetailNo, acceptType)
 	if err != nil {
 		if queryBillResp != nil {
 			return &DownloadChangeBillReceiptResp{
-				Code:           queryBillResp.Code,
-				Message:        queryBillResp.Message,
-				HttpStatusCode: queryBillResp.HttpStatusCode,
+				WepayStatus: queryBillResp.WepayStatus,
 			}, err
 		}
 		return nil, err
 	}
 
-	// 计算签名
-	downloadPath := mooonutils.ExtractUrlPath(queryBillResp.DownloadUrl)
-	signatureString := makeDownloadChangeBillReceiptSignatureString(req.NonceStr, downloadPath, req.Timestamp)
-	signature, err := moooncrypto.RsaSha256SignWithPrivateKey(req.PrivateKey, []byte(signatureString))
-	if err != nil {
-		return nil, fmt.Errorf("%s: RSA SHA256 sign error: %s", downloadChangeBillReceiptErrTag, err.Error())
-	}
-
-	// 生成 Authorization
-	authorization := makeChangeBillAuthorization(req.Mchid, req.SerialNo, req.NonceStr, signature, req.Timestamp)
-
-	// 构建请求
-	httpReq, err := http.NewRequestWithContext(ctx, "GET", queryBillResp.DownloadUrl, nil)
-	if err != nil {
-		return nil, fmt.Errorf("%s: new http request error: %s", downloadChangeBillReceiptErrTag, err.Error())
-	}
-
-	// 设置请求头
-	httpReq.Header.Set("Authorization", authorization)
-	httpReq.Header.Set("Accept", "application/json")
-	httpReq.Header.Set("Content-Type", "application/json")
-
-	// 发送请求
-	httpResp, err := req.HttpClient.Do(httpReq)
-	if err != nil {
-		return nil, fmt.Errorf("%s: do http request error: %s", downloadChangeBillReceiptErrTag, err.Error())
-	}
-	defer httpResp.Body.Close()
-
-	resp := &DownloadChangeBillReceiptResp{
-		HttpStatusCode: httpResp.StatusCode,
-	}
-	if httpResp.StatusCode != http.StatusOK {
-		respBodyBytes, err := io.ReadAll(httpResp.Body)
-		if err == nil {
-			json.Unmarshal(respBodyBytes, resp)
-		}
-		return resp, fmt.Errorf("%s: http get %s status code error: %d", downloadChangeBillReceiptErrTag, queryBillResp.DownloadUrl, httpResp.StatusCode)
-	}
-
-	// 创建文件
-	file, err := os.Create(req.Filepath)
-	if err != nil {
-		return resp, fmt.Errorf("%s: create %s error: %s", downloadChangeBillReceiptErrTag, req.Filepath, err.Error())
-	}
-	defer file.Close()
-
-	// 写入文件
-	_, err = io.Copy(file, httpResp.Body)
-	if err != nil {
-		return resp, fmt.Errorf("%s: write response to %s error: %s", downloadChangeBillReceiptErrTag, req.Filepath, err.Error())
+	resp := &DownloadChangeBillReceiptResp{}
+	err = c.download(ctx, queryBillResp.DownloadUrl, filepath, queryBillResp.HashType, queryBillResp.HashValue, downloadChangeBillReceiptErrTag, &resp.WepayStatus)
+	if err != nil && resp.HttpStatusCode == 0 {
+		return nil, err
 	}
-
-	return resp, nil
-}
-
-// makeDownloadChangeBillReceiptSignatureString 生成签名串
-//HTTP请求方法\n
-//URL\n
-//请求时间戳\n
-//请求随机串\n
-//请求报文主体\n
-func makeDownloadChangeBillReceiptSignatureString(nonceStr, downloadUrl string, timestamp int64) string {
-	return fmt.Sprintf("GET\n%s\n%d\n%s\n\n", downloadUrl, timestamp, nonceStr)
+	return resp, err
 }
 
-func getChangeBillReceiptDownloadUrl(req *DownloadChangeBillReceiptReq) (*QueryChangeBillReceiptResp, error) {
+func (c *Client) getChangeBillReceiptDownloadUrl(ctx context.Context, outBatchNo, outDetailNo, acceptType string) (*QueryChangeBillReceiptResp, error) {
 	// 调用 ApplyBillReceipt，取得下载 url
-	getBillReceiptResp, err := ApplyChangeBillReceipt(
-		&ApplyChangeBillReceiptReq{
-			Ctx:        req.Ctx,
-			HttpClient: req.HttpClient,
-			PrivateKey: req.PrivateKey,
-
-			Host:      req.Host,
-			NonceStr:  req.NonceStr,
-			Timestamp: req.Timestamp,
-
-			Mchid:       req.Mchid,
-			SerialNo:    req.SerialNo,
-			OutBatchNo:  req.OutBatchNo,
-			OutDetailNo: req.OutDetailNo,
-			AcceptType:  req.AcceptType,
-		})
+	getBillReceiptResp, err := c.ApplyChangeBillReceipt(ctx, outBatchNo, outDetailNo, acceptType)
 	// ALREADY_EXISTS 转账电子回单申请单数据已存在
 	// RESOURCE_ALREADY_EXISTS 该批次回单已申请，您可在通过查询电子回单接口来获取单据信息
 	if err == nil || (getBillReceiptResp != nil && getBillReceiptResp.Code != "ALREADY_EXISTS" && getBillReceiptResp.Code != "RESOURCE_ALREADY_EXISTS") {
@@ -162,10 +82,7 @@ func getChangeBillReceiptDownloadUrl(req *DownloadChangeBillReceiptReq) (*QueryC
 			CreateTime:      getBillReceiptResp.CreateTime,
 			UpdateTime:      getBillReceiptResp.UpdateTime,
 
-			Code:    getBillReceiptResp.Code,
-			Message: getBillReceiptResp.Message,
-
-			HttpStatusCode: getBillReceiptResp.HttpStatusCode,
+			WepayStatus: getBillReceiptResp.WepayStatus,
 		}, err
 	}
 	if err != nil {
@@ -175,21 +92,5 @@ func getChangeBillReceiptDownloadUrl(req *DownloadChangeBillReceiptReq) (*QueryC
 	}
 
 	// 调用 QueryBillReceipt，取得下载 url
-	queryBillReceiptResp, err := QueryChangeBillReceipt(
-		&QueryChangeBillReceiptReq{
-			Ctx:        req.Ctx,
-			HttpClient: req.HttpClient,
-			PrivateKey: req.PrivateKey,
-
-			Host:      req.Host,
-			NonceStr:  req.NonceStr,
-			Timestamp: req.Timestamp,
-
-			Mchid:       req.Mchid,
-			SerialNo:    req.SerialNo,
-			OutBatchNo:  req.OutBatchNo,
-			OutDetailNo: req.OutDetailNo,
-			AcceptType:  req.AcceptType,
-		})
-	return queryBillReceiptResp, err
+	return c.QueryChangeBillReceipt(ctx, outBatchNo, outDetailNo, acceptType)
 }
@@ -4,14 +4,9 @@ package mooonwepay
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
-	"io"
-	"os"
-	"strings"
+	"net/http"
 
-	"github.com/eyjian/gomooon/moooncrypto"
-	"github.com/tjfoc/gmsm/sm3"
+	"github.com/eyjian/gomooon/mooonerror"
 	"github.com/wechatpay-apiv3/wechatpay-go/core"
 )
 
@@ -30,67 +25,42 @@ type DownloadReceiptResponse struct {
 	Message string `json:"message"`
 }
 
-// DownloadReceipt 下载电子回单
+var (
+	downloadReceiptErrTag = "DownloadReceipt failed to download electronic receipt"
+)
+
+// DownloadReceipt 下载电子回单，同 Client.DownloadReceipt 先写入临时文件，失败时按默认的次数和间隔续传（参见 WithDownloadRetry）
 // 官方文档：https://pay.weixin.qq.com/doc/v3/merchant/4013866774
 func DownloadReceipt(client *core.Client, req *DownloadReceiptRequest) (*DownloadReceiptResponse, error) {
-	// 发送 GET 请求
-	apiResult, err := client.Get(req.Ctx, req.DownloadUrl)
-	if err != nil {
-		return nil, fmt.Errorf("DownloadReceipt failed to download electronic receipt: %w", err)
-	}
-	defer apiResult.Response.Body.Close()
-
-	// 检查响应状态码
-	if apiResult.Response.StatusCode != 200 {
-		return nil, fmt.Errorf("DownloadReceipt failed with status code: %d", apiResult.Response.StatusCode)
-	}
-
-	// 读取响应体内容
-	respBody, err := io.ReadAll(apiResult.Response.Body)
-	if err != nil {
-		return nil, fmt.Errorf("DownloadReceipt failed to read response body: %w", err)
-	}
-
-	// 检查响应体是否为空
-	if len(respBody) == 0 {
-		return nil, fmt.Errorf("DownloadReceipt response body is empty")
-	}
-
-	resp := &DownloadReceiptResponse{}
-	err = json.Unmarshal(respBody, resp)
-	if err == nil {
-		return resp, nil
-	}
-
-	// 验证哈希值
-	hashType := strings.ToUpper(req.HashType)
-	if hashType == "SM3" {
-		hashValue := fmt.Sprintf("%X", sm3.Sm3Sum(respBody))
-		if req.HashValue != hashValue {
-			return nil, fmt.Errorf("DownloadReceipt failed to verify SM3 hash value: %s", hashValue)
-		}
-	} else if hashType == "SHA256" {
-		hashValue := moooncrypto.Sha256Sign(string(respBody), "")
-		if req.HashValue != hashValue {
-			return nil, fmt.Errorf("DownloadReceipt failed to verify SHA256 hash value: %s", hashValue)
+	fetch := func(ctx context.Context, header http.Header) (*http.Response, error) {
+		apiResult, err := client.Request(ctx, http.MethodGet, req.DownloadUrl, header, nil, nil, "")
+		if err != nil {
+			return nil, newCoreError(mooonerror.ErrCodeWepayDownload, downloadReceiptErrTag, err)
 		}
+		return apiResult.Response, nil
 	}
-
-	// 写入本地文件
-	file, err := os.Create(req.LocalFilePath)
+	err := downloadFile(req.Ctx, fetch, req.LocalFilePath, req.HashType, req.HashValue, downloadReceiptErrTag,
+		defaultDownloadRetries, defaultDownloadInterval, &WepayStatus{})
 	if err != nil {
-		return nil, fmt.Errorf("DownloadReceipt failed to create file: %w", err)
+		return nil, err
 	}
-	defer file.Close()
-	_, err = file.Write(respBody)
+	return newDownloadReceiptResponse(), nil
+}
+
+// DownloadReceipt 下载电子回单，同 DownloadReceipt 函数，但使用 Client 的商户号和私钥签名
+// 先写入临时文件，摘要匹配后才重命名为 LocalFilePath，失败时按 WithDownloadRetry 的设置续传
+// HashType 不是 SHA1、SHA256 或 SM3 时返回 ErrCodeInvalidParam 错误
+func (c *Client) DownloadReceipt(req *DownloadReceiptRequest) (*DownloadReceiptResponse, error) {
+	err := c.download(req.Ctx, req.DownloadUrl, req.LocalFilePath, req.HashType, req.HashValue, downloadReceiptErrTag, &WepayStatus{})
 	if err != nil {
-		os.Remove(req.LocalFilePath)
-		return nil, fmt.Errorf("DownloadReceipt failed to write file: %w", err)
+		return nil, err
 	}
+	return newDownloadReceiptResponse(), nil
+}
 
-	// 返回响应
+func newDownloadReceiptResponse() *DownloadReceiptResponse {
 	return &DownloadReceiptResponse{
 		Code:    "SUCCESS",
 		Message: "SUCCESS",
-	}, nil
-}
\ No newline at end of file
+	}
+}
@@ -0,0 +1,177 @@
+// Package mooonwepay
+// Wrote by yijian on 2026/10/18
+package mooonwepay
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/eyjian/gomooon/mooonerror"
+)
+
+// newBillServer 提供账单申请和下载，第一次下载只写入一半内容后断开连接，rangeSupported 为 false 时忽略 Range
+func newBillServer(t *testing.T, content []byte, hashValue string, rangeSupported bool) (*httptest.Server, *[]string) {
+	var ranges []string
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v3/billdownload/file" {
+			fmt.Fprintf(w, `{"hash_type":"SHA1","hash_value":"%s","download_url":"%s/v3/billdownload/file?token=xxx"}`, hashValue, server.URL)
+			return
+		}
+
+		rangeHeader := r.Header.Get("Range")
+		ranges = append(ranges, rangeHeader)
+		if len(ranges) == 1 {
+			// 声明完整长度，只写入一半后中断，客户端读到 unexpected EOF
+			w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write(content[:len(content)/2])
+			w.(http.Flusher).Flush()
+			panic(http.ErrAbortHandler)
+		}
+
+		var offset int
+		if rangeSupported && rangeHeader != "" {
+			if _, err := fmt.Sscanf(rangeHeader, "bytes=%d-", &offset); err != nil {
+				t.Errorf("invalid range header: %s", rangeHeader)
+			}
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", offset, len(content)-1, len(content)))
+			w.WriteHeader(http.StatusPartialContent)
+		}
+		_, _ = w.Write(content[offset:])
+	}))
+	return server, &ranges
+}
+
+// assertNoTempFile 检查目录下没有残留的临时文件
+func assertNoTempFile(t *testing.T, dir string, expected int) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != expected {
+		for _, entry := range entries {
+			t.Logf("found %s", entry.Name())
+		}
+		t.Errorf("expected %d files in %s, got %d", expected, dir, len(entries))
+	}
+}
+
+// go test -v -run="TestDownloadResume$"
+func TestDownloadResume(t *testing.T) {
+	content := gzipBill(t, testTradeBill)
+	for _, rangeSupported := range []bool{true, false} {
+		server, ranges := newBillServer(t, content, sha1Hex(testTradeBill), rangeSupported)
+
+		client, _ := newTestClient(t, server.URL)
+		client.downloadInterval = 0
+		dir := t.TempDir()
+		path := filepath.Join(dir, "trade_bill.gz")
+		resp, err := client.DownloadBill(context.Background(), "ALL", "GZIP", "2026-10-17", path)
+		server.Close()
+		if err != nil {
+			t.Fatalf("range supported %v: %v", rangeSupported, err)
+		}
+		if resp.HttpStatusCode != http.StatusOK || resp.HashType != "SHA1" {
+			t.Errorf("unexpected response: %+v", resp)
+		}
+
+		// 第二次请求从中断处续传
+		expectedRange := fmt.Sprintf("bytes=%d-", len(content)/2)
+		if len(*ranges) != 2 || (*ranges)[0] != "" || (*ranges)[1] != expectedRange {
+			t.Errorf("unexpected ranges: %q", *ranges)
+		}
+		downloaded, err := os.ReadFile(path)
+		if err != nil || !bytes.Equal(downloaded, content) {
+			t.Errorf("range supported %v: unexpected file content, %v", rangeSupported, err)
+		}
+		assertNoTempFile(t, dir, 1)
+	}
+}
+
+// go test -v -run="TestDownloadHashMismatch$"
+func TestDownloadHashMismatch(t *testing.T) {
+	server, _ := newBillServer(t, gzipBill(t, testTradeBill), sha1Hex("other"), true)
+	defer server.Close()
+
+	client, _ := newTestClient(t, server.URL)
+	client.downloadInterval = 0
+	dir := t.TempDir()
+	path := filepath.Join(dir, "trade_bill.gz")
+	_, err := client.DownloadBill(context.Background(), "ALL", "GZIP", "2026-10-17", path)
+	if mooonerror.Code(err) != mooonerror.ErrCodeWepayHashMismatch {
+		t.Fatalf("expected hash mismatch, got %v", err)
+	}
+	if _, err = os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("%s should not exist: %v", path, err)
+	}
+	assertNoTempFile(t, dir, 0)
+}
+
+// go test -v -run="TestDownloadRetryExhausted$"
+func TestDownloadRetryExhausted(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprint(w, `{"code":"SYSTEM_ERROR","message":"系统繁忙"}`)
+	}))
+	defer server.Close()
+
+	client, _ := newTestClient(t, server.URL)
+	client.downloadRetries = 2
+	client.downloadInterval = 0
+	dir := t.TempDir()
+	status := &WepayStatus{}
+	err := client.download(context.Background(), server.URL+"/v3/billdownload/file", filepath.Join(dir, "bill"), "", "", "test", status)
+	if wepayErr, ok := AsWepayError(err); !ok || wepayErr.Code != "SYSTEM_ERROR" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if requests != 3 || status.HttpStatusCode != http.StatusServiceUnavailable {
+		t.Errorf("expected 3 requests, got %d, status %+v", requests, status)
+	}
+	assertNoTempFile(t, dir, 0)
+}
+
+// go test -v -run="TestDownloadReceiptResume$"
+func TestDownloadReceiptResume(t *testing.T) {
+	content := []byte("%PDF-1.4 transfer receipt")
+	server, ranges := newBillServer(t, content, "", true)
+	defer server.Close()
+
+	client, _ := newTestClient(t, server.URL)
+	client.downloadInterval = 0
+	dir := t.TempDir()
+	req := &DownloadReceiptRequest{
+		Ctx:           context.Background(),
+		HashType:      "SHA1",
+		HashValue:     sha1Hex(string(content)),
+		DownloadUrl:   server.URL + "/v3/billdownload/file",
+		LocalFilePath: filepath.Join(dir, "receipt.pdf"),
+	}
+	resp, err := client.DownloadReceipt(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Code != "SUCCESS" || len(*ranges) != 2 || (*ranges)[1] != fmt.Sprintf("bytes=%d-", len(content)/2) {
+		t.Errorf("unexpected response %+v, ranges %q", resp, *ranges)
+	}
+	downloaded, err := os.ReadFile(req.LocalFilePath)
+	if err != nil || !bytes.Equal(downloaded, content) {
+		t.Errorf("unexpected file content: %s, %v", downloaded, err)
+	}
+	assertNoTempFile(t, dir, 1)
+
+	// 不支持的摘要类型
+	req.HashType = "MD5"
+	if _, err = client.DownloadReceipt(req); mooonerror.Code(err) != mooonerror.ErrCodeInvalidParam {
+		t.Errorf("expected invalid param, got %v", err)
+	}
+}
@@ -0,0 +1,111 @@
+// Package mooonwepay
+// Wrote by yijian on 2026/10/18
+package mooonwepay
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultFailureThreshold = 3           // 默认连续失败 3 次后熔断主域名
+	defaultOpenDuration     = time.Minute // 默认熔断 1 分钟
+)
+
+// legacyBreaker 供各 *Req 函数共用的熔断器，它们每次调用都生成新的 Client，需共用才能记住主域名的状态
+var legacyBreaker = newHostBreaker(defaultFailureThreshold, defaultOpenDuration)
+
+// hostBreaker 主域名的熔断器
+// 连续失败 failureThreshold 次后打开，打开期间请求直接发往备域名；
+// openDuration 之后半开，放行请求到主域名探测，成功则关闭，失败则再次打开
+type hostBreaker struct {
+	failureThreshold int
+	openDuration     time.Duration
+
+	mu        sync.Mutex
+	failures  int       // 连续失败次数
+	openUntil time.Time // 熔断截止时间
+}
+
+func newHostBreaker(failureThreshold int, openDuration time.Duration) *hostBreaker {
+	if failureThreshold < 1 {
+		failureThreshold = 1
+	}
+	return &hostBreaker{
+		failureThreshold: failureThreshold,
+		openDuration:     openDuration,
+	}
+}
+
+// allow 是否可以请求主域名
+func (b *hostBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return !time.Now().Before(b.openUntil)
+}
+
+// success 记录主域名请求成功
+func (b *hostBreaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures = 0
+	b.openUntil = time.Time{}
+}
+
+// failure 记录主域名请求失败，连续失败达到阈值时熔断
+func (b *hostBreaker) failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	if b.failures >= b.failureThreshold {
+		b.openUntil = time.Now().Add(b.openDuration)
+	}
+}
+
+// WithBackupHost 设置备域名，主域名连接失败、超时或返回 5xx 时，以新的随机串和时间戳重新签名后请求备域名
+// 域名为 PrimaryHost 时默认备域名为 BackupHost，其它域名默认没有备域名；为空表示不切换
+func WithBackupHost(backupHost string) ClientOption {
+	return func(c *Client) {
+		c.backupHost = backupHost
+		c.backupHostSet = true
+	}
+}
+
+// WithCircuitBreaker 设置主域名的熔断：连续失败 failureThreshold 次后，openDuration 内的请求直接发往备域名
+// 默认连续失败 3 次后熔断 1 分钟
+func WithCircuitBreaker(failureThreshold int, openDuration time.Duration) ClientOption {
+	return func(c *Client) {
+		c.breaker = newHostBreaker(failureThreshold, openDuration)
+	}
+}
+
+// withBreaker 设置熔断器，供多个 Client 共用
+func withBreaker(breaker *hostBreaker) ClientOption {
+	return func(c *Client) {
+		c.breaker = breaker
+	}
+}
+
+// getBackupUrl 将主域名的 url 转换为备域名的，不需要切换时返回空字符串
+// 账单和回单的下载地址由微信支付返回，同样以主域名开头时才切换
+func (c *Client) getBackupUrl(url string) string {
+	if c.backupHost == "" || c.backupHost == c.host || !strings.HasPrefix(url, c.host) {
+		return ""
+	}
+	return c.backupHost + url[len(c.host):]
+}
+
+// shouldFailover 请求主域名的结果是否需要切换到备域名：连接失败、超时或 5xx
+// ctx 已结束时不切换
+func shouldFailover(ctx context.Context, httpResp *http.Response, err error) bool {
+	if ctx != nil && ctx.Err() != nil {
+		return false
+	}
+	if err != nil {
+		return true
+	}
+	return httpResp.StatusCode >= http.StatusInternalServerError
+}
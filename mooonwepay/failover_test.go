@@ -0,0 +1,128 @@
+// Package mooonwepay
+// Wrote by yijian on 2026/10/18
+package mooonwepay
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// go test -v -run="TestFailover$"
+func TestFailover(t *testing.T) {
+	var primaryNonces, backupNonces []string
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		primaryNonces = append(primaryNonces, r.Header.Get("Authorization"))
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprint(w, `{"code":"SYSTEM_ERROR","message":"系统繁忙"}`)
+	}))
+	defer primary.Close()
+	backup := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		backupNonces = append(backupNonces, r.Header.Get("Authorization"))
+		fmt.Fprint(w, `{"hash_type":"SHA1"}`)
+	}))
+	defer backup.Close()
+
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := 0
+	client := NewClient(testMchid, testSerialNo, privateKey,
+		WithHost(primary.URL),
+		WithBackupHost(backup.URL),
+		WithCircuitBreaker(2, time.Minute),
+		WithNonceFunc(func() string {
+			n++
+			return fmt.Sprintf("nonce%d", n)
+		}))
+
+	for i := 0; i < 3; i++ {
+		resp, err := client.ApplyBill(context.Background(), "ALL", "GZIP", "2026-10-17")
+		if err != nil || resp.HashType != "SHA1" {
+			t.Fatalf("request %d: %+v, %v", i, resp, err)
+		}
+	}
+
+	// 前两次先请求主域名再切换到备域名，之后主域名熔断，直接请求备域名
+	if len(primaryNonces) != 2 || len(backupNonces) != 3 {
+		t.Errorf("primary: %d, backup: %d", len(primaryNonces), len(backupNonces))
+	}
+	// 切换时重新生成随机串并签名
+	if !strings.Contains(primaryNonces[0], `nonce_str="nonce1"`) || !strings.Contains(backupNonces[0], `nonce_str="nonce2"`) {
+		t.Errorf("failover should re-sign request:\n%s\n%s", primaryNonces[0], backupNonces[0])
+	}
+}
+
+// go test -v -run="TestLegacyClientNonce$"
+func TestLegacyClientNonce(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 只有首次请求使用指定的随机串和时间戳，切换备域名和重试时重新生成
+	client := newLegacyClient(nil, privateKey, PrimaryHost, testMchid, testSerialNo, "nonce1", 1700000000)
+	if nonce := client.nonceFunc(); nonce != "nonce1" {
+		t.Errorf("first nonce should be nonce1, got %s", nonce)
+	}
+	if nonce := client.nonceFunc(); nonce == "nonce1" || nonce == "" {
+		t.Errorf("retry should use a fresh nonce, got %s", nonce)
+	}
+	if timestamp := client.clock().Unix(); timestamp != 1700000000 {
+		t.Errorf("first timestamp should be 1700000000, got %d", timestamp)
+	}
+	if timestamp := client.clock().Unix(); timestamp == 1700000000 {
+		t.Errorf("retry should use a fresh timestamp, got %d", timestamp)
+	}
+}
+
+// go test -v -run="TestFailoverConnectionError$"
+func TestFailoverConnectionError(t *testing.T) {
+	primary := httptest.NewServer(http.NotFoundHandler())
+	primary.Close() // 连接被拒绝
+	backup := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"hash_type":"SHA1"}`)
+	}))
+	defer backup.Close()
+
+	client, _ := newTestClient(t, primary.URL)
+	if _, err := client.ApplyBill(context.Background(), "ALL", "GZIP", "2026-10-17"); err == nil {
+		t.Error("request without backup host should fail")
+	}
+
+	client = NewClient(testMchid, testSerialNo, client.privateKey, WithHost(primary.URL), WithBackupHost(backup.URL))
+	if _, err := client.ApplyBill(context.Background(), "ALL", "GZIP", "2026-10-17"); err != nil {
+		t.Errorf("request should fail over to backup host: %v", err)
+	}
+}
+
+// go test -v -run="TestHostBreaker$"
+func TestHostBreaker(t *testing.T) {
+	breaker := newHostBreaker(2, 50*time.Millisecond)
+	breaker.failure()
+	if !breaker.allow() {
+		t.Error("breaker should be closed after 1 failure")
+	}
+	breaker.failure()
+	if breaker.allow() {
+		t.Error("breaker should be open after 2 failures")
+	}
+
+	// 半开后探测成功则关闭
+	time.Sleep(60 * time.Millisecond)
+	if !breaker.allow() {
+		t.Error("breaker should be half-open")
+	}
+	breaker.success()
+	breaker.failure()
+	if !breaker.allow() {
+		t.Error("breaker should be closed after success")
+	}
+}
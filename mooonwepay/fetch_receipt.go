@@ -0,0 +1,245 @@
+// Package mooonwepay
+// Wrote by yijian on 2026/10/18
+package mooonwepay
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/eyjian/gomooon/mooonerror"
+	"github.com/wechatpay-apiv3/wechatpay-go/core"
+)
+
+// 取得电子回单需要：
+// 1. ApplyReceipt 申请，已申请过（ALREADY_EXISTS、RESOURCE_ALREADY_EXISTS）的直接查询，可重试的错误（如网络错误、SYSTEM_ERROR、FREQUENCY_LIMITED）按轮询间隔重试
+// 2. QueryReceipt 轮询，直到状态从 GENERATING 变为 FINISHED 或 FAILED，轮询间隔从 pollInterval 起逐次翻倍，不超过 maxPollInterval
+// 3. FINISHED 后以查询应答中的 HashType 和 HashValue 调用 DownloadReceipt 下载并校验
+// FetchReceipt 一次完成以上步骤，FetchReceipts 为其批量版本
+
+const (
+	defaultReceiptPollInterval    = time.Second      // 默认首次轮询间隔
+	defaultReceiptMaxPollInterval = 10 * time.Second // 默认最大轮询间隔
+	defaultReceiptMaxWait         = 5 * time.Minute  // 默认最长等待回单生成的时间
+	minReceiptPollInterval        = time.Millisecond // 最小轮询间隔，避免间隔为 0 时空转
+)
+
+// ReceiptClient 申请、查询和下载电子回单，*Client 实现了它，使用 *core.Client 的可通过 NewCoreReceiptClient 转换
+type ReceiptClient interface {
+	ApplyReceipt(req *ApplyReceiptRequest) (*ApplyReceiptResponse, error)
+	QueryReceipt(req *QueryReceiptRequest) (*QueryReceiptResponse, error)
+	DownloadReceipt(req *DownloadReceiptRequest) (*DownloadReceiptResponse, error)
+}
+
+// coreReceiptClient 以 ApplyReceipt、QueryReceipt 和 DownloadReceipt 函数实现 ReceiptClient
+type coreReceiptClient struct {
+	client *core.Client
+}
+
+// NewCoreReceiptClient 将微信支付官方 SDK 的 *core.Client 转换为 ReceiptClient
+func NewCoreReceiptClient(client *core.Client) ReceiptClient {
+	return &coreReceiptClient{client: client}
+}
+
+func (c *coreReceiptClient) ApplyReceipt(req *ApplyReceiptRequest) (*ApplyReceiptResponse, error) {
+	return ApplyReceipt(c.client, req)
+}
+
+func (c *coreReceiptClient) QueryReceipt(req *QueryReceiptRequest) (*QueryReceiptResponse, error) {
+	return QueryReceipt(c.client, req)
+}
+
+func (c *coreReceiptClient) DownloadReceipt(req *DownloadReceiptRequest) (*DownloadReceiptResponse, error) {
+	return DownloadReceipt(c.client, req)
+}
+
+// FetchReceiptOption FetchReceipt 和 FetchReceipts 的可选项
+type FetchReceiptOption func(*fetchReceiptOptions)
+
+type fetchReceiptOptions struct {
+	pollInterval    time.Duration
+	maxPollInterval time.Duration
+	maxWait         time.Duration
+}
+
+// WithReceiptPollInterval 设置轮询间隔，首次为 interval，之后逐次翻倍，不超过 maxInterval，默认为 1 秒和 10 秒
+// 小于 1 毫秒的间隔按 1 毫秒处理
+func WithReceiptPollInterval(interval, maxInterval time.Duration) FetchReceiptOption {
+	return func(o *fetchReceiptOptions) {
+		o.pollInterval = interval
+		o.maxPollInterval = maxInterval
+	}
+}
+
+// WithReceiptMaxWait 设置最长等待回单生成的时间，超过时返回仍为 GENERATING 的结果和错误，默认 5 分钟
+// ctx 的截止时间更早时以 ctx 为准
+func WithReceiptMaxWait(maxWait time.Duration) FetchReceiptOption {
+	return func(o *fetchReceiptOptions) {
+		o.maxWait = maxWait
+	}
+}
+
+// ReceiptResult 取得一张电子回单的结果
+type ReceiptResult struct {
+	OutBillNo  string
+	DestPath   string
+	State      string // 最后查询到的状态，取值参见 receipt_state.go，未查询到时为空
+	FailReason string // State 为 FAILED 时的失败原因
+	HashType   string // 回单文件的摘要类型，取值参见 receipt_state.go
+	HashValue  string
+	Polls      int   // 调用 QueryReceipt 的次数
+	Err        error // 可通过 mooonerror.Code 取得错误码，mooonerror.IsRetryable 判断是否可重试
+}
+
+// FetchReceipt 申请并等待商户单号 outBillNo 的电子回单生成，下载并校验后存放到 destPath
+// 返回的 ReceiptResult 总不为 nil，其 Err 与返回的 error 相同：
+// 回单生成失败时为 ErrCodeWepayResponse 错误，等待超时时为可重试的 ErrCodeWepayRequest 错误，摘要不匹配时为 ErrCodeWepayHashMismatch 错误
+func FetchReceipt(ctx context.Context, client ReceiptClient, outBillNo, destPath string, opts ...FetchReceiptOption) (*ReceiptResult, error) {
+	options := newFetchReceiptOptions(opts)
+	result := &ReceiptResult{OutBillNo: outBillNo, DestPath: destPath}
+	result.Err = fetchReceipt(ctx, client, options, result)
+	return result, result.Err
+}
+
+// FetchReceipts 以不超过 concurrency 的并发数批量取得电子回单，destPaths 的 key 为商户单号，value 为存放回单的路径
+// 返回的结果与 outBillNos 一一对应，每张回单的错误记录在各自的 Err 中，ctx 结束后未开始的回单的 Err 为 ctx 的错误
+// outBillNos 指定处理顺序，须都在 destPaths 中
+func FetchReceipts(ctx context.Context, client ReceiptClient, concurrency int, outBillNos []string, destPaths map[string]string, opts ...FetchReceiptOption) []*ReceiptResult {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	options := newFetchReceiptOptions(opts)
+	results := make([]*ReceiptResult, len(outBillNos))
+
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, concurrency)
+	for i, outBillNo := range outBillNos {
+		result := &ReceiptResult{OutBillNo: outBillNo, DestPath: destPaths[outBillNo]}
+		results[i] = result
+		if result.DestPath == "" {
+			result.Err = mooonerror.Errorf(mooonerror.ErrCodeInvalidParam, "FetchReceipts: dest path of %s is empty", outBillNo)
+			continue
+		}
+
+		select {
+		case semaphore <- struct{}{}: // 获取信号量，限制并发数量
+		case <-ctx.Done():
+			result.Err = mooonerror.Errorf(mooonerror.ErrCodeWepayRequest, "FetchReceipts: %w", ctx.Err())
+			continue
+		}
+		wg.Add(1)
+		go func(result *ReceiptResult) {
+			defer wg.Done()
+			defer func() { <-semaphore }() // 释放信号量
+
+			result.Err = fetchReceipt(ctx, client, options, result)
+		}(result)
+	}
+
+	wg.Wait()
+	return results
+}
+
+func newFetchReceiptOptions(opts []FetchReceiptOption) *fetchReceiptOptions {
+	options := &fetchReceiptOptions{
+		pollInterval:    defaultReceiptPollInterval,
+		maxPollInterval: defaultReceiptMaxPollInterval,
+		maxWait:         defaultReceiptMaxWait,
+	}
+	for _, opt := range opts {
+		opt(options)
+	}
+	options.pollInterval = max(options.pollInterval, minReceiptPollInterval)
+	if options.maxPollInterval < options.pollInterval {
+		options.maxPollInterval = options.pollInterval
+	}
+	return options
+}
+
+// fetchReceipt 申请、轮询和下载，过程中的状态记录在 result 中
+func fetchReceipt(ctx context.Context, client ReceiptClient, options *fetchReceiptOptions, result *ReceiptResult) error {
+	// 申请和轮询受 maxWait 限制，下载不受
+	waitCtx, cancel := context.WithTimeout(ctx, options.maxWait)
+	defer cancel()
+
+	// 申请，可重试的错误等待后再申请，与轮询共用间隔
+	interval := options.pollInterval
+	for {
+		applyResp, err := client.ApplyReceipt(&ApplyReceiptRequest{Ctx: waitCtx, OutBillNo: result.OutBillNo})
+		if err == nil {
+			result.State = applyResp.State
+			break
+		}
+		if wepayErr, ok := AsWepayError(err); ok && (wepayErr.Code == "ALREADY_EXISTS" || wepayErr.Code == "RESOURCE_ALREADY_EXISTS") {
+			break
+		}
+		if !mooonerror.IsRetryable(err) {
+			return err
+		}
+		if err = waitReceipt(ctx, waitCtx, interval, "apply", result, err); err != nil {
+			return err
+		}
+		interval = min(interval*2, options.maxPollInterval)
+	}
+
+	// 轮询，申请应答中没有下载地址，即使已是 FINISHED 也需要查询
+	var (
+		queryResp *QueryReceiptResponse
+		err       error
+	)
+	for {
+		queryResp, err = client.QueryReceipt(&QueryReceiptRequest{Ctx: waitCtx, OutBillNo: result.OutBillNo})
+		result.Polls++
+		if err != nil && !mooonerror.IsRetryable(err) {
+			return err
+		}
+		if err == nil {
+			result.State = queryResp.State
+			result.FailReason = queryResp.FailReason
+			if queryResp.State == ReceiptStateFinished {
+				break
+			}
+			if queryResp.State == ReceiptStateFailed {
+				return mooonerror.Errorf(mooonerror.ErrCodeWepayResponse, "FetchReceipt: receipt of %s failed: %s", result.OutBillNo, queryResp.FailReason)
+			}
+		}
+
+		// GENERATING 或可重试的错误，等待后再查询
+		if err = waitReceipt(ctx, waitCtx, interval, "wait for", result, err); err != nil {
+			return err
+		}
+		interval = min(interval*2, options.maxPollInterval)
+	}
+
+	// 下载并校验
+	result.HashType = queryResp.HashType
+	result.HashValue = queryResp.HashValue
+	_, err = client.DownloadReceipt(&DownloadReceiptRequest{
+		Ctx:           ctx,
+		HashType:      queryResp.HashType,
+		HashValue:     queryResp.HashValue,
+		DownloadUrl:   queryResp.DownloadUrl,
+		LocalFilePath: result.DestPath,
+	})
+	return err
+}
+
+// waitReceipt 等待 interval 后返回 nil，waitCtx 先结束时返回 ErrCodeWepayRequest 错误，cause 为最后一次请求的错误
+// 仅当 maxWait 到期而 ctx 仍有效时错误可重试，ctx 被取消或到期时重试没有意义
+func waitReceipt(ctx, waitCtx context.Context, interval time.Duration, action string, result *ReceiptResult, cause error) error {
+	timer := time.NewTimer(interval)
+	select {
+	case <-waitCtx.Done():
+		timer.Stop()
+		if cause == nil {
+			cause = waitCtx.Err()
+		}
+		err := mooonerror.Errorf(mooonerror.ErrCodeWepayRequest, "FetchReceipt: %s receipt of %s (state %s): %w", action, result.OutBillNo, result.State, cause)
+		if ctx.Err() == nil {
+			return err.WithRetryable()
+		}
+		return err
+	case <-timer.C:
+		return nil
+	}
+}
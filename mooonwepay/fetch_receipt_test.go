@@ -0,0 +1,217 @@
+// Package mooonwepay
+// Wrote by yijian on 2026/10/18
+package mooonwepay
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/eyjian/gomooon/mooonerror"
+)
+
+var _ ReceiptClient = (*Client)(nil)
+
+// fakeReceiptClient 测试用的 ReceiptClient，每张回单查询 generating 次后变为 finalState
+type fakeReceiptClient struct {
+	generating int
+	finalState string
+	applyErrs  []string // 依次返回的申请错误码
+	applies    int
+
+	mu      sync.Mutex
+	queries map[string]int
+
+	running    int32
+	maxRunning int32
+}
+
+func (c *fakeReceiptClient) ApplyReceipt(req *ApplyReceiptRequest) (*ApplyReceiptResponse, error) {
+	c.mu.Lock()
+	c.applies++
+	if len(c.applyErrs) > 0 {
+		code := c.applyErrs[0]
+		c.applyErrs = c.applyErrs[1:]
+		c.mu.Unlock()
+		return nil, newWepayError(mooonerror.ErrCodeWepayResponse, "ApplyReceipt", &WepayStatus{Code: code, HttpStatusCode: http.StatusInternalServerError})
+	}
+	c.mu.Unlock()
+
+	running := atomic.AddInt32(&c.running, 1)
+	for {
+		maxRunning := atomic.LoadInt32(&c.maxRunning)
+		if running <= maxRunning || atomic.CompareAndSwapInt32(&c.maxRunning, maxRunning, running) {
+			break
+		}
+	}
+	return &ApplyReceiptResponse{State: ReceiptStateGenerating}, nil
+}
+
+func (c *fakeReceiptClient) QueryReceipt(req *QueryReceiptRequest) (*QueryReceiptResponse, error) {
+	c.mu.Lock()
+	c.queries[req.OutBillNo]++
+	n := c.queries[req.OutBillNo]
+	c.mu.Unlock()
+
+	if n <= c.generating {
+		return &QueryReceiptResponse{State: ReceiptStateGenerating}, nil
+	}
+	if c.finalState == ReceiptStateFailed {
+		return &QueryReceiptResponse{State: ReceiptStateFailed, FailReason: "转账单不存在"}, nil
+	}
+	return &QueryReceiptResponse{State: c.finalState, DownloadUrl: "https://example.com/" + req.OutBillNo}, nil
+}
+
+func (c *fakeReceiptClient) DownloadReceipt(req *DownloadReceiptRequest) (*DownloadReceiptResponse, error) {
+	defer atomic.AddInt32(&c.running, -1)
+	if err := os.WriteFile(req.LocalFilePath, []byte(req.DownloadUrl), 0644); err != nil {
+		return nil, err
+	}
+	return &DownloadReceiptResponse{Code: "SUCCESS", Message: "SUCCESS"}, nil
+}
+
+// go test -v -run="TestFetchReceipt$"
+func TestFetchReceipt(t *testing.T) {
+	content := []byte("%PDF-1.4 receipt")
+	sum := sha256.Sum256(content)
+	hashValue := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	var queries int32
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == ApplyReceiptByOutBillNoPath:
+			// 已申请过
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"code":"ALREADY_EXISTS","message":"电子回单申请单已存在"}`)
+		case r.URL.Path == fmt.Sprintf(QueryReceiptByOutBillNoPath, "B001"):
+			if atomic.AddInt32(&queries, 1) == 1 {
+				fmt.Fprint(w, `{"state":"GENERATING"}`)
+				return
+			}
+			fmt.Fprintf(w, `{"state":"FINISHED","hash_type":"SHA256","hash_value":"%s","download_url":"%s/v3/transfer/download/elecsign?token=xxx"}`, hashValue, server.URL)
+		case r.URL.Path == "/v3/transfer/download/elecsign":
+			_, _ = w.Write(content)
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client, _ := newTestClient(t, server.URL)
+	destPath := filepath.Join(t.TempDir(), "B001.pdf")
+	result, err := FetchReceipt(context.Background(), client, "B001", destPath, WithReceiptPollInterval(time.Millisecond, 2*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.State != ReceiptStateFinished || result.Polls != 2 || result.HashType != HashTypeSHA256 || result.HashValue != hashValue {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	downloaded, err := os.ReadFile(destPath)
+	if err != nil || string(downloaded) != string(content) {
+		t.Errorf("unexpected file content: %s, %v", downloaded, err)
+	}
+}
+
+// go test -v -run="TestFetchReceiptFailed$"
+func TestFetchReceiptFailed(t *testing.T) {
+	client := &fakeReceiptClient{generating: 1, finalState: ReceiptStateFailed, queries: map[string]int{}}
+	destPath := filepath.Join(t.TempDir(), "B001.pdf")
+	result, err := FetchReceipt(context.Background(), client, "B001", destPath, WithReceiptPollInterval(time.Millisecond, time.Millisecond))
+	if mooonerror.Code(err) != mooonerror.ErrCodeWepayResponse || mooonerror.IsRetryable(err) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Err != err || result.State != ReceiptStateFailed || result.FailReason != "转账单不存在" || result.Polls != 2 {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	if _, err = os.Stat(destPath); !os.IsNotExist(err) {
+		t.Errorf("%s should not exist: %v", destPath, err)
+	}
+}
+
+// go test -v -run="TestFetchReceiptApplyRetry$"
+func TestFetchReceiptApplyRetry(t *testing.T) {
+	// 可重试的申请错误按轮询间隔重试
+	client := &fakeReceiptClient{finalState: ReceiptStateFinished, applyErrs: []string{"SYSTEM_ERROR", "FREQUENCY_LIMITED"}, queries: map[string]int{}}
+	destPath := filepath.Join(t.TempDir(), "B001.pdf")
+	result, err := FetchReceipt(context.Background(), client, "B001", destPath, WithReceiptPollInterval(time.Millisecond, time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.applies != 3 || result.State != ReceiptStateFinished {
+		t.Errorf("unexpected result: %+v, applies %d", result, client.applies)
+	}
+
+	// 不可重试的申请错误直接返回
+	client = &fakeReceiptClient{finalState: ReceiptStateFinished, applyErrs: []string{"PARAM_ERROR"}, queries: map[string]int{}}
+	_, err = FetchReceipt(context.Background(), client, "B001", destPath, WithReceiptPollInterval(time.Millisecond, time.Millisecond))
+	if wepayErr, ok := AsWepayError(err); !ok || wepayErr.Code != "PARAM_ERROR" || client.applies != 1 {
+		t.Errorf("unexpected error: %v, applies %d", err, client.applies)
+	}
+}
+
+// go test -v -run="TestFetchReceiptTimeout$"
+func TestFetchReceiptTimeout(t *testing.T) {
+	client := &fakeReceiptClient{generating: 1 << 30, finalState: ReceiptStateFinished, queries: map[string]int{}}
+	result, err := FetchReceipt(context.Background(), client, "B001", filepath.Join(t.TempDir(), "B001.pdf"),
+		WithReceiptPollInterval(time.Millisecond, 4*time.Millisecond),
+		WithReceiptMaxWait(50*time.Millisecond))
+	if mooonerror.Code(err) != mooonerror.ErrCodeWepayRequest || !mooonerror.IsRetryable(err) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.State != ReceiptStateGenerating || result.Polls < 2 {
+		t.Errorf("unexpected result: %+v", result)
+	}
+
+	// ctx 到期时不可重试，间隔为 0 时按最小间隔轮询而不空转
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	result, err = FetchReceipt(ctx, client, "B002", filepath.Join(t.TempDir(), "B002.pdf"), WithReceiptPollInterval(0, 0))
+	if mooonerror.Code(err) != mooonerror.ErrCodeWepayRequest || mooonerror.IsRetryable(err) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Polls > 25 {
+		t.Errorf("too many polls: %d", result.Polls)
+	}
+}
+
+// go test -v -run="TestFetchReceipts$"
+func TestFetchReceipts(t *testing.T) {
+	client := &fakeReceiptClient{generating: 2, finalState: ReceiptStateFinished, queries: map[string]int{}}
+	dir := t.TempDir()
+	outBillNos := []string{"B001", "B002", "B003", "B004", "B005", "B006"}
+	destPaths := make(map[string]string)
+	for _, outBillNo := range outBillNos[:5] { // B006 没有存放路径
+		destPaths[outBillNo] = filepath.Join(dir, outBillNo+".pdf")
+	}
+
+	results := FetchReceipts(context.Background(), client, 2, outBillNos, destPaths, WithReceiptPollInterval(time.Millisecond, time.Millisecond))
+	if len(results) != len(outBillNos) {
+		t.Fatalf("expected %d results, got %d", len(outBillNos), len(results))
+	}
+	for i, result := range results[:5] {
+		if result.OutBillNo != outBillNos[i] || result.Err != nil || result.State != ReceiptStateFinished || result.Polls != 3 {
+			t.Errorf("unexpected result: %+v", result)
+		}
+		if content, err := os.ReadFile(result.DestPath); err != nil || !strings.HasSuffix(string(content), result.OutBillNo) {
+			t.Errorf("unexpected file content of %s: %s, %v", result.OutBillNo, content, err)
+		}
+	}
+	if mooonerror.Code(results[5].Err) != mooonerror.ErrCodeInvalidParam {
+		t.Errorf("unexpected result: %+v", results[5])
+	}
+	if client.maxRunning > 2 {
+		t.Errorf("expected at most 2 running, got %d", client.maxRunning)
+	}
+}
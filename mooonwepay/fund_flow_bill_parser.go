@@ -0,0 +1,104 @@
+// Package mooonwepay
+// Wrote by yijian on 2026/10/18
+package mooonwepay
+
+import (
+	"io"
+	"time"
+)
+
+// FundFlowRecord 资金账单中的一条资金流水，金额单位为分
+type FundFlowRecord struct {
+	BookTime      time.Time // 记账时间
+	TransactionId string    // 微信支付业务单号
+	FlowId        string    // 资金流水单号
+	BizName       string    // 业务名称
+	BizType       string    // 业务类型，如 交易、退款
+	FlowType      string    // 收支类型：收入或支出
+	Amount        int64     // 收支金额，总为非负数，方向见 FlowType
+	Balance       int64     // 账户结余
+	Applicant     string    // 资金变更提交申请人
+	Remark        string    // 备注
+	BizVoucherId  string    // 业务凭证号
+
+	Fields map[string]string // 所有字段，键为表头
+}
+
+// FundFlowSummary 资金账单的汇总，金额单位为分
+type FundFlowSummary struct {
+	TotalCount    int64 // 资金流水总笔数
+	IncomeCount   int64 // 收入笔数
+	IncomeAmount  int64 // 收入金额
+	ExpenseCount  int64 // 支出笔数
+	ExpenseAmount int64 // 支出金额
+
+	Fields map[string]string // 所有汇总字段，键为汇总表头
+}
+
+// FundFlowBillParser 资金账单（ApplyBill 的 BASIC、OPERATION、FEES）的流式解析器，用法同 TradeBillParser
+type FundFlowBillParser struct {
+	parser *billParser
+}
+
+// NewFundFlowBillParser 生成资金账单解析器并读取表头
+func NewFundFlowBillParser(r io.Reader, opts ...BillParserOption) (*FundFlowBillParser, error) {
+	parser, err := newBillParser(r, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &FundFlowBillParser{parser: parser}, nil
+}
+
+// Next 返回下一条记录，没有更多记录时返回 io.EOF
+func (p *FundFlowBillParser) Next() (*FundFlowRecord, error) {
+	row, err := p.parser.next()
+	if err != nil {
+		return nil, err
+	}
+	record := &FundFlowRecord{
+		BookTime:      row.time("记账时间"),
+		TransactionId: row.str("微信支付业务单号"),
+		FlowId:        row.str("资金流水单号"),
+		BizName:       row.str("业务名称"),
+		BizType:       row.str("业务类型"),
+		FlowType:      row.str("收支类型"),
+		Amount:        row.amount("收支金额（元）", "收支金额(元)", "收支金额"),
+		Balance:       row.amount("账户结余（元）", "账户结余(元)", "账户结余"),
+		Applicant:     row.str("资金变更提交申请人"),
+		Remark:        row.str("备注"),
+		BizVoucherId:  row.str("业务凭证号"),
+
+		Fields: row.fieldMap(),
+	}
+	if row.err != nil {
+		return nil, row.err
+	}
+	return record, nil
+}
+
+// Summary 返回汇总，需在 Next 返回 io.EOF 之后调用，账单没有汇总时返回 nil, nil
+// 汇总中的金额或笔数格式错误时返回 ErrCodeWepayDecode 错误
+func (p *FundFlowBillParser) Summary() (*FundFlowSummary, error) {
+	row := p.parser.summary
+	if row == nil {
+		return nil, nil
+	}
+	summary := &FundFlowSummary{
+		TotalCount:    row.count("资金流水总笔数"),
+		IncomeCount:   row.count("收入笔数"),
+		IncomeAmount:  row.amount("收入金额"),
+		ExpenseCount:  row.count("支出笔数"),
+		ExpenseAmount: row.amount("支出金额"),
+
+		Fields: row.fieldMap(),
+	}
+	if row.err != nil {
+		return nil, row.err
+	}
+	return summary, nil
+}
+
+// Close 释放解压使用的资源，不关闭传入的 io.Reader
+func (p *FundFlowBillParser) Close() error {
+	return p.parser.Close()
+}
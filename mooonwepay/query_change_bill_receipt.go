@@ -5,14 +5,9 @@ package mooonwepay
 import (
 	"context"
 	"crypto/rsa"
-	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 )
-import (
-	"github.com/eyjian/gomooon/moooncrypto"
-)
 
 var (
 	QueryChangeBillBatchReceiptPath  = "/v3/transfer/bill-receipt"
@@ -50,92 +45,37 @@ type QueryChangeBillReceiptResp struct {
 	CreateTime      string `json:"create_time,omitempty"`      // 电子签章单创建时间，按照使用 rfc3339 所定义的格式，格式为 YYYY-MM-DDThh:mm:ss+TIMEZONE
 	UpdateTime      string `json:"update_time,omitempty"`      // 电子签章单最近一次状态变更的时间，按照使用 rfc3339 所定义的格式，格式为 YYYY-MM-DDThh:mm:ss+TIMEZONE
 
-	Code    string `json:"code,omitempty"`
-	Message string `json:"message,omitempty"`
-
-	HttpStatusCode int `json:"http_status_code,omitempty"`
+	WepayStatus
 }
 
 // QueryChangeBillReceipt 查询转账电子回单
+// 仍保留以兼容旧的调用方式，新代码请使用 Client.QueryChangeBillReceipt
 func QueryChangeBillReceipt(req *QueryChangeBillReceiptReq) (*QueryChangeBillReceiptResp, error) {
-	ctx := req.Ctx
-	url := getQueryChangeBillReceiptUrl(req)
-
-	// 计算签名
-	signatureString := makeQueryChangeBillReceiptSignatureString(req)
-	signature, err := moooncrypto.RsaSha256SignWithPrivateKey(req.PrivateKey, []byte(signatureString))
-	if err != nil {
-		return nil, fmt.Errorf("%s: rsa sha256 sign error: %s", queryChangeBillReceiptErrTag, err.Error())
-	}
-
-	// 生成 Authorization
-	authorization := makeChangeBillAuthorization(req.Mchid, req.SerialNo, req.NonceStr, signature, req.Timestamp)
-
-	// 构建请求
-	httpReq, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("%s: new http request error: %s", queryChangeBillReceiptErrTag, err.Error())
-	}
-
-	// 设置请求头
-	httpReq.Header.Set("Authorization", authorization)
-	httpReq.Header.Set("Accept", "application/json")
-	httpReq.Header.Set("Content-Type", "application/json")
-	httpResp, err := req.HttpClient.Do(httpReq)
-	if err != nil {
-		return nil, fmt.Errorf("%s: do http request error: %s", queryChangeBillReceiptErrTag, err.Error())
-	}
-	defer httpResp.Body.Close()
+	client := newLegacyClient(req.HttpClient, req.PrivateKey, req.Host, req.Mchid, req.SerialNo, req.NonceStr, req.Timestamp)
+	return client.QueryChangeBillReceipt(req.Ctx, req.OutBatchNo, req.OutDetailNo, req.AcceptType)
+}
 
-	// 读取响应
-	resp := &QueryChangeBillReceiptResp{
-		HttpStatusCode: httpResp.StatusCode,
-	}
-	respBodyBytes, err := io.ReadAll(httpResp.Body)
+// QueryChangeBillReceipt 查询转账电子回单
+// outBatchNo 为商家转账批次单号；outDetailNo 和 acceptType 均不为空时表示单笔转账电子回单，取值参见 QueryChangeBillReceiptReq
+func (c *Client) QueryChangeBillReceipt(ctx context.Context, outBatchNo, outDetailNo, acceptType string) (*QueryChangeBillReceiptResp, error) {
+	resp := &QueryChangeBillReceiptResp{}
+	url := getQueryChangeBillReceiptUrl(c.host, outBatchNo, outDetailNo, acceptType)
+	err := c.doJSON(ctx, http.MethodGet, url, "", queryChangeBillReceiptErrTag, resp, &resp.WepayStatus)
 	if err != nil {
-		return nil, fmt.Errorf("%s: read http body error: %s", queryChangeBillReceiptErrTag, err.Error())
-	}
-
-	// 解析响应
-	err = json.Unmarshal(respBodyBytes, resp)
-	if httpResp.StatusCode != http.StatusOK {
-		// {"code":"RESOURCE_ALREADY_EXISTS","message":"该批次回单已申请，您可在通过查询电子回单接口来获取单据信息"}
-		if httpResp.StatusCode == http.StatusUnauthorized {
-			return resp, fmt.Errorf("%s: unauthorized, possible authorization incorrect or out_batch_no error", queryChangeBillReceiptErrTag)
-		} else {
-			return resp, fmt.Errorf("%s: http response %d", queryChangeBillReceiptErrTag, httpResp.StatusCode)
+		if isWepayResponseError(err) {
+			return resp, err
 		}
+		return nil, err
 	}
-	if err != nil {
-		return nil, fmt.Errorf("%s: json unmarshal http response error: %s\n", queryChangeBillReceiptErrTag, err.Error())
-	}
-
 	return resp, nil
 }
 
-// makeQueryBillReceiptSignatureString 生成签名串
-//HTTP请求方法\n
-//URL\n
-//请求时间戳\n
-//请求随机串\n
-//请求报文主体\n
-func makeQueryChangeBillReceiptSignatureString(req *QueryChangeBillReceiptReq) string {
-	if req.AcceptType == "" || req.OutDetailNo == "" {
-		return fmt.Sprintf("GET\n%s/%s\n%d\n%s\n\n",
-			QueryChangeBillBatchReceiptPath, req.OutBatchNo, req.Timestamp, req.NonceStr)
-	} else {
-		return fmt.Sprintf("GET\n%s?out_batch_no=%s&out_detail_no=%s&accept_type=%s\n%d\n%s\n\n",
-			QueryChangeBillDetailReceiptPath, req.OutBatchNo, req.OutDetailNo, req.AcceptType,
-			req.Timestamp, req.NonceStr)
-	}
-}
-
-func getQueryChangeBillReceiptUrl(req *QueryChangeBillReceiptReq) string {
-	if req.AcceptType == "" || req.OutDetailNo == "" {
+func getQueryChangeBillReceiptUrl(host, outBatchNo, outDetailNo, acceptType string) string {
+	if acceptType == "" || outDetailNo == "" {
 		return fmt.Sprintf("%s%s/%s",
-			req.Host, QueryChangeBillBatchReceiptPath, req.OutBatchNo)
+			host, QueryChangeBillBatchReceiptPath, outBatchNo)
 	} else {
 		return fmt.Sprintf("%s%s?out_batch_no=%s&out_detail_no=%s&accept_type=%s",
-			req.Host, QueryChangeBillDetailReceiptPath, req.OutBatchNo, req.OutDetailNo, req.AcceptType)
+			host, QueryChangeBillDetailReceiptPath, outBatchNo, outDetailNo, acceptType)
 	}
 }
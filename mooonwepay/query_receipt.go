@@ -7,7 +7,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"net/http"
 
+	"github.com/eyjian/gomooon/mooonerror"
 	"github.com/wechatpay-apiv3/wechatpay-go/core"
 )
 
@@ -26,39 +28,66 @@ type QueryReceiptResponse struct {
 	HashType    string `json:"hash_type"` // 取值参见 receipt_state.go 中的定义
 	HashValue   string `json:"hash_value"`
 	DownloadUrl string `json:"download_url"`
+	FailReason  string `json:"fail_reason,omitempty"` // 状态为 FAILED 时的失败原因
 }
 
+var (
+	QueryReceiptByOutBillNoPath      = "/v3/fund-app/mch-transfer/elecsign/out-bill-no/%s"      // 按商户单号查询电子回单
+	QueryReceiptByTransferBillNoPath = "/v3/fund-app/mch-transfer/elecsign/transfer-bill-no/%s" // 按微信单号查询电子回单
+	queryReceiptErrTag               = "QueryReceipt failed to query electronic receipt"
+)
+
 // QueryReceipt 查询电子回单
 func QueryReceipt(client *core.Client, req *QueryReceiptRequest) (*QueryReceiptResponse, error) {
-	// 定义请求 URL
-	var url string
-	if req.OutBillNo != "" {
-		url = fmt.Sprintf("https://api.mch.weixin.qq.com/v3/fund-app/mch-transfer/elecsign/out-bill-no/%s", req.OutBillNo)
-	} else if req.WepayBillNo != "" {
-		url = fmt.Sprintf("https://api.mch.weixin.qq.com/v3/fund-app/mch-transfer/elecsign/transfer-bill-no/%s", req.WepayBillNo)
-	} else {
-		return nil, fmt.Errorf("QueryReceipt with invalid request: out_bill_no and wepay_bill_no are both empty")
+	path, err := getQueryReceiptPath(req)
+	if err != nil {
+		return nil, err
 	}
 
 	// 发送 GET 请求
-	apiResult, err := client.Get(req.Ctx, url)
+	apiResult, err := client.Get(req.Ctx, PrimaryHost+path)
 	if err != nil {
-		return nil, fmt.Errorf("QueryReceipt failed to query electronic receipt: %w", err)
+		return nil, newCoreError(mooonerror.ErrCodeWepayRequest, queryReceiptErrTag, err)
 	}
 
 	// 读取响应体内容
 	defer apiResult.Response.Body.Close()
 	respBody, err := io.ReadAll(apiResult.Response.Body)
 	if err != nil {
-		return nil, fmt.Errorf("QueryReceipt failed to read response body: %w", err)
+		return nil, mooonerror.Errorf(mooonerror.ErrCodeWepayDecode, "QueryReceipt failed to read response body: %w", err)
 	}
 
 	// 解析响应
 	var resp QueryReceiptResponse
 	if err := json.Unmarshal(respBody, &resp); err != nil {
-		return nil, fmt.Errorf("QueryReceipt failed to unmarshal response: %w", err)
+		return nil, mooonerror.Errorf(mooonerror.ErrCodeWepayDecode, "QueryReceipt failed to unmarshal response: %w", err)
 	}
 
 	// 返回响应
 	return &resp, nil
-}
\ No newline at end of file
+}
+
+// QueryReceipt 查询电子回单，同 QueryReceipt 函数，但使用 Client 的商户号和私钥签名
+func (c *Client) QueryReceipt(req *QueryReceiptRequest) (*QueryReceiptResponse, error) {
+	path, err := getQueryReceiptPath(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp QueryReceiptResponse
+	if err := c.doJSON(req.Ctx, http.MethodGet, c.host+path, "", queryReceiptErrTag, &resp, &WepayStatus{}); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// getQueryReceiptPath 取得查询电子回单的请求路径
+func getQueryReceiptPath(req *QueryReceiptRequest) (string, error) {
+	if req.OutBillNo != "" {
+		return fmt.Sprintf(QueryReceiptByOutBillNoPath, req.OutBillNo), nil
+	} else if req.WepayBillNo != "" {
+		return fmt.Sprintf(QueryReceiptByTransferBillNoPath, req.WepayBillNo), nil
+	} else {
+		return "", mooonerror.Errorf(mooonerror.ErrCodeInvalidParam, "QueryReceipt with invalid request: out_bill_no and wepay_bill_no are both empty")
+	}
+}
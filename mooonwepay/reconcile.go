@@ -0,0 +1,343 @@
+// Package mooonwepay
+// Wrote by yijian on 2026/10/18
+package mooonwepay
+
+import (
+	"context"
+	"encoding/csv"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/eyjian/gomooon/mooonerror"
+)
+
+// 对账：将微信支付的交易账单与我方账本逐笔核对
+// 1. 账单中同一商户订单号的支付行和退款行合并为一笔 BillOrder，手续费为各行之和（退款行的手续费为负数）
+// 2. 按商户订单号匹配，商户订单号为空时按微信订单号匹配
+// 3. 账单中有、时间段内账本没有的，再以 LedgerSource.Lookup 查找（跨天支付或当天退款前一天的订单），仍找不到的才是 ReconcileMissingLocal
+// 4. 账本中有、账单中没有的，仅状态为 SUCCESS 或 REFUND（或未设置状态）时才是 ReconcileMissingWepay
+// 5. 当天支付的订单核对订单金额，只有退款行的订单不核对；账本的已退款金额不少于账单中的即可（账单日前后可能另有退款）
+
+// ReconcileDiffType 对账差异的类型
+type ReconcileDiffType string
+
+const (
+	ReconcileMissingLocal   ReconcileDiffType = "MISSING_LOCAL"   // 微信支付有，我方没有
+	ReconcileMissingWepay   ReconcileDiffType = "MISSING_WEPAY"   // 我方有，微信支付没有
+	ReconcileAmountMismatch ReconcileDiffType = "AMOUNT_MISMATCH" // 订单金额或退款金额不一致
+	ReconcileStatusMismatch ReconcileDiffType = "STATUS_MISMATCH" // 状态不一致
+	ReconcileFeeMismatch    ReconcileDiffType = "FEE_MISMATCH"    // 手续费不一致
+)
+
+// LedgerOrder 我方账本中的一笔订单，金额单位为分
+type LedgerOrder struct {
+	OutTradeNo    string // 商户订单号
+	TransactionId string // 微信订单号，可为空
+	Amount        int64  // 订单金额
+	RefundAmount  int64  // 已退款金额
+	Fee           *int64 // 手续费（含退款退回的手续费），nil 表示不核对手续费
+	State         string // 状态，取微信支付的交易状态，如 SUCCESS、REFUND、NOTPAY，为空表示不核对状态
+}
+
+// LedgerSource 我方账本，可按需实现，mooongorm.WepayLedger 为基于 gorm 的实现
+type LedgerSource interface {
+	// Range 依次回调时间段 [begin, end) 内的订单，fn 返回错误时中止并返回该错误
+	Range(ctx context.Context, begin, end time.Time, fn func(order *LedgerOrder) error) error
+
+	// Lookup 按商户订单号查找订单，不存在的不返回
+	Lookup(ctx context.Context, outTradeNos []string) ([]*LedgerOrder, error)
+}
+
+// TradeBillIterator 交易账单记录的迭代器，TradeBillParser 实现了它，没有更多记录时 Next 返回 io.EOF
+type TradeBillIterator interface {
+	Next() (*TradeBillRecord, error)
+}
+
+// BillOrder 交易账单中同一订单的各行合并而来，金额单位为分
+type BillOrder struct {
+	OutTradeNo    string
+	TransactionId string
+	Amount        int64  // 应结订单金额与代金券金额之和，仅当天支付的订单有值
+	RefundAmount  int64  // 退款金额之和
+	Fee           int64  // 手续费之和
+	State         string // 有退款行时为 REFUND，否则为支付行的交易状态
+	Paid          bool   // 是否有支付行，只有退款行的为之前支付、当天退款的订单
+}
+
+// ReconcileDiff 一条对账差异，Local 和 Wepay 分别为我方和微信支付的订单，缺失的一方为 nil
+type ReconcileDiff struct {
+	Type  ReconcileDiffType
+	Local *LedgerOrder
+	Wepay *BillOrder
+}
+
+// OutTradeNo 差异订单的商户订单号
+func (d *ReconcileDiff) OutTradeNo() string {
+	if d.Wepay != nil && d.Wepay.OutTradeNo != "" {
+		return d.Wepay.OutTradeNo
+	}
+	if d.Local != nil {
+		return d.Local.OutTradeNo
+	}
+	return ""
+}
+
+// TransactionId 差异订单的微信订单号
+func (d *ReconcileDiff) TransactionId() string {
+	if d.Wepay != nil && d.Wepay.TransactionId != "" {
+		return d.Wepay.TransactionId
+	}
+	if d.Local != nil {
+		return d.Local.TransactionId
+	}
+	return ""
+}
+
+// ReconcileResult 对账结果
+type ReconcileResult struct {
+	BillCount    int              // 账单中的订单数（同一订单的支付行和退款行算一笔）
+	LedgerCount  int              // 账本中参与核对的订单数
+	MatchedCount int              // 两边都有的订单数，含有金额、状态或手续费差异的
+	Diffs        []*ReconcileDiff // 按商户订单号排序，同一订单可有多条不同类型的差异
+}
+
+// reconcileCSVHeader 导出 CSV 的表头，金额单位为元
+var reconcileCSVHeader = []string{
+	"差异类型", "商户订单号", "微信订单号",
+	"我方金额", "微信金额", "我方退款金额", "微信退款金额",
+	"我方状态", "微信状态", "我方手续费", "微信手续费",
+}
+
+// WriteCSV 将差异以 CSV 格式写入 w，金额单位为元，缺失一方的字段为空
+func (r *ReconcileResult) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(reconcileCSVHeader); err != nil {
+		return mooonerror.Errorf(mooonerror.ErrCodeFileOperate, "write reconcile csv error: %w", err)
+	}
+	for _, diff := range r.Diffs {
+		record := make([]string, len(reconcileCSVHeader))
+		record[0] = string(diff.Type)
+		record[1] = diff.OutTradeNo()
+		record[2] = diff.TransactionId()
+		if local := diff.Local; local != nil {
+			record[3] = formatFenToYuan(local.Amount)
+			record[5] = formatFenToYuan(local.RefundAmount)
+			record[7] = local.State
+			if local.Fee != nil {
+				record[9] = formatFenToYuan(*local.Fee)
+			}
+		}
+		if wepay := diff.Wepay; wepay != nil {
+			record[4] = formatFenToYuan(wepay.Amount)
+			record[6] = formatFenToYuan(wepay.RefundAmount)
+			record[8] = wepay.State
+			record[10] = formatFenToYuan(wepay.Fee)
+		}
+		if err := writer.Write(record); err != nil {
+			return mooonerror.Errorf(mooonerror.ErrCodeFileOperate, "write reconcile csv error: %w", err)
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return mooonerror.Errorf(mooonerror.ErrCodeFileOperate, "write reconcile csv error: %w", err)
+	}
+	return nil
+}
+
+// ReconcilerOption 对账器的可选项
+type ReconcilerOption func(*Reconciler)
+
+// WithLookupBatchSize 设置调用 LedgerSource.Lookup 时每批的订单数，默认 500
+func WithLookupBatchSize(batchSize int) ReconcilerOption {
+	return func(r *Reconciler) {
+		if batchSize > 0 {
+			r.lookupBatchSize = batchSize
+		}
+	}
+}
+
+// Reconciler 对账器：
+//
+//	parser, _ := mooonwepay.NewTradeBillParser(file, mooonwepay.WithBillHash(resp.HashType, resp.HashValue))
+//	defer parser.Close()
+//	reconciler := mooonwepay.NewReconciler(mooongorm.NewWepayLedger(db.Table("t_order"), columns))
+//	begin := time.Date(2026, 10, 17, 0, 0, 0, 0, location)
+//	result, err := reconciler.Reconcile(ctx, parser, begin, begin.AddDate(0, 0, 1))
+//	if err != nil {
+//		return err
+//	}
+//	_ = result.WriteCSV(output)
+type Reconciler struct {
+	ledger          LedgerSource
+	lookupBatchSize int
+}
+
+// NewReconciler 生成对账器
+func NewReconciler(ledger LedgerSource, opts ...ReconcilerOption) *Reconciler {
+	r := &Reconciler{
+		ledger:          ledger,
+		lookupBatchSize: 500,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Reconcile 将账单 bill 与账本中时间段 [begin, end) 内的订单核对，时间段通常为账单日当天
+// 会读完 bill，账单摘要不匹配等错误原样返回
+func (r *Reconciler) Reconcile(ctx context.Context, bill TradeBillIterator, begin, end time.Time) (*ReconcileResult, error) {
+	billOrders, err := collectBillOrders(bill)
+	if err != nil {
+		return nil, err
+	}
+
+	// 账本中时间段内的订单，按商户订单号和微信订单号索引
+	byOutTradeNo := make(map[string]*LedgerOrder)
+	byTransactionId := make(map[string]*LedgerOrder)
+	add := func(order *LedgerOrder) {
+		if order.OutTradeNo != "" {
+			byOutTradeNo[order.OutTradeNo] = order
+		}
+		if order.TransactionId != "" {
+			byTransactionId[order.TransactionId] = order
+		}
+	}
+	var ledgerOrders []*LedgerOrder
+	err = r.ledger.Range(ctx, begin, end, func(order *LedgerOrder) error {
+		ledgerOrders = append(ledgerOrders, order)
+		add(order)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	find := func(billOrder *BillOrder) *LedgerOrder {
+		if order, ok := byOutTradeNo[billOrder.OutTradeNo]; ok && billOrder.OutTradeNo != "" {
+			return order
+		}
+		if order, ok := byTransactionId[billOrder.TransactionId]; ok && billOrder.TransactionId != "" {
+			return order
+		}
+		return nil
+	}
+
+	// 时间段内找不到的，按商户订单号补查
+	var missing []string
+	for _, billOrder := range billOrders {
+		if find(billOrder) == nil && billOrder.OutTradeNo != "" {
+			missing = append(missing, billOrder.OutTradeNo)
+		}
+	}
+	for i := 0; i < len(missing); i += r.lookupBatchSize {
+		batch := missing[i:min(i+r.lookupBatchSize, len(missing))]
+		orders, err := r.ledger.Lookup(ctx, batch)
+		if err != nil {
+			return nil, err
+		}
+		for _, order := range orders {
+			add(order)
+		}
+	}
+
+	result := &ReconcileResult{
+		BillCount:   len(billOrders),
+		LedgerCount: len(ledgerOrders),
+	}
+	matched := make(map[*LedgerOrder]bool, len(billOrders))
+	for _, billOrder := range billOrders {
+		local := find(billOrder)
+		if local == nil {
+			result.Diffs = append(result.Diffs, &ReconcileDiff{Type: ReconcileMissingLocal, Wepay: billOrder})
+			continue
+		}
+		if !matched[local] {
+			matched[local] = true
+			result.MatchedCount++
+		}
+		result.Diffs = append(result.Diffs, compareOrder(local, billOrder)...)
+	}
+	for _, local := range ledgerOrders {
+		if !matched[local] && (local.State == "" || local.State == "SUCCESS" || local.State == "REFUND") {
+			result.Diffs = append(result.Diffs, &ReconcileDiff{Type: ReconcileMissingWepay, Local: local})
+		}
+	}
+
+	sort.SliceStable(result.Diffs, func(i, j int) bool {
+		return result.Diffs[i].OutTradeNo() < result.Diffs[j].OutTradeNo()
+	})
+	return result, nil
+}
+
+// collectBillOrders 读完账单，将同一订单的各行合并，保持订单在账单中首次出现的顺序
+func collectBillOrders(bill TradeBillIterator) ([]*BillOrder, error) {
+	var billOrders []*BillOrder
+	index := make(map[string]*BillOrder)
+	for {
+		record, err := bill.Next()
+		if err == io.EOF {
+			return billOrders, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		key := record.OutTradeNo
+		if key == "" {
+			key = "\x00" + record.TransactionId
+		}
+		billOrder, ok := index[key]
+		if !ok {
+			billOrder = &BillOrder{OutTradeNo: record.OutTradeNo, TransactionId: record.TransactionId}
+			index[key] = billOrder
+			billOrders = append(billOrders, billOrder)
+		}
+		billOrder.Fee += record.Fee
+		if record.TradeState == "REFUND" {
+			billOrder.RefundAmount += record.RefundAmount
+			billOrder.State = "REFUND"
+		} else {
+			billOrder.Amount += record.SettlementAmount + record.CouponAmount
+			billOrder.Paid = true
+			if billOrder.State == "" {
+				billOrder.State = record.TradeState
+			}
+		}
+	}
+}
+
+// compareOrder 核对两边都有的订单
+func compareOrder(local *LedgerOrder, wepay *BillOrder) []*ReconcileDiff {
+	var diffs []*ReconcileDiff
+	// 我方的已退款金额含账单日之后的退款，只要求不小于账单中当天的退款金额；订单金额仅当天支付的可核对
+	amountMismatch := local.RefundAmount < wepay.RefundAmount
+	if wepay.Paid && local.Amount != wepay.Amount {
+		amountMismatch = true
+	}
+	if amountMismatch {
+		diffs = append(diffs, &ReconcileDiff{Type: ReconcileAmountMismatch, Local: local, Wepay: wepay})
+	}
+	if local.State != "" && local.State != wepay.State {
+		diffs = append(diffs, &ReconcileDiff{Type: ReconcileStatusMismatch, Local: local, Wepay: wepay})
+	}
+	if local.Fee != nil && *local.Fee != wepay.Fee {
+		diffs = append(diffs, &ReconcileDiff{Type: ReconcileFeeMismatch, Local: local, Wepay: wepay})
+	}
+	return diffs
+}
+
+// formatFenToYuan 将分转为以元为单位、保留两位小数的字符串，是 parseYuanToFen 的逆操作
+func formatFenToYuan(fen int64) string {
+	sign := ""
+	if fen < 0 {
+		sign = "-"
+		fen = -fen
+	}
+	cents := strconv.FormatInt(fen%100, 10)
+	if len(cents) < 2 {
+		cents = "0" + cents
+	}
+	return sign + strconv.FormatInt(fen/100, 10) + "." + cents
+}
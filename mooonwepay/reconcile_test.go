@@ -0,0 +1,144 @@
+// Package mooonwepay
+// Wrote by yijian on 2026/10/18
+package mooonwepay
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// memoryLedger 测试用的内存账本
+type memoryLedger struct {
+	inRange []*LedgerOrder
+	others  []*LedgerOrder // 不在时间段内，只能 Lookup 到
+	lookups int
+}
+
+func (l *memoryLedger) Range(ctx context.Context, begin, end time.Time, fn func(order *LedgerOrder) error) error {
+	for _, order := range l.inRange {
+		if err := fn(order); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (l *memoryLedger) Lookup(ctx context.Context, outTradeNos []string) ([]*LedgerOrder, error) {
+	l.lookups++
+	var orders []*LedgerOrder
+	for _, order := range l.others {
+		for _, outTradeNo := range outTradeNos {
+			if order.OutTradeNo == outTradeNo {
+				orders = append(orders, order)
+			}
+		}
+	}
+	return orders, nil
+}
+
+const testReconcileBill = "交易时间,微信订单号,商户订单号,交易状态,应结订单金额,代金券金额,退款金额,手续费\n" +
+	"`2026-10-17 10:00:00,`4200000001,`T001,`SUCCESS,`10.00,`0.00,`0.00,`0.06\n" + // 一致
+	"`2026-10-17 10:01:00,`4200000002,`T002,`SUCCESS,`20.00,`0.00,`0.00,`0.12\n" + // 金额不一致
+	"`2026-10-17 10:02:00,`4200000003,`T003,`SUCCESS,`30.00,`0.00,`0.00,`0.18\n" + // 当天部分退款
+	"`2026-10-17 10:03:00,`4200000003,`T003,`REFUND,`0.00,`0.00,`5.00,`-0.03\n" +
+	"`2026-10-17 10:04:00,`4200000004,`T004,`SUCCESS,`40.00,`0.00,`0.00,`0.24\n" + // 我方没有
+	"`2026-10-17 10:05:00,`4200000005,`T005,`REFUND,`0.00,`0.00,`1.00,`-0.01\n" + // 前一天支付、当天退款
+	"`2026-10-17 10:06:00,`4200000006,`T006,`SUCCESS,`60.00,`0.00,`0.00,`0.36\n" + // 手续费不一致
+	"`2026-10-17 10:07:00,`4200000009,`T009,`SUCCESS,`90.00,`0.00,`0.00,`0.54\n" // 当天支付、之后退款
+
+func int64Ptr(v int64) *int64 {
+	return &v
+}
+
+// go test -v -run="TestReconcile$"
+func TestReconcile(t *testing.T) {
+	ledger := &memoryLedger{
+		inRange: []*LedgerOrder{
+			{OutTradeNo: "T001", TransactionId: "4200000001", Amount: 1000, Fee: int64Ptr(6), State: "SUCCESS"},
+			{OutTradeNo: "T002", Amount: 2100, State: "SUCCESS"},
+			{OutTradeNo: "T003", Amount: 3000, RefundAmount: 500, State: "SUCCESS"}, // 状态应为 REFUND
+			{OutTradeNo: "T006", Amount: 6000, Fee: int64Ptr(30), State: "SUCCESS"},
+			{OutTradeNo: "T007", Amount: 7000, State: "SUCCESS"}, // 微信支付没有
+			{OutTradeNo: "T008", Amount: 8000, State: "NOTPAY"},  // 未支付，不算差异
+			{OutTradeNo: "T009", Amount: 9000, RefundAmount: 1000},
+		},
+		others: []*LedgerOrder{
+			{OutTradeNo: "T005", Amount: 500, RefundAmount: 300, State: "REFUND"},
+		},
+	}
+	parser, err := NewTradeBillParser(strings.NewReader(testReconcileBill))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer parser.Close()
+
+	begin := time.Date(2026, 10, 17, 0, 0, 0, 0, billLocation)
+	result, err := NewReconciler(ledger, WithLookupBatchSize(1)).Reconcile(context.Background(), parser, begin, begin.AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.BillCount != 7 || result.LedgerCount != 7 || result.MatchedCount != 6 {
+		t.Errorf("unexpected counts: %+v", result)
+	}
+	if ledger.lookups != 2 { // T004 和 T005 各一批
+		t.Errorf("expected 2 lookups, got %d", ledger.lookups)
+	}
+
+	expected := []struct {
+		outTradeNo string
+		diffType   ReconcileDiffType
+	}{
+		{"T002", ReconcileAmountMismatch},
+		{"T003", ReconcileStatusMismatch},
+		{"T004", ReconcileMissingLocal},
+		{"T006", ReconcileFeeMismatch},
+		{"T007", ReconcileMissingWepay},
+	}
+	if len(result.Diffs) != len(expected) {
+		for _, diff := range result.Diffs {
+			t.Logf("%s %s", diff.OutTradeNo(), diff.Type)
+		}
+		t.Fatalf("expected %d diffs, got %d", len(expected), len(result.Diffs))
+	}
+	for i, e := range expected {
+		if result.Diffs[i].OutTradeNo() != e.outTradeNo || result.Diffs[i].Type != e.diffType {
+			t.Errorf("diff %d: expected %s %s, got %s %s", i, e.outTradeNo, e.diffType, result.Diffs[i].OutTradeNo(), result.Diffs[i].Type)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err = result.WriteCSV(&buf); err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != len(expected)+1 || len(records[0]) != len(reconcileCSVHeader) {
+		t.Fatalf("unexpected csv: %v", records)
+	}
+	// T002 金额不一致
+	if records[1][3] != "21.00" || records[1][4] != "20.00" {
+		t.Errorf("unexpected csv record: %v", records[1])
+	}
+	// T007 微信支付没有，微信一方为空
+	if records[5][4] != "" || records[5][3] != "70.00" {
+		t.Errorf("unexpected csv record: %v", records[5])
+	}
+}
+
+// go test -v -run="TestFormatFenToYuan$"
+func TestFormatFenToYuan(t *testing.T) {
+	for fen, expected := range map[int64]string{0: "0.00", 1: "0.01", 1234: "12.34", -5: "-0.05", -100: "-1.00"} {
+		if s := formatFenToYuan(fen); s != expected {
+			t.Errorf("formatFenToYuan(%d) = %s, expected %s", fen, s, expected)
+		}
+		if back, err := parseYuanToFen(expected); err != nil || back != fen {
+			t.Errorf("parseYuanToFen(%s) = %d, %v", expected, back, err)
+		}
+	}
+}
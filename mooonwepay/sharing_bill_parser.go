@@ -0,0 +1,99 @@
+// Package mooonwepay
+// Wrote by yijian on 2026/10/18
+package mooonwepay
+
+import (
+	"io"
+	"time"
+)
+
+// SharingBillRecord 分账账单中的一条记录，金额单位为分
+type SharingBillRecord struct {
+	SharingTime   time.Time // 分账时间
+	Initiator     string    // 分账发起方
+	Mchid         string    // 分账方
+	TransactionId string    // 微信订单号
+	OrderId       string    // 微信分账单号或回退单号
+	OutOrderNo    string    // 商户分账单号或回退单号
+	Receiver      string    // 分账接收方
+	Amount        int64     // 分账金额
+	Type          string    // 业务类型，如 分账、分账回退
+	Result        string    // 处理结果
+	Description   string    // 分账描述
+
+	Fields map[string]string // 所有字段，键为表头
+}
+
+// SharingBillSummary 分账账单的汇总，金额单位为分
+// 分账账单汇总的列随业务变化，未识别的汇总字段从 Fields 中取
+type SharingBillSummary struct {
+	TotalCount  int64 // 总笔数
+	TotalAmount int64 // 总金额
+
+	Fields map[string]string // 所有汇总字段，键为汇总表头
+}
+
+// SharingBillParser 分账账单的流式解析器，用法同 TradeBillParser
+type SharingBillParser struct {
+	parser *billParser
+}
+
+// NewSharingBillParser 生成分账账单解析器并读取表头
+func NewSharingBillParser(r io.Reader, opts ...BillParserOption) (*SharingBillParser, error) {
+	parser, err := newBillParser(r, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &SharingBillParser{parser: parser}, nil
+}
+
+// Next 返回下一条记录，没有更多记录时返回 io.EOF
+func (p *SharingBillParser) Next() (*SharingBillRecord, error) {
+	row, err := p.parser.next()
+	if err != nil {
+		return nil, err
+	}
+	record := &SharingBillRecord{
+		SharingTime:   row.time("分账时间", "分账发起时间"),
+		Initiator:     row.str("分账发起方"),
+		Mchid:         row.str("分账方"),
+		TransactionId: row.str("微信订单号"),
+		OrderId:       row.str("微信分账/回退单号", "微信分账单号", "分账单号"),
+		OutOrderNo:    row.str("商户分账/回退单号", "商户分账单号"),
+		Receiver:      row.str("分账接收方"),
+		Amount:        row.amount("分账金额（元）", "分账金额(元)", "分账金额"),
+		Type:          row.str("业务类型"),
+		Result:        row.str("处理结果"),
+		Description:   row.str("分账描述", "备注"),
+
+		Fields: row.fieldMap(),
+	}
+	if row.err != nil {
+		return nil, row.err
+	}
+	return record, nil
+}
+
+// Summary 返回汇总，需在 Next 返回 io.EOF 之后调用，账单没有汇总时返回 nil, nil
+// 汇总中的金额或笔数格式错误时返回 ErrCodeWepayDecode 错误
+func (p *SharingBillParser) Summary() (*SharingBillSummary, error) {
+	row := p.parser.summary
+	if row == nil {
+		return nil, nil
+	}
+	summary := &SharingBillSummary{
+		TotalCount:  row.count("总笔数", "分账总笔数"),
+		TotalAmount: row.amount("总金额（元）", "总金额(元)", "总金额", "分账总金额"),
+
+		Fields: row.fieldMap(),
+	}
+	if row.err != nil {
+		return nil, row.err
+	}
+	return summary, nil
+}
+
+// Close 释放解压使用的资源，不关闭传入的 io.Reader
+func (p *SharingBillParser) Close() error {
+	return p.parser.Close()
+}
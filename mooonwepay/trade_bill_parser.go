@@ -0,0 +1,170 @@
+// Package mooonwepay
+// Wrote by yijian on 2026/10/18
+package mooonwepay
+
+import (
+	"io"
+	"time"
+)
+
+// TradeBillRecord 交易账单中的一条记录，金额单位为分
+// SUCCESS 账单没有退款相关字段，REFUND 账单多出退款申请时间和退款成功时间，不存在的字段为零值
+type TradeBillRecord struct {
+	TradeTime     time.Time // 交易时间
+	Appid         string    // 公众账号ID
+	Mchid         string    // 商户号
+	SubMchid      string    // 特约商户号
+	DeviceInfo    string    // 设备号
+	TransactionId string    // 微信订单号
+	OutTradeNo    string    // 商户订单号
+	Openid        string    // 用户标识
+	TradeType     string    // 交易类型，如 JSAPI、NATIVE
+	TradeState    string    // 交易状态，如 SUCCESS、REFUND
+	BankType      string    // 付款银行
+	Currency      string    // 货币种类
+
+	SettlementAmount int64 // 应结订单金额
+	CouponAmount     int64 // 代金券金额
+
+	RefundApplyTime    time.Time // 退款申请时间（仅 REFUND 账单）
+	RefundSuccessTime  time.Time // 退款成功时间（仅 REFUND 账单）
+	RefundId           string    // 微信退款单号
+	OutRefundNo        string    // 商户退款单号
+	RefundAmount       int64     // 退款金额
+	CouponRefundAmount int64     // 充值券退款金额
+	RefundType         string    // 退款类型
+	RefundStatus       string    // 退款状态
+
+	GoodsName         string // 商品名称
+	Attach            string // 商户数据包
+	Fee               int64  // 手续费
+	FeeRate           string // 费率，如 0.60%
+	OrderAmount       int64  // 订单金额
+	ApplyRefundAmount int64  // 申请退款金额
+	FeeRateRemark     string // 费率备注
+
+	Fields map[string]string // 所有字段，键为表头，可取得以上未列出的字段
+}
+
+// TradeBillSummary 交易账单的汇总，金额单位为分
+type TradeBillSummary struct {
+	TotalCount              int64 // 总交易单数
+	TotalSettlementAmount   int64 // 应结订单总金额
+	TotalRefundAmount       int64 // 退款总金额
+	TotalCouponRefundAmount int64 // 充值券退款总金额
+	TotalFee                int64 // 手续费总金额
+	TotalOrderAmount        int64 // 订单总金额
+	TotalApplyRefundAmount  int64 // 申请退款总金额
+
+	Fields map[string]string // 所有汇总字段，键为汇总表头
+}
+
+// TradeBillParser 交易账单的流式解析器：
+//
+//	file, _ := os.Open(filepath) // DownloadBill 下载的文件，gzip 压缩与否均可
+//	defer file.Close()
+//	parser, err := mooonwepay.NewTradeBillParser(file, mooonwepay.WithBillHash(resp.HashType, resp.HashValue))
+//	if err != nil {
+//		return err
+//	}
+//	defer parser.Close()
+//	for {
+//		record, err := parser.Next()
+//		if err == io.EOF {
+//			break
+//		}
+//		if err != nil {
+//			return err
+//		}
+//		// 处理 record
+//	}
+//	summary, err := parser.Summary()
+type TradeBillParser struct {
+	parser *billParser
+}
+
+// NewTradeBillParser 生成交易账单解析器并读取表头
+func NewTradeBillParser(r io.Reader, opts ...BillParserOption) (*TradeBillParser, error) {
+	parser, err := newBillParser(r, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &TradeBillParser{parser: parser}, nil
+}
+
+// Next 返回下一条记录，没有更多记录时返回 io.EOF（设置了 WithBillHash 且摘要不匹配时返回 ErrCodeWepayHashMismatch 错误）
+func (p *TradeBillParser) Next() (*TradeBillRecord, error) {
+	row, err := p.parser.next()
+	if err != nil {
+		return nil, err
+	}
+	record := &TradeBillRecord{
+		TradeTime:     row.time("交易时间"),
+		Appid:         row.str("公众账号ID"),
+		Mchid:         row.str("商户号"),
+		SubMchid:      row.str("特约商户号", "子商户号"),
+		DeviceInfo:    row.str("设备号"),
+		TransactionId: row.str("微信订单号"),
+		OutTradeNo:    row.str("商户订单号"),
+		Openid:        row.str("用户标识"),
+		TradeType:     row.str("交易类型"),
+		TradeState:    row.str("交易状态"),
+		BankType:      row.str("付款银行"),
+		Currency:      row.str("货币种类"),
+
+		SettlementAmount: row.amount("应结订单金额"),
+		CouponAmount:     row.amount("代金券金额", "代金券或立减优惠金额"),
+
+		RefundApplyTime:    row.time("退款申请时间"),
+		RefundSuccessTime:  row.time("退款成功时间"),
+		RefundId:           row.str("微信退款单号"),
+		OutRefundNo:        row.str("商户退款单号"),
+		RefundAmount:       row.amount("退款金额"),
+		CouponRefundAmount: row.amount("充值券退款金额", "代金券或立减优惠退款金额"),
+		RefundType:         row.str("退款类型"),
+		RefundStatus:       row.str("退款状态"),
+
+		GoodsName:         row.str("商品名称"),
+		Attach:            row.str("商户数据包"),
+		Fee:               row.amount("手续费"),
+		FeeRate:           row.str("费率"),
+		OrderAmount:       row.amount("订单金额"),
+		ApplyRefundAmount: row.amount("申请退款金额"),
+		FeeRateRemark:     row.str("费率备注"),
+
+		Fields: row.fieldMap(),
+	}
+	if row.err != nil {
+		return nil, row.err
+	}
+	return record, nil
+}
+
+// Summary 返回汇总，需在 Next 返回 io.EOF 之后调用，账单没有汇总时返回 nil, nil
+// 汇总中的金额或笔数格式错误时返回 ErrCodeWepayDecode 错误
+func (p *TradeBillParser) Summary() (*TradeBillSummary, error) {
+	row := p.parser.summary
+	if row == nil {
+		return nil, nil
+	}
+	summary := &TradeBillSummary{
+		TotalCount:              row.count("总交易单数"),
+		TotalSettlementAmount:   row.amount("应结订单总金额"),
+		TotalRefundAmount:       row.amount("退款总金额"),
+		TotalCouponRefundAmount: row.amount("充值券退款总金额", "代金券或立减优惠退款总金额"),
+		TotalFee:                row.amount("手续费总金额"),
+		TotalOrderAmount:        row.amount("订单总金额"),
+		TotalApplyRefundAmount:  row.amount("申请退款总金额"),
+
+		Fields: row.fieldMap(),
+	}
+	if row.err != nil {
+		return nil, row.err
+	}
+	return summary, nil
+}
+
+// Close 释放解压使用的资源，不关闭传入的 io.Reader
+func (p *TradeBillParser) Close() error {
+	return p.parser.Close()
+}
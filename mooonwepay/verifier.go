@@ -0,0 +1,108 @@
+// Package mooonwepay
+// Wrote by yijian on 2026/10/18
+package mooonwepay
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/eyjian/gomooon/moooncrypto"
+	"github.com/eyjian/gomooon/mooonerror"
+)
+
+const (
+	HeaderWechatpaySignature = "Wechatpay-Signature" // 应答签名
+	HeaderWechatpaySerial    = "Wechatpay-Serial"    // 平台证书序列号或微信支付公钥 ID
+	HeaderWechatpayTimestamp = "Wechatpay-Timestamp" // 应答时间戳
+	HeaderWechatpayNonce     = "Wechatpay-Nonce"     // 应答随机串
+
+	// 应答时间戳与本地时间相差超过该值时视为重放的应答
+	responseTimestampSkew = 5 * time.Minute
+)
+
+// Verifier 验证微信支付应答的签名
+// 平台证书模式使用 CertificateDownloader，微信支付公钥模式使用 PublicKeyVerifier
+type Verifier interface {
+	// Verify 以 serialNo 指定的平台证书或微信支付公钥验证 message 的签名，签名匹配时返回 nil
+	// serialNo 取自应答头 Wechatpay-Serial，signature 取自应答头 Wechatpay-Signature
+	Verify(ctx context.Context, serialNo, message, signature string) error
+}
+
+// PublicKeyVerifier 微信支付公钥模式的验签器
+// 公钥和公钥 ID 在商户平台“API安全”中获取，公钥 ID 形如 PUB_KEY_ID_0114232134912410000000000000
+type PublicKeyVerifier struct {
+	publicKeyID string
+	publicKey   *rsa.PublicKey
+}
+
+// NewPublicKeyVerifier 生成微信支付公钥模式的验签器，publicKey 可用 moooncrypto.String2PublicKey 从 PEM 解析
+func NewPublicKeyVerifier(publicKeyID string, publicKey *rsa.PublicKey) *PublicKeyVerifier {
+	return &PublicKeyVerifier{
+		publicKeyID: publicKeyID,
+		publicKey:   publicKey,
+	}
+}
+
+// Verify 实现 Verifier 接口
+func (v *PublicKeyVerifier) Verify(ctx context.Context, serialNo, message, signature string) error {
+	if serialNo != v.publicKeyID {
+		return mooonerror.Errorf(mooonerror.ErrCodeWepayVerify, "unknown wechatpay public key id: %s", serialNo)
+	}
+	if err := moooncrypto.RsaSha256VerifyWithPublicKey(v.publicKey, []byte(message), signature); err != nil {
+		return mooonerror.Errorf(mooonerror.ErrCodeWepayVerify, "verify with public key %s error: %w", serialNo, err)
+	}
+	return nil
+}
+
+// certificateVerifier 以平台证书序列号为键的平台证书集
+type certificateVerifier map[string]*x509.Certificate
+
+// Verify 实现 Verifier 接口
+func (v certificateVerifier) Verify(ctx context.Context, serialNo, message, signature string) error {
+	cert, ok := v[serialNo]
+	if !ok {
+		return mooonerror.Errorf(mooonerror.ErrCodeWepayVerify, "unknown wechatpay certificate: %s", serialNo)
+	}
+	if time.Now().After(cert.NotAfter) {
+		return mooonerror.Errorf(mooonerror.ErrCodeWepayVerify, "wechatpay certificate %s expired at %s", serialNo, cert.NotAfter.Format(time.RFC3339))
+	}
+	publicKey, ok := cert.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return mooonerror.Errorf(mooonerror.ErrCodeWepayVerify, "wechatpay certificate %s is not RSA", serialNo)
+	}
+	if err := moooncrypto.RsaSha256VerifyWithPublicKey(publicKey, []byte(message), signature); err != nil {
+		return mooonerror.Errorf(mooonerror.ErrCodeWepayVerify, "verify with certificate %s error: %w", serialNo, err)
+	}
+	return nil
+}
+
+// verifyResponse 验证应答的签名，验签串格式：
+// 应答时间戳\n
+// 应答随机串\n
+// 应答报文主体\n
+func verifyResponse(ctx context.Context, verifier Verifier, header http.Header, body []byte, now time.Time) error {
+	signature := header.Get(HeaderWechatpaySignature)
+	serialNo := header.Get(HeaderWechatpaySerial)
+	timestamp := header.Get(HeaderWechatpayTimestamp)
+	nonceStr := header.Get(HeaderWechatpayNonce)
+	if signature == "" || serialNo == "" || timestamp == "" || nonceStr == "" {
+		return mooonerror.NewError(mooonerror.ErrCodeWepayVerify, "response missing wechatpay signature headers")
+	}
+
+	// 拒绝时间偏差过大的应答，防止重放
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return mooonerror.Errorf(mooonerror.ErrCodeWepayVerify, "invalid %s: %s", HeaderWechatpayTimestamp, timestamp)
+	}
+	if skew := now.Sub(time.Unix(seconds, 0)); skew > responseTimestampSkew || skew < -responseTimestampSkew {
+		return mooonerror.Errorf(mooonerror.ErrCodeWepayVerify, "response timestamp %s out of range", timestamp)
+	}
+
+	message := fmt.Sprintf("%s\n%s\n%s\n", timestamp, nonceStr, body)
+	return verifier.Verify(ctx, serialNo, message, signature)
+}
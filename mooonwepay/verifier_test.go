@@ -0,0 +1,139 @@
+// Package mooonwepay
+// Wrote by yijian on 2026/10/18
+package mooonwepay
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/eyjian/gomooon/moooncrypto"
+	"github.com/eyjian/gomooon/mooonerror"
+)
+
+const (
+	testApiV3Key       = "0123456789abcdef0123456789abcdef"
+	testPlatformSerial = "7132D72A03E93CDDF8C03BBD1F37EEDF1C2BCE37"
+	testPublicKeyID    = "PUB_KEY_ID_0114232134912410000000000000"
+)
+
+// writeSignedResponse 以平台私钥签名并写应答
+func writeSignedResponse(t *testing.T, w http.ResponseWriter, platformKey *rsa.PrivateKey, serialNo, body string) {
+	timestamp := strconv.FormatInt(testTimestamp, 10)
+	nonceStr := "B3A8B2C1A6A5E0DB4EBB0DF1DB4C2C5E"
+	signature, err := moooncrypto.RsaSha256SignWithPrivateKey(platformKey, []byte(fmt.Sprintf("%s\n%s\n%s\n", timestamp, nonceStr, body)))
+	if err != nil {
+		t.Error(err)
+	}
+	w.Header().Set(HeaderWechatpaySignature, signature)
+	w.Header().Set(HeaderWechatpaySerial, serialNo)
+	w.Header().Set(HeaderWechatpayTimestamp, timestamp)
+	w.Header().Set(HeaderWechatpayNonce, nonceStr)
+	fmt.Fprint(w, body)
+}
+
+// go test -v -run="TestPublicKeyVerifier$"
+func TestPublicKeyVerifier(t *testing.T) {
+	platformKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tampered := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := `{"download_url":"https://api.mch.weixin.qq.com/v3/billdownload/file?token=xxx"}`
+		if !tampered {
+			writeSignedResponse(t, w, platformKey, testPublicKeyID, body)
+			return
+		}
+		signature, _ := moooncrypto.RsaSha256SignWithPrivateKey(platformKey, []byte("other"))
+		w.Header().Set(HeaderWechatpaySignature, signature)
+		w.Header().Set(HeaderWechatpaySerial, testPublicKeyID)
+		w.Header().Set(HeaderWechatpayTimestamp, strconv.FormatInt(testTimestamp, 10))
+		w.Header().Set(HeaderWechatpayNonce, "nonce")
+		fmt.Fprint(w, `{"download_url":"https://evil.example.com/file"}`)
+	}))
+	defer server.Close()
+
+	client, _ := newTestClient(t, server.URL)
+	client.SetVerifier(NewPublicKeyVerifier(testPublicKeyID, &platformKey.PublicKey))
+	if _, err = client.ApplyBill(context.Background(), "ALL", "GZIP", "2026-10-17"); err != nil {
+		t.Fatal(err)
+	}
+
+	tampered = true
+	resp, err := client.ApplyBill(context.Background(), "ALL", "GZIP", "2026-10-17")
+	if resp != nil || mooonerror.Code(err) != mooonerror.ErrCodeWepayVerify {
+		t.Errorf("tampered response should fail: %+v, %v", resp, err)
+	}
+}
+
+// go test -v -run="TestCertificateDownloader$"
+func TestCertificateDownloader(t *testing.T) {
+	platformKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM := newTestCertificate(t, platformKey)
+	nonce := "0123456789ab"
+	ciphertext, err := moooncrypto.AesGCMEncryptText(testApiV3Key, nonce, "certificate", certPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	numDownloads := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == CertificatesPath {
+			numDownloads++
+			writeSignedResponse(t, w, platformKey, testPlatformSerial, fmt.Sprintf(
+				`{"data":[{"serial_no":"%s","effective_time":"2026-01-01T00:00:00+08:00","expire_time":"2031-01-01T00:00:00+08:00","encrypt_certificate":{"algorithm":"AEAD_AES_256_GCM","nonce":"%s","associated_data":"certificate","ciphertext":"%s"}}]}`,
+				testPlatformSerial, nonce, ciphertext))
+			return
+		}
+		writeSignedResponse(t, w, platformKey, testPlatformSerial, `{"hash_type":"SHA1"}`)
+	}))
+	defer server.Close()
+
+	client, _ := newTestClient(t, server.URL)
+	downloader := NewCertificateDownloader(client, testApiV3Key)
+	client.SetVerifier(downloader)
+
+	// 第一次验签时下载证书，之后使用缓存
+	for i := 0; i < 2; i++ {
+		if _, err = client.ApplyBill(context.Background(), "ALL", "GZIP", "2026-10-17"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if numDownloads != 1 || len(downloader.Certificates()) != 1 {
+		t.Errorf("downloads: %d, certificates: %d", numDownloads, len(downloader.Certificates()))
+	}
+
+	// APIv3 密钥不正确时无法解密证书
+	if err = NewCertificateDownloader(client, "fedcba9876543210fedcba9876543210").Refresh(context.Background()); mooonerror.Code(err) != mooonerror.ErrCodeWepayVerify {
+		t.Errorf("refresh with wrong key should fail: %v", err)
+	}
+}
+
+func newTestCertificate(t *testing.T, privateKey *rsa.PrivateKey) string {
+	serialNumber, _ := new(big.Int).SetString(testPlatformSerial, 16)
+	template := &x509.Certificate{
+		SerialNumber: serialNumber,
+		Subject:      pkix.Name{CommonName: "Tenpay.com Root CA"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &privateKey.PublicKey, privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
@@ -0,0 +1,114 @@
+// Package mooonwepay
+// Wrote by yijian on 2026/10/18
+package mooonwepay
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/eyjian/gomooon/mooonerror"
+	"github.com/wechatpay-apiv3/wechatpay-go/core"
+)
+
+// WepayStatus 微信支付应答中的错误码、错误描述和 HTTP 状态码，各响应结构体共用
+type WepayStatus struct {
+	Code    string `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+
+	HttpStatusCode int `json:"http_status_code,omitempty"`
+}
+
+// WepayError 微信支付返回的错误（HTTP 状态码非 200）
+// 各 Apply*、Query*、Download* 函数返回的 error 均可通过 errors.As 或 AsWepayError 取得：
+//
+//	resp, err := mooonwepay.ApplyBill(req)
+//	if wepayErr, ok := mooonwepay.AsWepayError(err); ok && wepayErr.Retryable {
+//		// 稍后重试
+//	}
+type WepayError struct {
+	HttpStatusCode int    `json:"http_status_code"`
+	Code           string `json:"code"`      // 微信支付的错误码，如 SYSTEM_ERROR
+	Message        string `json:"message"`   // 微信支付的错误描述
+	Retryable      bool   `json:"retryable"` // 是否可重试，参见 WepayErrorCodes
+}
+
+// Error 实现 error 接口
+func (e *WepayError) Error() string {
+	return fmt.Sprintf("http response %d, code: %s, message: %s", e.HttpStatusCode, e.Code, e.Message)
+}
+
+// WepayCodeInfo 微信支付错误码的说明
+type WepayCodeInfo struct {
+	Desc      string // 说明
+	Retryable bool   // 是否可重试：true 表示稍后以相同参数重试可能成功，false 表示需修正请求或联系微信支付
+}
+
+// WepayErrorCodes 微信支付 APIv3 文档中的公共错误码和账单、电子回单相关的错误码
+// 不在其中的错误码按 HTTP 状态码判断：5xx 和 429 可重试
+var WepayErrorCodes = map[string]WepayCodeInfo{
+	"SYSTEM_ERROR":            {"系统错误，请稍后重试", true},
+	"FREQUENCY_LIMITED":       {"频率超限，请降低请求频率", true},
+	"FREQUENCY_LIMIT_EXCEED":  {"频率超限，请降低请求频率", true},
+	"BANK_ERROR":              {"银行系统异常，请稍后重试", true},
+	"STATEMENT_CREATING":      {"账单生成中，请稍后重试", true},
+	"NO_STATEMENT_EXIST":      {"账单文件不存在，请确认账单日期", false},
+	"PARAM_ERROR":             {"参数错误", false},
+	"INVALID_REQUEST":         {"请求不符合业务规则", false},
+	"SIGN_ERROR":              {"签名错误，请检查商户证书序列号和私钥", false},
+	"NO_AUTH":                 {"商户无权限", false},
+	"NOT_ENOUGH":              {"余额不足", false},
+	"NOTENOUGH":               {"余额不足", false},
+	"MCH_NOT_EXISTS":          {"商户号不存在", false},
+	"NOT_FOUND":               {"记录不存在", false},
+	"RESOURCE_NOT_EXISTS":     {"记录不存在", false},
+	"ALREADY_EXISTS":          {"记录已存在", false},
+	"RESOURCE_ALREADY_EXISTS": {"记录已存在", false},
+}
+
+// IsRetryableWepayCode 判断微信支付的错误码是否可重试
+// 错误码在 WepayErrorCodes 中时以其为准，否则 5xx 和 429 可重试
+func IsRetryableWepayCode(code string, httpStatusCode int) bool {
+	if info, ok := WepayErrorCodes[code]; ok {
+		return info.Retryable
+	}
+	return httpStatusCode >= http.StatusInternalServerError || httpStatusCode == http.StatusTooManyRequests
+}
+
+// AsWepayError 取得 err 中的 *WepayError，err 不是微信支付返回的错误时返回 nil, false
+func AsWepayError(err error) (*WepayError, bool) {
+	var wepayErr *WepayError
+	if errors.As(err, &wepayErr) {
+		return wepayErr, true
+	}
+	return nil, false
+}
+
+// newWepayError 根据应答状态生成错误：*mooonerror.CError，Cause 为 *WepayError，可重试时标记 Retryable
+func newWepayError(errCode int, tag string, status *WepayStatus) error {
+	wepayErr := &WepayError{
+		HttpStatusCode: status.HttpStatusCode,
+		Code:           status.Code,
+		Message:        status.Message,
+		Retryable:      IsRetryableWepayCode(status.Code, status.HttpStatusCode),
+	}
+	cerr := mooonerror.Errorf(errCode, "%s: %w", tag, wepayErr).WithDetail("wepay_code", status.Code)
+	if wepayErr.Retryable {
+		cerr = cerr.WithRetryable()
+	}
+	return cerr
+}
+
+// newCoreError 转换 wechatpay-go 的 core.Client 返回的错误
+// 微信支付返回的错误（*core.APIError）转为 *WepayError，其它（如网络错误）以 errCode 包装并标记为可重试
+func newCoreError(errCode int, tag string, err error) error {
+	var apiErr *core.APIError
+	if errors.As(err, &apiErr) {
+		return newWepayError(mooonerror.ErrCodeWepayResponse, tag, &WepayStatus{
+			Code:           apiErr.Code,
+			Message:        apiErr.Message,
+			HttpStatusCode: apiErr.StatusCode,
+		})
+	}
+	return mooonerror.Errorf(errCode, "%s: %w", tag, err).WithRetryable()
+}
@@ -0,0 +1,61 @@
+// Package mooonwepay
+// Wrote by yijian on 2026/10/18
+package mooonwepay
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/eyjian/gomooon/mooonerror"
+	"github.com/wechatpay-apiv3/wechatpay-go/core"
+)
+
+// go test -v -run="TestWepayError$"
+func TestWepayError(t *testing.T) {
+	cases := []struct {
+		status    WepayStatus
+		retryable bool
+	}{
+		{WepayStatus{Code: "SYSTEM_ERROR", HttpStatusCode: http.StatusInternalServerError}, true},
+		{WepayStatus{Code: "FREQUENCY_LIMITED", HttpStatusCode: http.StatusTooManyRequests}, true},
+		{WepayStatus{Code: "PARAM_ERROR", HttpStatusCode: http.StatusBadRequest}, false},
+		{WepayStatus{Code: "SIGN_ERROR", HttpStatusCode: http.StatusUnauthorized}, false},
+		{WepayStatus{Code: "UNKNOWN_CODE", HttpStatusCode: http.StatusBadGateway}, true},
+		{WepayStatus{Code: "UNKNOWN_CODE", HttpStatusCode: http.StatusForbidden}, false},
+	}
+	for _, c := range cases {
+		err := newWepayError(mooonerror.ErrCodeWepayResponse, "test", &c.status)
+		wepayErr, ok := AsWepayError(err)
+		if !ok {
+			t.Fatalf("%s: not a WepayError: %v", c.status.Code, err)
+		}
+		if wepayErr.Code != c.status.Code || wepayErr.HttpStatusCode != c.status.HttpStatusCode {
+			t.Errorf("%s: unexpected WepayError: %+v", c.status.Code, wepayErr)
+		}
+		if wepayErr.Retryable != c.retryable || mooonerror.IsRetryable(err) != c.retryable {
+			t.Errorf("%s/%d: retryable expected %v", c.status.Code, c.status.HttpStatusCode, c.retryable)
+		}
+		if mooonerror.Code(err) != mooonerror.ErrCodeWepayResponse {
+			t.Errorf("%s: unexpected code %d", c.status.Code, mooonerror.Code(err))
+		}
+	}
+}
+
+// go test -v -run="TestNewCoreError$"
+func TestNewCoreError(t *testing.T) {
+	apiErr := &core.APIError{StatusCode: http.StatusServiceUnavailable, Code: "SYSTEM_ERROR", Message: "系统繁忙"}
+	err := newCoreError(mooonerror.ErrCodeWepayRequest, "test", apiErr)
+	if wepayErr, ok := AsWepayError(err); !ok || !wepayErr.Retryable || wepayErr.Message != "系统繁忙" {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	netErr := errors.New("connection reset by peer")
+	err = newCoreError(mooonerror.ErrCodeWepayRequest, "test", netErr)
+	if _, ok := AsWepayError(err); ok {
+		t.Errorf("network error should not be WepayError: %v", err)
+	}
+	if !errors.Is(err, netErr) || !mooonerror.IsRetryable(err) || mooonerror.Code(err) != mooonerror.ErrCodeWepayRequest {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -10,13 +10,13 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
-	"fmt"
 	"net/url"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
 
+	"github.com/eyjian/gomooon/mooonerror"
 	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
 	ocr "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/ocr/v20181119"
 )
@@ -52,7 +52,7 @@ func NewGeneralInvoiceRecognizer(secretId, secretKey, region string) *GeneralInv
 // RecognizeInvoice 识别发票（实现 InvoiceRecognizer 接口）
 func (g *GeneralInvoiceRecognizer) RecognizeInvoice(ctx context.Context, req *InvoiceRequest) (*InvoiceResult, error) {
 	if req == nil {
-		return nil, fmt.Errorf("invoice request is nil")
+		return nil, mooonerror.Errorf(mooonerror.ErrCodeInvalidParam, "invoice request is nil")
 	}
 
 	imageURL, imageBase64, isPdf, err := resolveSource(req)
@@ -60,9 +60,13 @@ func (g *GeneralInvoiceRecognizer) RecognizeInvoice(ctx context.Context, req *In
 		return nil, err
 	}
 
+	if err := g.wait(ctx); err != nil {
+		return nil, err
+	}
+
 	client, err := ocr.NewClient(g.credential, g.TxCloud.Region, g.clientProfile)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create ocr client: %w", err)
+		return nil, mooonerror.Errorf(mooonerror.ErrCodeTxcloudRequest, "failed to create ocr client: %w", err)
 	}
 
 	request := ocr.NewRecognizeGeneralInvoiceRequest()
@@ -94,8 +98,7 @@ func (g *GeneralInvoiceRecognizer) RecognizeInvoice(ctx context.Context, req *In
 
 	response, err := client.RecognizeGeneralInvoice(request)
 	if err != nil {
-		// 腾讯云 SDK 错误（*errors.TencentCloudSDKError）原样向上透传
-		return nil, err
+		return nil, TranslateError(err)
 	}
 
 	return buildInvoiceResult(response), nil
@@ -141,7 +144,7 @@ func resolveSource(req *InvoiceRequest) (imageURL, imageBase64 string, isPdf boo
 	// 全部高级字段为空时，使用 Source 自动判别
 	src := strings.TrimSpace(req.Source)
 	if src == "" {
-		err = fmt.Errorf("invoice request: at least one of Source/ImageURL/ImageBase64/ImageFile must be provided")
+		err = mooonerror.Errorf(mooonerror.ErrCodeInvalidParam, "invoice request: at least one of Source/ImageURL/ImageBase64/ImageFile must be provided")
 		return
 	}
 
@@ -176,7 +179,7 @@ func resolveSource(req *InvoiceRequest) (imageURL, imageBase64 string, isPdf boo
 			}
 			return
 		}
-		err = fmt.Errorf("invoice request: cannot determine source type, file not found and not a valid base64 string")
+		err = mooonerror.Errorf(mooonerror.ErrCodeInvalidParam, "invoice request: cannot determine source type, file not found and not a valid base64 string")
 	}
 	return
 }
@@ -185,7 +188,7 @@ func resolveSource(req *InvoiceRequest) (imageURL, imageBase64 string, isPdf boo
 func readFileAsBase64(path string) (string, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
-		return "", fmt.Errorf("failed to read file %q: %w", path, err)
+		return "", mooonerror.Errorf(mooonerror.ErrCodeFileOperate, "failed to read file %q: %w", path, err)
 	}
 	return base64.StdEncoding.EncodeToString(data), nil
 }
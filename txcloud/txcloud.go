@@ -3,6 +3,10 @@
 package txcloud
 
 import (
+	"context"
+	stderrors "errors"
+
+	"github.com/eyjian/gomooon/mooonerror"
 	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
 	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/errors"
 	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/profile"
@@ -17,6 +21,35 @@ type TxCloud struct {
 
 	credential *common.Credential
 	clientProfile *profile.ClientProfile
+	limiter Limiter // 限流器，为 nil 表示不限流
+}
+
+// Limiter 限流器，用于多个 POD 共享腾讯云接口的频率配额
+// mooonredis.SlidingWindowLimiter 和 mooonredis.TokenBucketLimiter 均实现了该接口，
+// 如多个 POD 的 Face 共享 100 次/秒的配额：
+//
+//	face := txcloud.NewFace(secretId, secretKey)
+//	face.SetLimiter(mooonredis.NewSlidingWindowLimiter(rdb, "txcloud:faceid", 100, time.Second))
+type Limiter interface {
+	// Wait 阻塞直到放行一个请求或 ctx 结束
+	Wait(ctx context.Context) error
+}
+
+// SetLimiter 设置限流器，每次调用腾讯云接口前都会先调用 limiter.Wait
+// 多个对象（如 Face 和 SesEmailSender）设置同一个限流器即共享同一份配额
+func (t *TxCloud) SetLimiter(limiter Limiter) {
+	t.limiter = limiter
+}
+
+// wait 等待限流器放行，未设置限流器时直接返回
+func (t *TxCloud) wait(ctx context.Context) error {
+	if t.limiter == nil {
+		return nil
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return mooonerror.Wrap(mooonerror.ErrCodeTxcloudLimit, t.limiter.Wait(ctx))
 }
 
 // FaceResponse 腾讯云 face 类接口的响应
@@ -37,7 +70,8 @@ type FaceResponse struct {
 
 // GetErrCodeAndErrMsg 获取腾讯云错误码和错误信息，如果不是腾讯云错则返回两个空字符串
 func GetErrCodeAndErrMsg(err error) (string, string) {
-	if e, ok := err.(*errors.TencentCloudSDKError); ok {
+	var e *errors.TencentCloudSDKError
+	if stderrors.As(err, &e) {
 		return e.Code, e.Message
 	} else {
 		return "", ""
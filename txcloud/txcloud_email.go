@@ -5,10 +5,10 @@ package txcloud
 import (
 	"context"
 	"encoding/base64"
-	"fmt"
 	"os"
 	"strings"
 
+	"github.com/eyjian/gomooon/mooonerror"
 	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
 	ses "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/ses/v20201002"
 )
@@ -19,8 +19,9 @@ import (
 type EmailSender interface {
 	// SendEmail 发送一封邮件
 	//
-	// 当腾讯云返回 *errors.TencentCloudSDKError 错误时，原样向上透传，
-	// 调用方可使用 GetErrCodeAndErrMsg 提取错误码和错误描述。
+	// 当腾讯云返回 *errors.TencentCloudSDKError 错误时，经 TranslateError 转换为 *mooonerror.CError，
+	// 调用方可使用 mooonerror.Code 取得归类后的错误码，mooonerror.IsRetryable 判断是否可重试，
+	// 或使用 GetErrCodeAndErrMsg 提取腾讯云的错误码和错误描述。
 	SendEmail(ctx context.Context, req *EmailRequest) (*EmailResult, error)
 }
 
@@ -121,21 +122,25 @@ func NewSesEmailSender(secretId, secretKey, region string) *SesEmailSender {
 // SendEmail 发送邮件（实现 EmailSender 接口）
 func (s *SesEmailSender) SendEmail(ctx context.Context, req *EmailRequest) (*EmailResult, error) {
 	if req == nil {
-		return nil, fmt.Errorf("email request is nil")
+		return nil, mooonerror.Errorf(mooonerror.ErrCodeInvalidParam, "email request is nil")
 	}
 	if len(req.Destination) == 0 && len(req.Cc) == 0 && len(req.Bcc) == 0 {
-		return nil, fmt.Errorf("email request: at least one of Destination/Cc/Bcc must be provided")
+		return nil, mooonerror.Errorf(mooonerror.ErrCodeInvalidParam, "email request: at least one of Destination/Cc/Bcc must be provided")
 	}
 	if req.FromEmailAddress == "" {
-		return nil, fmt.Errorf("email request: FromEmailAddress is required")
+		return nil, mooonerror.Errorf(mooonerror.ErrCodeInvalidParam, "email request: FromEmailAddress is required")
 	}
 	if req.Subject == "" {
-		return nil, fmt.Errorf("email request: Subject is required")
+		return nil, mooonerror.Errorf(mooonerror.ErrCodeInvalidParam, "email request: Subject is required")
+	}
+
+	if err := s.wait(ctx); err != nil {
+		return nil, err
 	}
 
 	client, err := ses.NewClient(s.credential, s.TxCloud.Region, s.clientProfile)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create ses client: %w", err)
+		return nil, mooonerror.Errorf(mooonerror.ErrCodeTxcloudRequest, "failed to create ses client: %w", err)
 	}
 
 	request := ses.NewSendEmailRequest()
@@ -176,7 +181,7 @@ func (s *SesEmailSender) SendEmail(ctx context.Context, req *EmailRequest) (*Ema
 		if strings.HasPrefix(htmlContent, "file://") {
 			data, err := os.ReadFile(strings.TrimPrefix(htmlContent, "file://"))
 			if err != nil {
-				return nil, fmt.Errorf("failed to read html file: %w", err)
+				return nil, mooonerror.Errorf(mooonerror.ErrCodeFileOperate, "failed to read html file: %w", err)
 			}
 			htmlContent = string(data)
 		}
@@ -184,7 +189,7 @@ func (s *SesEmailSender) SendEmail(ctx context.Context, req *EmailRequest) (*Ema
 		if strings.HasPrefix(textContent, "file://") {
 			data, err := os.ReadFile(strings.TrimPrefix(textContent, "file://"))
 			if err != nil {
-				return nil, fmt.Errorf("failed to read text file: %w", err)
+				return nil, mooonerror.Errorf(mooonerror.ErrCodeFileOperate, "failed to read text file: %w", err)
 			}
 			textContent = string(data)
 		}
@@ -193,7 +198,7 @@ func (s *SesEmailSender) SendEmail(ctx context.Context, req *EmailRequest) (*Ema
 			Text: common.StringPtr(base64.StdEncoding.EncodeToString([]byte(textContent))),
 		}
 	} else {
-		return nil, fmt.Errorf("email request: one of TemplateID, Html or Text must be provided")
+		return nil, mooonerror.Errorf(mooonerror.ErrCodeInvalidParam, "email request: one of TemplateID, Html or Text must be provided")
 	}
 
 	// 附件
@@ -205,7 +210,7 @@ func (s *SesEmailSender) SendEmail(ctx context.Context, req *EmailRequest) (*Ema
 			if content == "" && att.FilePath != "" {
 				data, err := os.ReadFile(att.FilePath)
 				if err != nil {
-					return nil, fmt.Errorf("failed to read attachment file %q: %w", att.FilePath, err)
+					return nil, mooonerror.Errorf(mooonerror.ErrCodeFileOperate, "failed to read attachment file %q: %w", att.FilePath, err)
 				}
 				content = base64.StdEncoding.EncodeToString(data)
 			}
@@ -222,7 +227,7 @@ func (s *SesEmailSender) SendEmail(ctx context.Context, req *EmailRequest) (*Ema
 
 	response, err := client.SendEmail(request)
 	if err != nil {
-		return nil, err
+		return nil, TranslateError(err)
 	}
 
 	result := &EmailResult{}
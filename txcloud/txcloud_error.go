@@ -0,0 +1,72 @@
+// Package txcloud
+// Wrote by yijian on 2026/10/18
+package txcloud
+
+import (
+	stderrors "errors"
+	"strings"
+
+	"github.com/eyjian/gomooon/mooonerror"
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/errors"
+)
+
+// 腾讯云错误码（前缀匹配）到 mooonerror 错误码的映射，按顺序匹配
+// 错误码参见：https://cloud.tencent.com/document/api/1007/31324
+var txcloudErrorClasses = []struct {
+	prefix    string
+	code      int
+	retryable bool
+}{
+	{"RequestLimitExceeded", mooonerror.ErrCodeTxcloudRateLimited, true},
+	{"LimitExceeded", mooonerror.ErrCodeTxcloudRateLimited, true},
+	{"AuthFailure", mooonerror.ErrCodeTxcloudAuth, false},
+	{"UnauthorizedOperation", mooonerror.ErrCodeTxcloudAuth, false},
+	{"InvalidParameter", mooonerror.ErrCodeTxcloudInvalidParam, false}, // 含 InvalidParameterValue
+	{"MissingParameter", mooonerror.ErrCodeTxcloudInvalidParam, false},
+	{"UnknownParameter", mooonerror.ErrCodeTxcloudInvalidParam, false},
+	{"InternalError", mooonerror.ErrCodeTxcloudInternal, true},
+	{"ServiceUnavailable", mooonerror.ErrCodeTxcloudInternal, true},
+	{"ClientError.NetworkError", mooonerror.ErrCodeTxcloudRequest, true}, // SDK 的网络错误
+}
+
+// 余额不足的错误码分散在各产品中（如 ResourceUnavailable.InArrears、FailedOperation.InsufficientBalance），按关键字匹配
+var txcloudBalanceKeywords = []string{"InsufficientBalance", "InArrears", "Arrears", "LowBalance"}
+
+// TranslateError 将腾讯云 SDK 返回的错误转换为 *mooonerror.CError
+// 1. 根据腾讯云的错误码归类为频率限制、鉴权失败、余额不足、参数错误、内部错误等，并标记是否可重试
+// 2. 腾讯云的错误码和 RequestId 记录在 Details 的 tencent_code 和 request_id 中
+// 3. 原始的 *errors.TencentCloudSDKError 作为 Cause，仍可通过 errors.As 或 GetErrCodeAndErrMsg 取得
+// err 为 nil 时返回 nil；不是腾讯云 SDK 错误时以 ErrCodeTxcloudRequest 包装
+func TranslateError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var sdkErr *errors.TencentCloudSDKError
+	if !stderrors.As(err, &sdkErr) {
+		return mooonerror.Wrap(mooonerror.ErrCodeTxcloudRequest, err)
+	}
+
+	code, retryable := classifyErrCode(sdkErr.Code)
+	cerr := mooonerror.NewError(code, err.Error()).WithCause(err).
+		WithDetail("tencent_code", sdkErr.Code).
+		WithDetail("request_id", sdkErr.RequestId)
+	if retryable {
+		cerr = cerr.WithRetryable()
+	}
+	return cerr
+}
+
+// classifyErrCode 将腾讯云的错误码归类，未知的错误码归为 ErrCodeTxcloudAPI，不可重试
+func classifyErrCode(tencentCode string) (int, bool) {
+	for _, keyword := range txcloudBalanceKeywords {
+		if strings.Contains(tencentCode, keyword) {
+			return mooonerror.ErrCodeTxcloudInsufficientBalance, false
+		}
+	}
+	for _, class := range txcloudErrorClasses {
+		if strings.HasPrefix(tencentCode, class.prefix) {
+			return class.code, class.retryable
+		}
+	}
+	return mooonerror.ErrCodeTxcloudAPI, false
+}
@@ -0,0 +1,46 @@
+// Package txcloud
+// Wrote by yijian on 2026/10/18
+package txcloud
+
+import (
+	stderrors "errors"
+	"io"
+	"testing"
+
+	"github.com/eyjian/gomooon/mooonerror"
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/errors"
+)
+
+// go test -v -run="TestTranslateError"
+func TestTranslateError(t *testing.T) {
+	tests := []struct {
+		code      string
+		errCode   int
+		retryable bool
+	}{
+		{"RequestLimitExceeded", mooonerror.ErrCodeTxcloudRateLimited, true},
+		{"AuthFailure.SecretIdNotFound", mooonerror.ErrCodeTxcloudAuth, false},
+		{"ResourceUnavailable.InArrears", mooonerror.ErrCodeTxcloudInsufficientBalance, false},
+		{"InvalidParameterValue.IdCard", mooonerror.ErrCodeTxcloudInvalidParam, false},
+		{"InternalError", mooonerror.ErrCodeTxcloudInternal, true},
+		{"ClientError.NetworkError", mooonerror.ErrCodeTxcloudRequest, true},
+		{"FailedOperation.UnsupportedInvoice", mooonerror.ErrCodeTxcloudAPI, false},
+	}
+	for _, tt := range tests {
+		err := TranslateError(errors.NewTencentCloudSDKError(tt.code, "message", "request-id"))
+		if mooonerror.Code(err) != tt.errCode || mooonerror.IsRetryable(err) != tt.retryable {
+			t.Errorf("%s: code=%d retryable=%v", tt.code, mooonerror.Code(err), mooonerror.IsRetryable(err))
+		}
+		if errCode, _ := GetErrCodeAndErrMsg(err); errCode != tt.code {
+			t.Errorf("%s: GetErrCodeAndErrMsg returned %s", tt.code, errCode)
+		}
+		var cerr *mooonerror.CError
+		if !stderrors.As(err, &cerr) || cerr.Details["request_id"] != "request-id" {
+			t.Errorf("%s: request_id not recorded", tt.code)
+		}
+	}
+
+	if TranslateError(nil) != nil || mooonerror.Code(TranslateError(io.EOF)) != mooonerror.ErrCodeTxcloudRequest {
+		t.Errorf("unexpected result of nil or non-SDK error")
+	}
+}
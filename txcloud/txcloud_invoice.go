@@ -20,8 +20,9 @@ import (
 type InvoiceRecognizer interface {
 	// RecognizeInvoice 识别一张图片或一份 PDF 中的发票，返回结构化结果
 	//
-	// 当腾讯云返回 *errors.TencentCloudSDKError 错误时，原样向上透传，
-	// 调用方可使用 GetErrCodeAndErrMsg 提取错误码和错误描述。
+	// 当腾讯云返回 *errors.TencentCloudSDKError 错误时，经 TranslateError 转换为 *mooonerror.CError，
+	// 调用方可使用 mooonerror.Code 取得归类后的错误码，mooonerror.IsRetryable 判断是否可重试，
+	// 或使用 GetErrCodeAndErrMsg 提取腾讯云的错误码和错误描述。
 	RecognizeInvoice(ctx context.Context, req *InvoiceRequest) (*InvoiceResult, error)
 }
 
@@ -3,7 +3,6 @@
 package txcloud
 
 import (
-	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/errors"
 	"os"
 	"testing"
 )
@@ -38,8 +37,8 @@ func TestBatchVerifyIdcardAndName(t *testing.T) {
 	t.Logf("consistent:%d, inconsistent:%d, fail:%d\n", consistent, inconsistent, fail)
 	for k, v := range data {
 		t.Logf("%s: %+v\n", k, v)
-		if e, ok := v.Err.(*errors.TencentCloudSDKError); ok {
-			t.Logf("e.Code is `%s`, e.Message is `%s`\n", e.Code, e.Message)
+		if errCode, errMsg := GetErrCodeAndErrMsg(v.Err); errCode != "" || errMsg != "" {
+			t.Logf("ErrCode is `%s`, ErrMessage is `%s`\n", errCode, errMsg)
 		}
 	}
 }
@@ -55,8 +54,8 @@ func TestVerifyIdcardAndName(t *testing.T) {
 	txCloud := NewFace(secretId, secretKey)
 	ok, desc, requestId, err := txCloud.VerifyIdcardAndName(idcard, name)
 	if err != nil {
-		if e, ok := err.(*errors.TencentCloudSDKError); ok {
-			t.Logf("e.Code is `%s`, e.Message is `%s`\n", e.Code, e.Message)
+		if errCode, errMsg := GetErrCodeAndErrMsg(err); errCode != "" || errMsg != "" {
+			t.Logf("ErrCode is `%s`, ErrMessage is `%s`\n", errCode, errMsg)
 		} else {
 			t.Logf("%s\n", err.Error())
 		}
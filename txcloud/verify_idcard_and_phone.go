@@ -3,14 +3,15 @@
 package txcloud
 
 import (
+	"context"
 	"encoding/json"
 	"strings"
 	"sync"
 	"sync/atomic"
 )
 import (
+	"github.com/eyjian/gomooon/mooonerror"
 	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
-	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/errors"
 	faceid "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/faceid/v20180301"
 )
 
@@ -23,11 +24,12 @@ type IdcardPhoneTuple struct {
 	Consistent bool   // 是否一致
 	ErrDesc    string // 不一致原因描述
 	RequestId  string
-	Err        error // if txcloudErr, ok := err.(*errors.TencentCloudSDKError); ok {
+	Err        error // 可通过 mooonerror.Code 取得错误码，mooonerror.IsRetryable 判断是否可重试
 }
 
 // BatchVerifyIdcardAndPhone 批量验证身份证号码和手机号是否匹配
 // 腾讯云频率限制：默认接口请求频率限制 20 次/秒
+// 多个 POD 共享频率配额时，可通过 SetLimiter 设置集群共享的限流器（concurrency 仅限制本进程的并发数）
 // 参数说明：
 // concurrency 一次并发验证的个数（值应大于 0）
 // failCount 接口调用失败数达到时中止执行，为 0 表示遇到一个错误即中止执行
@@ -85,6 +87,9 @@ func (t *Face) BatchVerifyIdcardAndPhone(concurrency, failCount int, data map[st
 // 返回值：一致性返回 true，否则返回 false，出错返回 error；第二个返回值为验证结果描述；第三个返回值为 RequestId
 // 参数 idcard 和 bankcard 可含有空格
 func (t *Face) VerifyIdcardAndPhone(idcard, name, phone string) (bool, string, string, error) {
+	if err := t.wait(context.Background()); err != nil {
+		return false, "", "", err
+	}
 	client, _ := faceid.NewClient(t.credential, "", t.clientProfile)
 	request := faceid.NewPhoneVerificationRequest()
 	request.IdCard = common.StringPtr(strings.ReplaceAll(idcard, " ", ""))
@@ -93,12 +98,8 @@ func (t *Face) VerifyIdcardAndPhone(idcard, name, phone string) (bool, string, s
 
 	// 发起请求
 	response, err := client.PhoneVerification(request)
-	if _, ok := err.(*errors.TencentCloudSDKError); ok {
-		//return false, "", fmt.Errorf("a txcloud API error has returned: %s", err.Error())
-		return false, "", "", err
-	}
 	if err != nil {
-		return false, "", "", err
+		return false, "", "", TranslateError(err)
 	}
 
 	// 解析响应
@@ -106,7 +107,7 @@ func (t *Face) VerifyIdcardAndPhone(idcard, name, phone string) (bool, string, s
 	jsonStr := response.ToJsonString()
 	err = json.Unmarshal([]byte(jsonStr), &resp)
 	if err != nil {
-		return false, "", "", err
+		return false, "", "", mooonerror.Wrap(mooonerror.ErrCodeTxcloudResponse, err)
 	}
 
 	// 判断是否一致